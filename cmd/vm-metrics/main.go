@@ -6,8 +6,13 @@ import (
 	"os"
 
 	"github.com/lwmacct/251203-vm-metrics/internal/command"
+	"github.com/lwmacct/251203-vm-metrics/internal/command/admin"
+	configcmd "github.com/lwmacct/251203-vm-metrics/internal/command/config"
+	"github.com/lwmacct/251203-vm-metrics/internal/command/convert"
 	"github.com/lwmacct/251203-vm-metrics/internal/command/export"
 	importcmd "github.com/lwmacct/251203-vm-metrics/internal/command/import"
+	"github.com/lwmacct/251203-vm-metrics/internal/command/lint"
+	"github.com/lwmacct/251203-vm-metrics/internal/command/migrate"
 	"github.com/lwmacct/251203-vm-metrics/internal/command/query"
 	"github.com/lwmacct/251207-go-pkg-version/pkg/version"
 
@@ -23,6 +28,11 @@ func main() {
 			queryCommand(),
 			exportCommand(),
 			importCommand(),
+			migrate.Command,
+			admin.Command,
+			lint.Command,
+			convert.Command,
+			configcmd.Command,
 			version.Command,
 		},
 		Flags: command.BaseFlags(),
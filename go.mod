@@ -12,6 +12,8 @@ require (
 	github.com/knadh/koanf/v2 v2.3.0
 	github.com/lwmacct/251207-go-pkg-version v0.0.2
 	github.com/urfave/cli/v3 v3.6.1
+	go.yaml.in/yaml/v3 v3.0.3
+	golang.org/x/term v0.34.0
 )
 
 require (
@@ -21,7 +23,6 @@ require (
 	github.com/knadh/koanf/maps v0.1.2 // indirect
 	github.com/mitchellh/copystructure v1.2.0 // indirect
 	github.com/mitchellh/reflectwalk v1.0.2 // indirect
-	go.yaml.in/yaml/v3 v3.0.3 // indirect
 	golang.org/x/net v0.43.0 // indirect
 	golang.org/x/sys v0.35.0 // indirect
 )
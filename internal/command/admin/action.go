@@ -0,0 +1,212 @@
+package admin
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/lwmacct/251203-vm-metrics/internal/command"
+	"github.com/lwmacct/251203-vm-metrics/internal/vmapi"
+	"github.com/urfave/cli/v3"
+	"golang.org/x/term"
+)
+
+// getAdmin 创建客户端并返回 Admin 接口
+func getAdmin(cmd *cli.Command) (vmapi.Client, vmapi.Admin, error) {
+	client, err := command.NewClient(command.GetConfig(cmd))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create client: %w", err)
+	}
+	admin, ok := client.(vmapi.Admin)
+	if !ok {
+		return nil, nil, fmt.Errorf("client does not support admin API")
+	}
+	return client, admin, nil
+}
+
+// confirm 在终端上请求确认，--yes 时直接通过
+// stdin 不是终端且未指定 --yes 时拒绝执行，避免脚本中误删
+func confirm(cmd *cli.Command, prompt string) error {
+	if cmd.Bool("yes") {
+		return nil
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return fmt.Errorf("confirmation required: use --yes to run non-interactively")
+	}
+
+	_, _ = fmt.Fprintf(os.Stderr, "%s [y/N]: ", prompt)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("aborted")
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	default:
+		return fmt.Errorf("aborted")
+	}
+}
+
+// actionDeleteSeries 删除匹配的时间序列
+// 先列出匹配的序列，--dry-run 时到此为止，否则确认后删除
+func actionDeleteSeries(ctx context.Context, cmd *cli.Command) error {
+	match := cmd.Args().Slice()
+	if len(match) == 0 {
+		return fmt.Errorf("at least one match selector is required")
+	}
+
+	client, admin, err := getAdmin(cmd)
+	if err != nil {
+		return err
+	}
+
+	start, err := command.ParseTimeFlag(cmd, "start")
+	if err != nil {
+		return err
+	}
+	result, err := client.Series(ctx, match, start, time.Time{}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to list matching series: %w", err)
+	}
+
+	if cmd.Bool("dry-run") {
+		w, err := command.NewWriter(command.GetConfig(cmd))
+		if err != nil {
+			return err
+		}
+		if err := w.WriteSeries(result.Series); err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(os.Stderr, "%d series would be deleted (dry run)\n", len(result.Series))
+		return nil
+	}
+
+	prompt := fmt.Sprintf("Delete %d series matching %s (all time ranges)?", len(result.Series), strings.Join(match, ", "))
+	if len(result.Series) == 0 {
+		// series 接口只覆盖部分时间范围，找不到序列时仍允许删除
+		prompt = fmt.Sprintf("No series found in the listed time range; delete all series matching %s anyway?", strings.Join(match, ", "))
+	}
+	if err := confirm(cmd, prompt); err != nil {
+		return err
+	}
+
+	if err := admin.DeleteSeries(ctx, match); err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintln(os.Stderr, "Deleted")
+	return nil
+}
+
+// actionTSDBStatus 输出 TSDB 基数统计
+func actionTSDBStatus(ctx context.Context, cmd *cli.Command) error {
+	_, admin, err := getAdmin(cmd)
+	if err != nil {
+		return err
+	}
+
+	date, err := command.ParseTimeFlag(cmd, "date")
+	if err != nil {
+		return err
+	}
+
+	status, err := admin.TSDBStatus(ctx, &vmapi.TSDBStatusOptions{
+		TopN:       cmd.Int("top-n"),
+		Date:       date,
+		FocusLabel: cmd.String("focus-label"),
+		Match:      cmd.Args().Slice(),
+	})
+	if err != nil {
+		return err
+	}
+
+	w, err := command.NewWriter(command.GetConfig(cmd))
+	if err != nil {
+		return err
+	}
+	return w.WriteTSDBStatus(status)
+}
+
+// actionSnapshotCreate 创建快照
+func actionSnapshotCreate(ctx context.Context, cmd *cli.Command) error {
+	_, admin, err := getAdmin(cmd)
+	if err != nil {
+		return err
+	}
+	name, err := admin.SnapshotCreate(ctx)
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Println(name)
+	return nil
+}
+
+// actionSnapshotList 列出所有快照
+func actionSnapshotList(ctx context.Context, cmd *cli.Command) error {
+	_, admin, err := getAdmin(cmd)
+	if err != nil {
+		return err
+	}
+	names, err := admin.SnapshotList(ctx)
+	if err != nil {
+		return err
+	}
+
+	w, err := command.NewWriter(command.GetConfig(cmd))
+	if err != nil {
+		return err
+	}
+	return w.WriteStrings(names)
+}
+
+// actionSnapshotDelete 删除指定快照
+func actionSnapshotDelete(ctx context.Context, cmd *cli.Command) error {
+	names := cmd.Args().Slice()
+	if len(names) == 0 {
+		return fmt.Errorf("snapshot name is required")
+	}
+
+	_, admin, err := getAdmin(cmd)
+	if err != nil {
+		return err
+	}
+	if err := confirm(cmd, fmt.Sprintf("Delete snapshot %s?", strings.Join(names, ", "))); err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		if err := admin.SnapshotDelete(ctx, name); err != nil {
+			return fmt.Errorf("delete snapshot %s: %w", name, err)
+		}
+		_, _ = fmt.Fprintf(os.Stderr, "Deleted snapshot %s\n", name)
+	}
+	return nil
+}
+
+// actionSnapshotDeleteAll 删除所有快照
+func actionSnapshotDeleteAll(ctx context.Context, cmd *cli.Command) error {
+	_, admin, err := getAdmin(cmd)
+	if err != nil {
+		return err
+	}
+
+	names, err := admin.SnapshotList(ctx)
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		_, _ = fmt.Fprintln(os.Stderr, "No snapshots")
+		return nil
+	}
+	if err := confirm(cmd, fmt.Sprintf("Delete all %d snapshots?", len(names))); err != nil {
+		return err
+	}
+
+	if err := admin.SnapshotDeleteAll(ctx); err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(os.Stderr, "Deleted %d snapshots\n", len(names))
+	return nil
+}
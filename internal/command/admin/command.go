@@ -0,0 +1,115 @@
+// Package admin 提供 vm-metrics admin 命令
+package admin
+
+import (
+	"github.com/lwmacct/251203-vm-metrics/internal/command"
+	"github.com/urfave/cli/v3"
+)
+
+// Command admin 根命令
+var Command = &cli.Command{
+	Name:   "admin",
+	Usage:  "管理操作: 删除序列、TSDB 状态、快照",
+	Before: command.BeforeLoadConfig,
+	Commands: []*cli.Command{
+		deleteSeriesCommand,
+		tsdbStatusCommand,
+		snapshotCommand,
+	},
+	Flags: adminFlags(),
+}
+
+// adminFlags 返回管理命令的 flags (基础 + 输出)
+func adminFlags() []cli.Flag {
+	flags := append(command.BaseFlags(),
+		&cli.StringFlag{
+			Name:    "output-format",
+			Aliases: []string{"o"},
+			Usage:   "输出格式: table, json, csv, prom, markdown, html",
+			Value:   command.Defaults.Output.Format,
+		},
+		&cli.BoolFlag{
+			Name:  "output-no-headers",
+			Usage: "禁用表头输出",
+			Value: command.Defaults.Output.NoHeaders,
+		},
+	)
+	flags = append(flags, command.OutputDisplayFlags()...)
+	return append(flags,
+		&cli.BoolFlag{
+			Name:    "yes",
+			Aliases: []string{"y"},
+			Usage:   "跳过确认提示",
+		},
+	)
+}
+
+// deleteSeriesCommand delete-series 子命令
+var deleteSeriesCommand = &cli.Command{
+	Name:      "delete-series",
+	Usage:     "删除匹配的时间序列 (全部时间范围)",
+	ArgsUsage: "<match>...",
+	Action:    actionDeleteSeries,
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "仅列出将被删除的序列",
+		},
+		&cli.StringFlag{
+			Name:  "start",
+			Usage: "列出序列时的开始时间 (默认由服务端决定，通常为最近一天)",
+		},
+	},
+}
+
+// tsdbStatusCommand tsdb-status 子命令
+var tsdbStatusCommand = &cli.Command{
+	Name:      "tsdb-status",
+	Usage:     "显示 TSDB 基数统计 (同 query cardinality)",
+	ArgsUsage: "[match]...",
+	Action:    actionTSDBStatus,
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:  "top-n",
+			Usage: "每类统计返回的条目数",
+			Value: 10,
+		},
+		&cli.StringFlag{
+			Name:  "date",
+			Usage: "统计日期 (如 today, yesterday, 2024-05-01)",
+		},
+		&cli.StringFlag{
+			Name:  "focus-label",
+			Usage: "额外统计该标签各个值的序列数",
+		},
+	},
+}
+
+// snapshotCommand snapshot 子命令
+var snapshotCommand = &cli.Command{
+	Name:  "snapshot",
+	Usage: "管理存储快照 (集群模式需将 --server-url 指向 vmstorage)",
+	Commands: []*cli.Command{
+		{
+			Name:   "create",
+			Usage:  "创建快照",
+			Action: actionSnapshotCreate,
+		},
+		{
+			Name:   "list",
+			Usage:  "列出所有快照",
+			Action: actionSnapshotList,
+		},
+		{
+			Name:      "delete",
+			Usage:     "删除指定快照",
+			ArgsUsage: "<name>...",
+			Action:    actionSnapshotDelete,
+		},
+		{
+			Name:   "delete-all",
+			Usage:  "删除所有快照",
+			Action: actionSnapshotDeleteAll,
+		},
+	},
+}
@@ -3,8 +3,6 @@ package command
 
 import (
 	"context"
-	"fmt"
-	"time"
 
 	"github.com/lwmacct/251203-vm-metrics/internal/config"
 	"github.com/lwmacct/251207-go-pkg-version/pkg/version"
@@ -22,7 +20,18 @@ const MetaKeyConfig = "config"
 // BeforeLoadConfig 在 Action 执行前加载配置
 // 将配置存入 cmd.Metadata 供后续 Action 使用
 func BeforeLoadConfig(ctx context.Context, cmd *cli.Command) (context.Context, error) {
-	cfg, err := config.Load(cmd, cmd.String("config"), version.GetAppRawName())
+	return beforeLoad(ctx, cmd, config.Load)
+}
+
+// BeforeLoadConfigWithoutProfile 与 BeforeLoadConfig 相同，但不应用 profile
+// 用于只读写 profile 列表的命令，current_profile 指向已删除的 profile 时不会失败
+func BeforeLoadConfigWithoutProfile(ctx context.Context, cmd *cli.Command) (context.Context, error) {
+	return beforeLoad(ctx, cmd, config.LoadWithoutProfile)
+}
+
+// beforeLoad 使用 load 加载配置并存入 cmd.Metadata
+func beforeLoad(ctx context.Context, cmd *cli.Command, load func(*cli.Command, string, string) (*config.Config, error)) (context.Context, error) {
+	cfg, err := load(cmd, cmd.String("config"), version.GetAppRawName())
 	if err != nil {
 		return ctx, err
 	}
@@ -35,18 +44,21 @@ func BeforeLoadConfig(ctx context.Context, cmd *cli.Command) (context.Context, e
 }
 
 // GetConfig 从 cmd.Metadata 获取已加载的配置
+// Before 钩子运行在父命令上，因此沿命令链向上查找
 func GetConfig(cmd *cli.Command) *config.Config {
-	if cmd.Metadata == nil {
-		return nil
-	}
-	if cfg, ok := cmd.Metadata[MetaKeyConfig].(*config.Config); ok {
-		return cfg
+	for _, c := range cmd.Lineage() {
+		if c.Metadata == nil {
+			continue
+		}
+		if cfg, ok := c.Metadata[MetaKeyConfig].(*config.Config); ok {
+			return cfg
+		}
 	}
 	return nil
 }
 
 // BaseFlags 返回所有命令共享的基础 flags
-// 包括：配置文件、服务器、认证、TLS 配置
+// 包括：配置文件、profile、服务器、认证、TLS 配置
 func BaseFlags() []cli.Flag {
 	return []cli.Flag{
 		// 配置文件
@@ -55,6 +67,10 @@ func BaseFlags() []cli.Flag {
 			Aliases: []string{"c"},
 			Usage:   "配置文件路径",
 		},
+		&cli.StringFlag{
+			Name:  "profile",
+			Usage: "使用的 profile 名称 (覆盖配置文件中的 current_profile)",
+		},
 		// 服务器配置
 		&cli.StringFlag{
 			Name:  "server-url",
@@ -70,6 +86,55 @@ func BaseFlags() []cli.Flag {
 			Usage: "请求超时时间",
 			Value: Defaults.Server.Timeout,
 		},
+		// 集群模式配置
+		&cli.StringFlag{
+			Name:  "server-select-url",
+			Usage: "集群模式 vmselect 地址 (默认: --server-url)",
+		},
+		&cli.StringFlag{
+			Name:  "server-insert-url",
+			Usage: "集群模式 vminsert 地址 (默认: --server-url)",
+		},
+		&cli.StringFlag{
+			Name:    "server-tenant",
+			Aliases: []string{"tenant"},
+			Usage:   "集群模式租户 ID (accountID 或 accountID:projectID)",
+		},
+		// 重试与熔断
+		&cli.IntFlag{
+			Name:  "server-retry-max-attempts",
+			Usage: "最大尝试次数 (含首次)，1 表示不重试",
+			Value: Defaults.Server.RetryMaxAttempts,
+		},
+		&cli.DurationFlag{
+			Name:  "server-retry-wait",
+			Usage: "首次重试等待时间，之后指数增长并加入随机抖动",
+			Value: Defaults.Server.RetryWait,
+		},
+		&cli.DurationFlag{
+			Name:  "server-retry-max-wait",
+			Usage: "单次重试等待上限 (同时限制 Retry-After)",
+			Value: Defaults.Server.RetryMaxWait,
+		},
+		&cli.IntSliceFlag{
+			Name:  "server-retry-status-codes",
+			Usage: "需要重试的 HTTP 状态码",
+			Value: Defaults.Server.RetryStatusCodes,
+		},
+		&cli.BoolFlag{
+			Name:  "server-retry-network-errors",
+			Usage: "是否重试网络错误 (连接失败、连接被重置)",
+			Value: Defaults.Server.RetryNetworkErrors,
+		},
+		&cli.IntFlag{
+			Name:  "server-breaker-threshold",
+			Usage: "连续失败多少次后熔断 (0 表示禁用)",
+		},
+		&cli.DurationFlag{
+			Name:  "server-breaker-cooldown",
+			Usage: "熔断持续时间",
+			Value: Defaults.Server.BreakerCooldown,
+		},
 		// 认证配置
 		&cli.StringFlag{
 			Name:  "auth-type",
@@ -105,46 +170,48 @@ func BaseFlags() []cli.Flag {
 			Usage: "跳过证书验证",
 			Value: Defaults.TLS.SkipVerify,
 		},
+		// 时间解析
+		&cli.StringFlag{
+			Name:  "tz",
+			Usage: "解析不带时区的时间表达式所用的时区 (如 UTC, Asia/Shanghai，默认本地时区)",
+		},
 	}
 }
 
 // NewClient 从配置创建 vmapi 客户端
 func NewClient(cfg *config.Config) (vmapi.Client, error) {
-	return vmapi.NewClient(&vmapi.ClientConfig{
-		URL:        cfg.Server.URL,
-		PathPrefix: cfg.Server.PathPrefix,
-		Timeout:    cfg.Server.Timeout,
-		AuthType:   cfg.Auth.Type,
-		User:       cfg.Auth.User,
-		Password:   cfg.Auth.Password,
-		Token:      cfg.Auth.Token,
-		CAPath:     cfg.TLS.CA,
-		CertPath:   cfg.TLS.Cert,
-		KeyPath:    cfg.TLS.Key,
-		SkipVerify: cfg.TLS.SkipVerify,
+	return NewProfileClient(&config.ProfileConfig{
+		Server: cfg.Server,
+		Auth:   cfg.Auth,
+		TLS:    cfg.TLS,
 	})
 }
 
-// ParseTime 解析时间字符串，支持多种格式
-// - 空字符串: 返回零值
-// - "now": 返回当前时间
-// - RFC3339: 如 "2024-01-01T00:00:00Z"
-// - Unix 时间戳: 如 "1704067200"
-func ParseTime(s string) (time.Time, error) {
-	if s == "" {
-		return time.Time{}, nil
-	}
-	if s == "now" {
-		return time.Now(), nil
-	}
-	// 尝试 RFC3339
-	if t, err := time.Parse(time.RFC3339, s); err == nil {
-		return t, nil
-	}
-	// 尝试 Unix 时间戳
-	var ts int64
-	if _, err := fmt.Sscanf(s, "%d", &ts); err == nil {
-		return time.Unix(ts, 0), nil
-	}
-	return time.Time{}, fmt.Errorf("invalid time format: %s (use RFC3339 or Unix timestamp)", s)
+// NewProfileClient 从一套连接配置 (server/auth/tls) 创建 vmapi 客户端
+func NewProfileClient(p *config.ProfileConfig) (vmapi.Client, error) {
+	return vmapi.NewClient(&vmapi.ClientConfig{
+		URL:        p.Server.URL,
+		PathPrefix: p.Server.PathPrefix,
+		Timeout:    p.Server.Timeout,
+		SelectURL:  p.Server.SelectURL,
+		InsertURL:  p.Server.InsertURL,
+		TenantID:   p.Server.Tenant,
+
+		RetryMaxAttempts:   p.Server.RetryMaxAttempts,
+		RetryWait:          p.Server.RetryWait,
+		RetryMaxWait:       p.Server.RetryMaxWait,
+		RetryStatusCodes:   p.Server.RetryStatusCodes,
+		RetryNetworkErrors: p.Server.RetryNetworkErrors,
+		BreakerThreshold:   p.Server.BreakerThreshold,
+		BreakerCooldown:    p.Server.BreakerCooldown,
+
+		AuthType:   p.Auth.Type,
+		User:       p.Auth.User,
+		Password:   p.Auth.Password,
+		Token:      p.Auth.Token,
+		CAPath:     p.TLS.CA,
+		CertPath:   p.TLS.Cert,
+		KeyPath:    p.TLS.Key,
+		SkipVerify: p.TLS.SkipVerify,
+	})
 }
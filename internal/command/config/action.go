@@ -0,0 +1,88 @@
+package configcmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/knadh/koanf/providers/structs"
+	"github.com/knadh/koanf/v2"
+	"github.com/lwmacct/251203-vm-metrics/internal/command"
+	"github.com/lwmacct/251203-vm-metrics/internal/config"
+	"github.com/lwmacct/251207-go-pkg-version/pkg/version"
+	"github.com/urfave/cli/v3"
+)
+
+// actionUseProfile 切换当前 profile
+func actionUseProfile(ctx context.Context, cmd *cli.Command) error {
+	name := cmd.Args().First()
+	if name == "" {
+		return fmt.Errorf("profile name is required")
+	}
+
+	cfg := command.GetConfig(cmd)
+	if _, ok := cfg.Profiles[name]; !ok {
+		return fmt.Errorf("profile not found: %s", name)
+	}
+
+	path := config.FindConfigFile(cmd.String("config"), version.GetAppRawName())
+	if path == "" {
+		return fmt.Errorf("no config file found (use --config to specify one)")
+	}
+
+	if err := config.SetCurrentProfile(path, name); err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintf(os.Stdout, "Switched to profile %q (%s)\n", name, path)
+	return nil
+}
+
+// actionListProfiles 列出所有 profile，当前 profile 以 * 标记
+func actionListProfiles(ctx context.Context, cmd *cli.Command) error {
+	cfg := command.GetConfig(cmd)
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	defer func() { _ = tw.Flush() }()
+
+	_, _ = fmt.Fprintln(tw, "CURRENT\tNAME\tSERVER\tTENANT")
+	for _, name := range cfg.ProfileNames() {
+		p := cfg.Profiles[name]
+		current := ""
+		if name == cfg.CurrentProfile {
+			current = "*"
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", current, name, p.Server.URL, p.Server.Tenant)
+	}
+
+	return nil
+}
+
+// actionShow 以 key: value 形式输出当前生效的配置
+// profile 列表由 list-profiles 展示，这里不再重复输出
+func actionShow(ctx context.Context, cmd *cli.Command) error {
+	cfg := command.GetConfig(cmd).Redacted()
+
+	k := koanf.New(".")
+	if err := k.Load(structs.Provider(cfg, "koanf"), nil); err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if path := config.FindConfigFile(cmd.String("config"), version.GetAppRawName()); path != "" {
+		_, _ = fmt.Fprintf(os.Stdout, "# config file: %s\n", path)
+	}
+
+	keys := k.Keys()
+	sort.Strings(keys)
+	for _, key := range keys {
+		if strings.HasPrefix(key, "profiles.") {
+			continue
+		}
+		_, _ = fmt.Fprintf(os.Stdout, "%s: %v\n", key, k.Get(key))
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+// Package configcmd 提供 vm-metrics config 命令
+// 注意：包名使用 configcmd 以避免与 internal/config 冲突
+package configcmd
+
+import (
+	"github.com/lwmacct/251203-vm-metrics/internal/command"
+
+	"github.com/urfave/cli/v3"
+)
+
+// Command config 根命令
+// 配置在各子命令上加载：use-profile/list-profiles 不应用 profile，
+// 以便 current_profile 指向已删除的 profile 时仍能切换回可用的 profile
+var Command = &cli.Command{
+	Name:  "config",
+	Usage: "管理配置文件与 profile",
+	Commands: []*cli.Command{
+		useProfileCommand,
+		listProfilesCommand,
+		showCommand,
+	},
+	Flags: command.BaseFlags(),
+}
+
+// useProfileCommand use-profile 子命令
+var useProfileCommand = &cli.Command{
+	Name:      "use-profile",
+	Usage:     "设置配置文件中的 current_profile",
+	ArgsUsage: "<name>",
+	Before:    command.BeforeLoadConfigWithoutProfile,
+	Action:    actionUseProfile,
+}
+
+// listProfilesCommand list-profiles 子命令
+var listProfilesCommand = &cli.Command{
+	Name:   "list-profiles",
+	Usage:  "列出所有 profile",
+	Before: command.BeforeLoadConfigWithoutProfile,
+	Action: actionListProfiles,
+}
+
+// showCommand show 子命令
+var showCommand = &cli.Command{
+	Name:   "show",
+	Usage:  "显示当前生效的配置 (敏感信息已脱敏)",
+	Before: command.BeforeLoadConfig,
+	Action: actionShow,
+}
@@ -0,0 +1,45 @@
+package configcmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestUseProfileRecovers 验证 current_profile 指向已删除的 profile 时，use-profile 仍能切换到可用的 profile
+func TestUseProfileRecovers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := `current_profile: deleted
+profiles:
+  prod:
+    server:
+      url: http://prod:8481
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("写入配置文件失败: %v", err)
+	}
+
+	run := func(args ...string) error {
+		return Command.Run(context.Background(), append([]string{"config"}, args...))
+	}
+
+	if err := run("show", "--config", path); err == nil || !strings.Contains(err.Error(), "profile not found: deleted") {
+		t.Errorf("show err = %v, want profile not found", err)
+	}
+	if err := run("use-profile", "--config", path, "missing"); err == nil {
+		t.Error("切换到不存在的 profile 应返回错误")
+	}
+	if err := run("use-profile", "--config", path, "prod"); err != nil {
+		t.Fatalf("use-profile: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if !strings.HasPrefix(string(data), "current_profile: prod\n") {
+		t.Errorf("current_profile 未更新:\n%s", data)
+	}
+	if err := run("show", "--config", path); err != nil {
+		t.Errorf("修复后 show 失败: %v", err)
+	}
+}
@@ -0,0 +1,165 @@
+package convert
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/lwmacct/251203-vm-metrics/internal/lineproto"
+	"github.com/urfave/cli/v3"
+)
+
+// convertStats 转换统计
+type convertStats struct {
+	Series  int64
+	Samples int64
+}
+
+// actionConvert 流式转换输入文件或 stdin
+func actionConvert(ctx context.Context, cmd *cli.Command) error {
+	from, to := cmd.String("from"), cmd.String("to")
+	readOpts := lineproto.ConvertOptions{CSVFormat: cmd.String("from-csv-format"), InfluxPrecision: cmd.String("from-precision")}
+	writeOpts := lineproto.ConvertOptions{CSVFormat: cmd.String("to-csv-format"), InfluxPrecision: cmd.String("to-precision")}
+	// 先校验输出格式，避免创建空的输出文件
+	if _, err := lineproto.NewRowWriter(to, io.Discard, writeOpts); err != nil {
+		return fmt.Errorf("invalid --to: %w", err)
+	}
+
+	r, err := openInput(cmd)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = r.Close() }()
+	rr, err := lineproto.NewRowReader(from, r, readOpts)
+	if err != nil {
+		return fmt.Errorf("invalid --from: %w", err)
+	}
+
+	w, err := createOutput(cmd)
+	if err != nil {
+		return err
+	}
+	rw, _ := lineproto.NewRowWriter(to, w, writeOpts)
+
+	started := time.Now()
+	stats, err := convert(ctx, rr, rw)
+	if err != nil {
+		err = fmt.Errorf("%s -> %s: %w", from, to, err)
+	}
+	if cerr := w.Close(); err == nil && cerr != nil {
+		err = fmt.Errorf("failed to close output: %w", cerr)
+	}
+	_, _ = fmt.Fprintf(os.Stderr, "Converted %d series rows, %d samples in %s\n",
+		stats.Series, stats.Samples, time.Since(started).Round(time.Millisecond))
+	return err
+}
+
+// convert 逐行读取并写出，读取错误带有输入的行号
+func convert(ctx context.Context, rr lineproto.RowReader, rw lineproto.RowWriter) (convertStats, error) {
+	var stats convertStats
+	for {
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+		row, err := rr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return stats, fmt.Errorf("failed to read input: %w", err)
+		}
+		if err := rw.Write(row); err != nil {
+			return stats, fmt.Errorf("failed to write output: %w", err)
+		}
+		stats.Series++
+		stats.Samples += int64(len(row.Values))
+	}
+	if err := rw.Flush(); err != nil {
+		return stats, fmt.Errorf("failed to write output: %w", err)
+	}
+	return stats, nil
+}
+
+// gzipMagic gzip 文件头
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// openInput 打开 --input、位置参数或 stdin，gzip 输入按文件头自动解压
+func openInput(cmd *cli.Command) (io.ReadCloser, error) {
+	path := cmd.String("input")
+	if path == "" && cmd.Args().Len() > 0 {
+		path = cmd.Args().First()
+	}
+
+	var f io.ReadCloser = os.Stdin
+	if path != "" && path != "-" {
+		var err error
+		if f, err = os.Open(path); err != nil {
+			return nil, fmt.Errorf("failed to open input file: %w", err)
+		}
+	}
+
+	br := bufio.NewReaderSize(f, 1<<20)
+	if head, _ := br.Peek(len(gzipMagic)); !bytes.Equal(head, gzipMagic) {
+		return readCloser{Reader: br, Closer: f}, nil
+	}
+	gr, err := gzip.NewReader(br)
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("failed to create gzip reader: %w", err)
+	}
+	return readCloser{Reader: gr, Closer: f}, nil
+}
+
+// createOutput 创建 --output 或 stdout，--gzip 或 .gz 后缀时压缩
+func createOutput(cmd *cli.Command) (io.WriteCloser, error) {
+	path := cmd.String("output")
+
+	var f io.WriteCloser = nopCloser{os.Stdout}
+	if path != "" && path != "-" {
+		var err error
+		if f, err = os.Create(path); err != nil {
+			return nil, fmt.Errorf("failed to create output file: %w", err)
+		}
+	}
+
+	if cmd.Bool("gzip") || strings.HasSuffix(path, ".gz") {
+		return gzipWriteCloser{Writer: gzip.NewWriter(f), file: f}, nil
+	}
+	return f, nil
+}
+
+// readCloser 组合解压后的 Reader 与原始文件的 Closer
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// nopCloser 不关闭 stdout
+type nopCloser struct {
+	io.Writer
+}
+
+// Close 实现 io.Closer
+func (nopCloser) Close() error { return nil }
+
+// gzipWriteCloser 关闭时先写出 gzip 尾部再关闭文件
+type gzipWriteCloser struct {
+	*gzip.Writer
+	file io.Closer
+}
+
+// Close 实现 io.Closer
+func (w gzipWriteCloser) Close() error {
+	err := w.Writer.Close()
+	if cerr := w.file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,64 @@
+// Package convert 提供 vm-metrics convert 命令
+package convert
+
+import (
+	"strings"
+
+	"github.com/lwmacct/251203-vm-metrics/internal/lineproto"
+	"github.com/urfave/cli/v3"
+)
+
+// Command convert 根命令
+// 只在本地转换格式，不读取配置、不连接服务器
+var Command = &cli.Command{
+	Name:      "convert",
+	Usage:     "离线转换时序数据格式 (" + strings.Join(lineproto.Formats, ", ") + ")",
+	ArgsUsage: "[file]",
+	Action:    actionConvert,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "from",
+			Usage:    "输入格式: " + strings.Join(lineproto.Formats, ", "),
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:     "to",
+			Usage:    "输出格式: " + strings.Join(lineproto.Formats, ", "),
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:    "input",
+			Aliases: []string{"i"},
+			Usage:   "输入文件路径 (默认: stdin)，gzip 压缩的输入自动解压",
+		},
+		&cli.StringFlag{
+			Name:    "output",
+			Aliases: []string{"o"},
+			Usage:   "输出文件路径 (默认: stdout)",
+		},
+		&cli.BoolFlag{
+			Name:  "gzip",
+			Usage: "启用 gzip 压缩输出 (输出文件以 .gz 结尾时自动启用)",
+		},
+		&cli.StringFlag{
+			Name:  "from-csv-format",
+			Usage: "输入 CSV 的列定义，同 mc-vmexport csv --csv-format",
+			Value: lineproto.DefaultCSVFormat,
+		},
+		&cli.StringFlag{
+			Name:  "to-csv-format",
+			Usage: "输出 CSV 的列定义，同 mc-vmexport csv --csv-format",
+			Value: lineproto.DefaultCSVFormat,
+		},
+		&cli.StringFlag{
+			Name:  "from-precision",
+			Usage: "输入 Influx 时间戳精度: " + strings.Join(lineproto.InfluxPrecisions, ", "),
+			Value: "ns",
+		},
+		&cli.StringFlag{
+			Name:  "to-precision",
+			Usage: "输出 Influx 时间戳精度: " + strings.Join(lineproto.InfluxPrecisions, ", "),
+			Value: "ns",
+		},
+	},
+}
@@ -8,6 +8,7 @@ import (
 	"os"
 
 	"github.com/lwmacct/251203-vm-metrics/internal/command"
+	"github.com/lwmacct/251203-vm-metrics/internal/relabel"
 	"github.com/lwmacct/251203-vm-metrics/internal/vmapi"
 	"github.com/urfave/cli/v3"
 )
@@ -41,11 +42,20 @@ func buildExportOptions(cmd *cli.Command) (*vmapi.ExportOptions, error) {
 		return nil, fmt.Errorf("at least one match selector is required")
 	}
 
-	start, err := command.ParseTime(cmd.String("start"))
+	start, err := command.ParseTimeFlag(cmd, "start")
 	if err != nil {
 		return nil, err
 	}
-	end, err := command.ParseTime(cmd.String("end"))
+	end, err := command.ParseTimeFlag(cmd, "end")
+	if err != nil {
+		return nil, err
+	}
+
+	extraLabels, err := command.ExtraLabels(cmd)
+	if err != nil {
+		return nil, err
+	}
+	extraFilters, err := command.ExtraFilters(cmd)
 	if err != nil {
 		return nil, err
 	}
@@ -57,41 +67,28 @@ func buildExportOptions(cmd *cli.Command) (*vmapi.ExportOptions, error) {
 		MaxRowsPerLine: cmd.Int("max-rows-per-line"),
 		CSVFormat:      cmd.String("csv-format"),
 		ReduceMemUsage: cmd.Bool("reduce-mem-usage"),
+		ExtraLabels:    extraLabels,
+		ExtraFilters:   extraFilters,
 	}, nil
 }
 
 // actionExportJSON 导出 JSON Line 格式
 func actionExportJSON(ctx context.Context, cmd *cli.Command) error {
-	if cmd.Args().Len() == 0 {
-		return cli.ShowAppHelp(cmd)
-	}
-
-	client, err := command.NewClient(command.GetConfig(cmd))
-	if err != nil {
-		return fmt.Errorf("failed to create client: %w", err)
-	}
-
-	opts, err := buildExportOptions(cmd)
-	if err != nil {
-		return err
-	}
-
-	w, err := getWriter(cmd)
-	if err != nil {
-		return err
-	}
-	defer func() { _ = w.Close() }()
-
-	exporter, ok := client.(vmapi.Exporter)
-	if !ok {
-		return fmt.Errorf("client does not support export")
-	}
-
-	return exporter.ExportJSON(ctx, w, opts)
+	return runExport(ctx, cmd, vmapi.ExportFormatJSON)
 }
 
 // actionExportCSV 导出 CSV 格式
 func actionExportCSV(ctx context.Context, cmd *cli.Command) error {
+	return runExport(ctx, cmd, vmapi.ExportFormatCSV)
+}
+
+// actionExportNative 导出 Native 二进制格式
+func actionExportNative(ctx context.Context, cmd *cli.Command) error {
+	return runExport(ctx, cmd, vmapi.ExportFormatNative)
+}
+
+// runExport 执行导出，指定 --chunk 时按时间窗口分片导出
+func runExport(ctx context.Context, cmd *cli.Command, format vmapi.ExportFormat) error {
 	if cmd.Args().Len() == 0 {
 		return cli.ShowAppHelp(cmd)
 	}
@@ -106,34 +103,36 @@ func actionExportCSV(ctx context.Context, cmd *cli.Command) error {
 		return err
 	}
 
-	w, err := getWriter(cmd)
-	if err != nil {
-		return err
-	}
-	defer func() { _ = w.Close() }()
-
 	exporter, ok := client.(vmapi.Exporter)
 	if !ok {
 		return fmt.Errorf("client does not support export")
 	}
 
-	return exporter.ExportCSV(ctx, w, opts)
-}
-
-// actionExportNative 导出 Native 二进制格式
-func actionExportNative(ctx context.Context, cmd *cli.Command) error {
-	if cmd.Args().Len() == 0 {
-		return cli.ShowAppHelp(cmd)
+	var export exportFunc
+	switch format {
+	case vmapi.ExportFormatCSV:
+		export = exporter.ExportCSV
+	case vmapi.ExportFormatNative:
+		export = exporter.ExportNative
+	default:
+		export = exporter.ExportJSON
 	}
 
-	client, err := command.NewClient(command.GetConfig(cmd))
-	if err != nil {
-		return fmt.Errorf("failed to create client: %w", err)
+	if path := cmd.String("relabel-config"); path != "" {
+		if format != vmapi.ExportFormatJSON {
+			return fmt.Errorf("--relabel-config only applies to json export")
+		}
+		rules, err := relabel.LoadFile(path)
+		if err != nil {
+			return err
+		}
+		processor := relabel.NewProcessor(rules)
+		defer func() { _, _ = fmt.Fprintf(os.Stderr, "Relabel summary: %s\n", processor) }()
+		export = relabeledExport(export, processor)
 	}
 
-	opts, err := buildExportOptions(cmd)
-	if err != nil {
-		return err
+	if cmd.String("chunk") != "" {
+		return runChunkedExport(ctx, cmd, format, export, opts)
 	}
 
 	w, err := getWriter(cmd)
@@ -142,10 +141,17 @@ func actionExportNative(ctx context.Context, cmd *cli.Command) error {
 	}
 	defer func() { _ = w.Close() }()
 
-	exporter, ok := client.(vmapi.Exporter)
-	if !ok {
-		return fmt.Errorf("client does not support export")
-	}
+	return export(ctx, w, opts)
+}
 
-	return exporter.ExportNative(ctx, w, opts)
+// relabeledExport 包装 JSON Line 导出，写出前逐行执行 relabel 规则
+// 每次调用 (包括每个分片) 使用独立的行缓冲，统计在 processor 中汇总
+func relabeledExport(export exportFunc, processor *relabel.Processor) exportFunc {
+	return func(ctx context.Context, w io.Writer, opts *vmapi.ExportOptions) error {
+		rw := processor.NewWriter(w)
+		if err := export(ctx, rw, opts); err != nil {
+			return err
+		}
+		return rw.Close()
+	}
 }
@@ -0,0 +1,487 @@
+package export
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/lwmacct/251203-vm-metrics/internal/command"
+	"github.com/lwmacct/251203-vm-metrics/internal/util"
+	"github.com/lwmacct/251203-vm-metrics/internal/vmapi"
+	"github.com/urfave/cli/v3"
+)
+
+// exportFunc 单次导出调用 (ExportJSON/ExportCSV/ExportNative)
+type exportFunc func(ctx context.Context, w io.Writer, opts *vmapi.ExportOptions) error
+
+// checkpoint 分片导出进度，用于 --resume
+// 除时间范围与进度外的字段决定输出内容的形态，恢复时必须与当前参数一致
+type checkpoint struct {
+	Format       string   `json:"format"`
+	Match        []string `json:"match"`
+	CSVFormat    string   `json:"csv_format,omitempty"`
+	Gzip         bool     `json:"gzip,omitempty"`
+	SplitFiles   bool     `json:"split_files,omitempty"`
+	ExtraLabels  []string `json:"extra_labels,omitempty"`
+	ExtraFilters []string `json:"extra_filters,omitempty"`
+	Relabel      string   `json:"relabel,omitempty"` // relabel 配置文件内容的 SHA-256
+
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+	Chunk  string    `json:"chunk"`
+	Done   []int     `json:"done"`   // 已完成的分片序号
+	Offset int64     `json:"offset"` // 顺序输出模式下已完整写入的字节数
+}
+
+// mismatch 返回与 want 不一致的第一个参数名，一致时返回空字符串
+func (cp *checkpoint) mismatch(want *checkpoint) string {
+	switch {
+	case cp.Format != want.Format:
+		return "format"
+	case !slices.Equal(cp.Match, want.Match):
+		return "match"
+	case cp.CSVFormat != want.CSVFormat:
+		return "csv-format"
+	case cp.Gzip != want.Gzip:
+		return "gzip"
+	case cp.SplitFiles != want.SplitFiles:
+		return "split-files"
+	case !slices.Equal(cp.ExtraLabels, want.ExtraLabels):
+		return "extra-label"
+	case !slices.Equal(cp.ExtraFilters, want.ExtraFilters):
+		return "extra-filter"
+	case cp.Relabel != want.Relabel:
+		return "relabel-config"
+	}
+	return ""
+}
+
+// chunkedExport 分片导出任务
+type chunkedExport struct {
+	export      exportFunc
+	format      vmapi.ExportFormat
+	opts        *vmapi.ExportOptions
+	windows     []command.TimeWindow
+	concurrency int
+	output      string // 输出文件路径，空表示 stdout
+	gzip        bool
+	splitFiles  bool   // 每个分片写入单独文件
+	relabel     string // relabel 配置文件内容的 SHA-256，未指定时为空
+	cpPath      string // checkpoint 文件路径，空表示不记录
+	cp          *checkpoint
+}
+
+// chunkResult 单个分片的下载结果 (临时文件)
+type chunkResult struct {
+	index int
+	path  string
+	err   error
+}
+
+// runChunkedExport 按时间窗口分片、并发导出
+func runChunkedExport(ctx context.Context, cmd *cli.Command, format vmapi.ExportFormat, export exportFunc, opts *vmapi.ExportOptions) error {
+	chunk, err := command.ParseDuration(cmd.String("chunk"))
+	if err != nil || chunk <= 0 {
+		return fmt.Errorf("invalid chunk duration: %s", cmd.String("chunk"))
+	}
+
+	output := cmd.String("output")
+	if output == "-" {
+		output = ""
+	}
+
+	e := &chunkedExport{
+		export:      export,
+		format:      format,
+		opts:        opts,
+		concurrency: max(cmd.Int("concurrency"), 1),
+		output:      output,
+		gzip:        cmd.Bool("gzip"),
+		splitFiles:  cmd.Bool("split-files"),
+	}
+
+	if path := cmd.String("relabel-config"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read relabel config: %w", err)
+		}
+		sum := sha256.Sum256(data)
+		e.relabel = hex.EncodeToString(sum[:])
+	}
+
+	if e.splitFiles && e.output == "" {
+		return fmt.Errorf("--split-files requires --output")
+	}
+	// native 格式每个响应都带有独立的头部，不能直接拼接
+	if format == vmapi.ExportFormatNative && !e.splitFiles {
+		return fmt.Errorf("chunked native export requires --split-files")
+	}
+
+	if e.output != "" {
+		e.cpPath = cmd.String("checkpoint")
+		if e.cpPath == "" {
+			e.cpPath = e.output + ".checkpoint"
+		}
+	}
+
+	if err := e.prepare(cmd, chunk); err != nil {
+		return err
+	}
+	return e.run(ctx)
+}
+
+// prepare 计算时间窗口，并在 --resume 时加载 checkpoint
+func (e *chunkedExport) prepare(cmd *cli.Command, chunk time.Duration) error {
+	if cmd.Bool("resume") {
+		if e.cpPath == "" {
+			return fmt.Errorf("--resume requires --output")
+		}
+		cp, err := loadCheckpoint(e.cpPath)
+		if err != nil {
+			return err
+		}
+		if cp != nil {
+			if name := cp.mismatch(e.newCheckpoint()); name != "" {
+				return fmt.Errorf("checkpoint %s does not match current export (%s changed)", e.cpPath, name)
+			}
+			// 相对时间 (如 now-1d) 每次解析结果不同，恢复时沿用 checkpoint 中的时间范围
+			chunk, err = command.ParseDuration(cp.Chunk)
+			if err != nil {
+				return fmt.Errorf("invalid checkpoint %s: %w", e.cpPath, err)
+			}
+			e.cp = cp
+			e.windows = command.SplitTimeRange(cp.Start, cp.End, chunk)
+			_, _ = fmt.Fprintf(os.Stderr, "Resuming from %s: %d/%d chunks done\n", e.cpPath, len(cp.Done), len(e.windows))
+			return nil
+		}
+	}
+
+	if e.opts.Start.IsZero() {
+		return fmt.Errorf("--start is required for chunked export")
+	}
+	if e.opts.End.IsZero() {
+		e.opts.End = time.Now()
+	}
+	if !e.opts.Start.Before(e.opts.End) {
+		return fmt.Errorf("--start must be before --end")
+	}
+
+	e.windows = command.SplitTimeRange(e.opts.Start, e.opts.End, chunk)
+	e.cp = e.newCheckpoint()
+	e.cp.Start = e.opts.Start
+	e.cp.End = e.opts.End
+	e.cp.Chunk = chunk.String()
+	return nil
+}
+
+// newCheckpoint 返回记录当前导出参数的 checkpoint (不含时间范围与进度)
+func (e *chunkedExport) newCheckpoint() *checkpoint {
+	return &checkpoint{
+		Format:       string(e.format),
+		Match:        e.opts.Match,
+		CSVFormat:    e.opts.CSVFormat,
+		Gzip:         e.gzip,
+		SplitFiles:   e.splitFiles,
+		ExtraLabels:  e.opts.ExtraLabels,
+		ExtraFilters: e.opts.ExtraFilters,
+		Relabel:      e.relabel,
+	}
+}
+
+// run 并发下载分片，并按顺序 (或按分片文件) 写出
+func (e *chunkedExport) run(ctx context.Context) error {
+	out, err := e.openOutput()
+	if err != nil {
+		return err
+	}
+	if out != nil {
+		defer func() { _ = out.Close() }()
+	}
+
+	var pending []int
+	for i := range e.windows {
+		if !slices.Contains(e.cp.Done, i) {
+			pending = append(pending, i)
+		}
+	}
+
+	// 先保存一次 checkpoint，确保恢复时使用相同的时间范围
+	if e.cpPath != "" {
+		if err := saveCheckpoint(e.cpPath, e.cp); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// slots 限制已下载但尚未写出的分片数量，避免临时文件无限堆积
+	slots := make(chan struct{}, e.concurrency*2)
+	jobs := make(chan int)
+	results := make(chan chunkResult)
+
+	go func() {
+		defer close(jobs)
+		for _, i := range pending {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for range e.concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				path, err := e.fetch(ctx, i)
+				results <- chunkResult{index: i, path: path, err: err}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// 顺序模式下缓存已下载但未轮到写出的分片
+	ready := make(map[int]string)
+	next := 0
+	var firstErr error
+
+	for res := range results {
+		if res.err != nil {
+			if firstErr == nil {
+				firstErr = res.err
+				cancel()
+			}
+			continue
+		}
+		// 出错后已下载完成的分片仍按顺序写出，以便 --resume 时跳过
+
+		var err error
+		if e.splitFiles {
+			err = e.commitFile(res)
+			<-slots
+		} else {
+			ready[res.index] = res.path
+			for next < len(pending) {
+				path, ok := ready[pending[next]]
+				if !ok {
+					break
+				}
+				delete(ready, pending[next])
+				err = e.appendChunk(out, pending[next], path)
+				<-slots
+				if err != nil {
+					break
+				}
+				next++
+			}
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+
+	for _, path := range ready {
+		_ = os.Remove(path)
+	}
+	if firstErr != nil {
+		return firstErr
+	}
+
+	// 全部完成后删除 checkpoint
+	if e.cpPath != "" {
+		_ = os.Remove(e.cpPath)
+	}
+	return nil
+}
+
+// fetch 下载单个分片到临时文件
+func (e *chunkedExport) fetch(ctx context.Context, i int) (string, error) {
+	dir := os.TempDir()
+	if e.output != "" {
+		dir = filepath.Dir(e.output)
+	}
+	f, err := os.CreateTemp(dir, ".vmexport-chunk-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+
+	// 分片文件模式下直接压缩到临时文件
+	var w io.Writer = f
+	var gz *gzip.Writer
+	if e.splitFiles && e.gzip {
+		gz = gzip.NewWriter(f)
+		w = gz
+	}
+
+	opts := *e.opts
+	opts.Start, opts.End = e.windowRange(i)
+	err = e.export(ctx, w, &opts)
+	if gz != nil {
+		err = errors.Join(err, gz.Close())
+	}
+	err = errors.Join(err, f.Close())
+	if err != nil {
+		_ = os.Remove(f.Name())
+		w := e.windows[i]
+		return "", fmt.Errorf("chunk %d/%d [%s, %s): %w", i+1, len(e.windows), w.Start.Format(time.RFC3339), w.End.Format(time.RFC3339), err)
+	}
+	return f.Name(), nil
+}
+
+// windowRange 返回分片请求的时间范围
+// VictoriaMetrics 的 end 参数包含边界，非最后一个分片提前 1ms 结束以避免样本重复
+func (e *chunkedExport) windowRange(i int) (time.Time, time.Time) {
+	w := e.windows[i]
+	if i == len(e.windows)-1 {
+		return w.Start, w.End
+	}
+	return w.Start, w.End.Add(-time.Millisecond)
+}
+
+// openOutput 打开顺序模式的输出，恢复时截断到 checkpoint 记录的偏移量后追加
+func (e *chunkedExport) openOutput() (io.WriteCloser, error) {
+	if e.splitFiles {
+		return nil, nil
+	}
+	if e.output == "" {
+		return nopCloser{os.Stdout}, nil
+	}
+
+	f, err := os.OpenFile(e.output, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open output file: %w", err)
+	}
+	// 丢弃上次中断时写了一半的分片
+	if err := f.Truncate(e.cp.Offset); err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("failed to truncate output file: %w", err)
+	}
+	if _, err := f.Seek(e.cp.Offset, io.SeekStart); err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("failed to seek output file: %w", err)
+	}
+	return f, nil
+}
+
+// appendChunk 将分片追加到输出，gzip 模式下每个分片是独立的 gzip member
+func (e *chunkedExport) appendChunk(out io.Writer, i int, path string) error {
+	defer func() { _ = os.Remove(path) }()
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open chunk: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	cw := &util.CountingWriter{W: out}
+	if e.gzip {
+		gz := gzip.NewWriter(cw)
+		if _, err := io.Copy(gz, f); err != nil {
+			return fmt.Errorf("failed to write chunk %d: %w", i, err)
+		}
+		if err := gz.Close(); err != nil {
+			return fmt.Errorf("failed to write chunk %d: %w", i, err)
+		}
+	} else if _, err := io.Copy(cw, f); err != nil {
+		return fmt.Errorf("failed to write chunk %d: %w", i, err)
+	}
+
+	if s, ok := out.(interface{ Sync() error }); ok && e.output != "" {
+		_ = s.Sync()
+	}
+	e.cp.Offset += cw.N
+	return e.markDone(i)
+}
+
+// commitFile 将分片临时文件重命名为最终的分片文件
+func (e *chunkedExport) commitFile(res chunkResult) error {
+	if err := os.Rename(res.path, chunkFilePath(e.output, res.index, e.gzip)); err != nil {
+		_ = os.Remove(res.path)
+		return fmt.Errorf("failed to write chunk %d: %w", res.index, err)
+	}
+	return e.markDone(res.index)
+}
+
+// markDone 记录分片完成并保存 checkpoint
+func (e *chunkedExport) markDone(i int) error {
+	e.cp.Done = append(e.cp.Done, i)
+	w := e.windows[i]
+	_, _ = fmt.Fprintf(os.Stderr, "chunk %d/%d [%s, %s) done\n",
+		len(e.cp.Done), len(e.windows), w.Start.Format(time.RFC3339), w.End.Format(time.RFC3339))
+
+	if e.cpPath == "" {
+		return nil
+	}
+	return saveCheckpoint(e.cpPath, e.cp)
+}
+
+// chunkFilePath 返回分片文件路径，序号插入扩展名之前
+// 例如 data.jsonl → data.00003.jsonl
+func chunkFilePath(output string, i int, useGzip bool) string {
+	ext := filepath.Ext(output)
+	base := output[:len(output)-len(ext)]
+	path := fmt.Sprintf("%s.%05d%s", base, i, ext)
+	if useGzip && ext != ".gz" {
+		path += ".gz"
+	}
+	return path
+}
+
+// loadCheckpoint 读取 checkpoint，文件不存在时返回 nil
+func loadCheckpoint(path string) (*checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+	var cp checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("invalid checkpoint %s: %w", path, err)
+	}
+	return &cp, nil
+}
+
+// saveCheckpoint 原子写入 checkpoint
+func saveCheckpoint(path string, cp *checkpoint) error {
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// nopCloser 不关闭底层 Writer (用于 stdout)
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
@@ -0,0 +1,179 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lwmacct/251203-vm-metrics/internal/command"
+	"github.com/lwmacct/251203-vm-metrics/internal/vmapi"
+	"github.com/urfave/cli/v3"
+)
+
+// testStart 测试分片的起始时间，每个分片 1h
+var testStart = time.Unix(1700000000, 0)
+
+// chunkIndex 由请求的开始时间计算分片序号
+func chunkIndex(opts *vmapi.ExportOptions) int {
+	return int(opts.Start.Sub(testStart) / time.Hour)
+}
+
+// newTestExport 创建写入 dir/out.jsonl 的顺序模式分片导出
+func newTestExport(dir string, chunks, concurrency int, export exportFunc) *chunkedExport {
+	output := filepath.Join(dir, "out.jsonl")
+	end := testStart.Add(time.Duration(chunks) * time.Hour)
+	return &chunkedExport{
+		export:      export,
+		format:      vmapi.ExportFormatJSON,
+		opts:        &vmapi.ExportOptions{Match: []string{"up"}, Start: testStart, End: end},
+		windows:     command.SplitTimeRange(testStart, end, time.Hour),
+		concurrency: concurrency,
+		output:      output,
+		cpPath:      output + ".checkpoint",
+		cp:          &checkpoint{Format: "json", Match: []string{"up"}, Start: testStart, End: end, Chunk: "1h0m0s"},
+	}
+}
+
+// TestChunkedExportOutOfOrder 验证后面的分片先完成时仍按顺序写出，失败时 checkpoint 只记录已写出的分片
+func TestChunkedExportOutOfOrder(t *testing.T) {
+	dir := t.TempDir()
+
+	// 分片 0 等待分片 2 下载完成后才返回，分片 1 失败
+	chunk2Done := make(chan struct{})
+	e := newTestExport(dir, 3, 3, func(ctx context.Context, w io.Writer, opts *vmapi.ExportOptions) error {
+		i := chunkIndex(opts)
+		switch i {
+		case 0:
+			<-chunk2Done
+		case 1:
+			<-chunk2Done
+			return errors.New("boom")
+		case 2:
+			defer close(chunk2Done)
+		}
+		_, err := fmt.Fprintf(w, "chunk%d\n", i)
+		return err
+	})
+
+	if err := e.run(context.Background()); err == nil {
+		t.Fatal("run 应返回分片 1 的错误")
+	}
+
+	data, _ := os.ReadFile(e.output)
+	if string(data) != "chunk0\n" {
+		t.Errorf("output = %q, want only chunk0", data)
+	}
+	cp, err := loadCheckpoint(e.cpPath)
+	if err != nil || cp == nil {
+		t.Fatalf("checkpoint = %v, %v", cp, err)
+	}
+	if !slices.Equal(cp.Done, []int{0}) || cp.Offset != int64(len("chunk0\n")) {
+		t.Errorf("checkpoint done = %v, offset = %d", cp.Done, cp.Offset)
+	}
+	matches, _ := filepath.Glob(filepath.Join(dir, ".vmexport-chunk-*"))
+	if len(matches) != 0 {
+		t.Errorf("临时文件未清理: %v", matches)
+	}
+}
+
+// TestChunkedExportResume 验证恢复时截断上次写了一半的分片，只下载未完成的分片
+func TestChunkedExportResume(t *testing.T) {
+	dir := t.TempDir()
+
+	var mu sync.Mutex
+	var fetched []int
+	e := newTestExport(dir, 4, 2, func(ctx context.Context, w io.Writer, opts *vmapi.ExportOptions) error {
+		i := chunkIndex(opts)
+		mu.Lock()
+		fetched = append(fetched, i)
+		mu.Unlock()
+		_, err := fmt.Fprintf(w, "chunk%d\n", i)
+		return err
+	})
+
+	// 上次中断: 分片 0 已写出，分片 1 写了一半
+	committed := "chunk0\n"
+	if err := os.WriteFile(e.output, []byte(committed+"chu"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	e.cp.Done = []int{0}
+	e.cp.Offset = int64(len(committed))
+	if err := saveCheckpoint(e.cpPath, e.cp); err != nil {
+		t.Fatal(err)
+	}
+
+	cp, err := loadCheckpoint(e.cpPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.cp = cp
+	if err := e.run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := os.ReadFile(e.output)
+	if want := "chunk0\nchunk1\nchunk2\nchunk3\n"; string(data) != want {
+		t.Errorf("output = %q, want %q", data, want)
+	}
+	slices.Sort(fetched)
+	if !slices.Equal(fetched, []int{1, 2, 3}) {
+		t.Errorf("fetched = %v, want [1 2 3]", fetched)
+	}
+	if _, err := os.Stat(e.cpPath); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("完成后应删除 checkpoint: %v", err)
+	}
+}
+
+// TestChunkedExportResumeMismatch 验证影响输出形态的参数变化时拒绝恢复
+func TestChunkedExportResumeMismatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(e *chunkedExport)
+		want   string
+	}{
+		{"unchanged", func(*chunkedExport) {}, ""},
+		{"format", func(e *chunkedExport) { e.format = vmapi.ExportFormatCSV }, "format"},
+		{"match", func(e *chunkedExport) { e.opts.Match = []string{"down"} }, "match"},
+		{"csv format", func(e *chunkedExport) { e.opts.CSVFormat = "1:metric:x" }, "csv-format"},
+		{"gzip", func(e *chunkedExport) { e.gzip = true }, "gzip"},
+		{"split files", func(e *chunkedExport) { e.splitFiles = true }, "split-files"},
+		{"extra label", func(e *chunkedExport) { e.opts.ExtraLabels = []string{"team=a"} }, "extra-label"},
+		{"extra filter", func(e *chunkedExport) { e.opts.ExtraFilters = []string{`{env="prod"}`} }, "extra-filter"},
+		{"relabel", func(e *chunkedExport) { e.relabel = "digest" }, "relabel-config"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestExport(t.TempDir(), 2, 1, nil)
+			if err := saveCheckpoint(e.cpPath, e.cp); err != nil {
+				t.Fatal(err)
+			}
+			tt.change(e)
+
+			cmd := &cli.Command{
+				Name:  "test",
+				Flags: []cli.Flag{&cli.BoolFlag{Name: "resume"}},
+				Action: func(_ context.Context, cmd *cli.Command) error {
+					return e.prepare(cmd, time.Hour)
+				},
+			}
+			err := cmd.Run(context.Background(), []string{"test", "--resume"})
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("resume: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), "("+tt.want+" changed)") {
+				t.Errorf("err = %v, want %s changed", err, tt.want)
+			}
+		})
+	}
+}
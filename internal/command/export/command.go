@@ -33,11 +33,11 @@ func exportFlags() []cli.Flag {
 		// 导出参数
 		&cli.StringFlag{
 			Name:  "start",
-			Usage: "开始时间 (RFC3339 或 Unix 时间戳)",
+			Usage: "开始时间 (如 now-1d, 2024-05-01, RFC3339 或 Unix 时间戳)",
 		},
 		&cli.StringFlag{
 			Name:  "end",
-			Usage: "结束时间 (RFC3339 或 Unix 时间戳)",
+			Usage: "结束时间 (如 now, now/d, RFC3339 或 Unix 时间戳)",
 		},
 		&cli.StringFlag{
 			Name:    "output",
@@ -48,6 +48,34 @@ func exportFlags() []cli.Flag {
 			Name:  "gzip",
 			Usage: "启用 gzip 压缩输出",
 		},
+		&cli.StringFlag{
+			Name:  "relabel-config",
+			Usage: "relabel_configs YAML 文件，输出前改写或过滤序列 (仅 json)",
+		},
+		command.ExtraLabelFlag("强制附加的标签过滤条件 (extra_label)，格式 name=value，可重复"),
+		command.ExtraFilterFlag(),
+		// 分片导出
+		&cli.StringFlag{
+			Name:  "chunk",
+			Usage: "按时间窗口分片导出 (如 1h, 1d)，需要 --start",
+		},
+		&cli.IntFlag{
+			Name:  "concurrency",
+			Usage: "分片并发下载数量",
+			Value: 1,
+		},
+		&cli.BoolFlag{
+			Name:  "split-files",
+			Usage: "每个分片写入单独的文件 (如 data.00000.jsonl)",
+		},
+		&cli.StringFlag{
+			Name:  "checkpoint",
+			Usage: "分片进度文件路径 (默认: <output>.checkpoint)",
+		},
+		&cli.BoolFlag{
+			Name:  "resume",
+			Usage: "根据 checkpoint 跳过已完成的分片继续导出",
+		},
 	)
 }
 
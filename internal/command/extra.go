@@ -0,0 +1,76 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+)
+
+// repeatedValue 可重复指定的字符串列表，不按逗号拆分 (序列选择器中通常包含逗号)
+type repeatedValue []string
+
+// Set 实现 cli.Value
+func (v *repeatedValue) Set(s string) error {
+	*v = append(*v, s)
+	return nil
+}
+
+// String 实现 cli.Value
+func (v *repeatedValue) String() string {
+	return strings.Join(*v, " ")
+}
+
+// Get 实现 cli.Value
+func (v *repeatedValue) Get() any {
+	return []string(*v)
+}
+
+// RepeatedFlag 返回可重复、不按逗号拆分的字符串 flag，用 RepeatedValues 读取
+func RepeatedFlag(name, usage string) cli.Flag {
+	return &cli.GenericFlag{
+		Name:  name,
+		Usage: usage,
+		Value: &repeatedValue{},
+	}
+}
+
+// RepeatedValues 读取 RepeatedFlag 的值，未定义或未指定时返回 nil
+// cmd.Generic 返回的是 Get() 的结果而不是 flag.Value，因此通过 cmd.Value 读取
+func RepeatedValues(cmd *cli.Command, name string) []string {
+	v, _ := cmd.Value(name).([]string)
+	return v
+}
+
+// ExtraLabelFlag 返回 --extra-label flag (可重复)
+func ExtraLabelFlag(usage string) cli.Flag {
+	return RepeatedFlag("extra-label", usage)
+}
+
+// ExtraFilterFlag 返回 --extra-filter flag (可重复)
+func ExtraFilterFlag() cli.Flag {
+	return RepeatedFlag("extra-filter", "附加的序列选择器 (extra_filters[])，如 '{env=~\"prod|staging\"}'，可重复，多个之间为或的关系")
+}
+
+// ExtraLabels 返回 --extra-label 的值，校验 name=value 格式
+func ExtraLabels(cmd *cli.Command) ([]string, error) {
+	labels := RepeatedValues(cmd, "extra-label")
+	for _, l := range labels {
+		name, _, ok := strings.Cut(l, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid --extra-label %q: expected name=value", l)
+		}
+	}
+	return labels, nil
+}
+
+// ExtraFilters 返回 --extra-filter 的值
+func ExtraFilters(cmd *cli.Command) ([]string, error) {
+	filters := RepeatedValues(cmd, "extra-filter")
+	for _, f := range filters {
+		if strings.TrimSpace(f) == "" {
+			return nil, fmt.Errorf("invalid --extra-filter: empty selector")
+		}
+	}
+	return filters, nil
+}
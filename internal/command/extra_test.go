@@ -0,0 +1,61 @@
+package command
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/urfave/cli/v3"
+)
+
+// TestExtraFlags 验证 --extra-label/--extra-filter 可重复且不按逗号拆分
+func TestExtraFlags(t *testing.T) {
+	var labels, filters []string
+	cmd := &cli.Command{
+		Name:  "test",
+		Flags: []cli.Flag{ExtraLabelFlag(""), ExtraFilterFlag()},
+		Action: func(_ context.Context, cmd *cli.Command) error {
+			var err error
+			if labels, err = ExtraLabels(cmd); err != nil {
+				return err
+			}
+			filters, err = ExtraFilters(cmd)
+			return err
+		},
+	}
+	args := []string{"test", "--extra-label", "team=a", "--extra-label", "env=prod,staging",
+		"--extra-filter", `{job="a",x="1"}`, "--extra-filter", `{y="2"}`}
+	if err := cmd.Run(context.Background(), args); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"team=a", "env=prod,staging"}; !slices.Equal(labels, want) {
+		t.Errorf("labels = %q, want %q", labels, want)
+	}
+	if want := []string{`{job="a",x="1"}`, `{y="2"}`}; !slices.Equal(filters, want) {
+		t.Errorf("filters = %q, want %q", filters, want)
+	}
+
+	if err := cmd.Run(context.Background(), []string{"test", "--extra-label", "team"}); err == nil {
+		t.Error("--extra-label 应要求 name=value")
+	}
+}
+
+// TestRepeatedFlag 验证值中的逗号不会把一个值拆成多个 (如带逗号的 graphite 模板)
+func TestRepeatedFlag(t *testing.T) {
+	var got []string
+	cmd := &cli.Command{
+		Name:  "test",
+		Flags: []cli.Flag{RepeatedFlag("template", "")},
+		Action: func(_ context.Context, cmd *cli.Command) error {
+			got = RepeatedValues(cmd, "template")
+			return nil
+		},
+	}
+	args := []string{"test", "--template", "a.* host=$1,x", "--template", "b.* metric=$1"}
+	if err := cmd.Run(context.Background(), args); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a.* host=$1,x", "b.* metric=$1"}; !slices.Equal(got, want) {
+		t.Errorf("templates = %q, want %q", got, want)
+	}
+}
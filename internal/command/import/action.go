@@ -1,13 +1,19 @@
 package importcmd
 
 import (
+	"bufio"
+	"bytes"
 	"compress/gzip"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"time"
 
 	"github.com/lwmacct/251203-vm-metrics/internal/command"
+	"github.com/lwmacct/251203-vm-metrics/internal/lineproto"
+	"github.com/lwmacct/251203-vm-metrics/internal/relabel"
 	"github.com/lwmacct/251203-vm-metrics/internal/vmapi"
 	"github.com/urfave/cli/v3"
 )
@@ -47,8 +53,77 @@ func getReader(cmd *cli.Command) (io.ReadCloser, error) {
 	return r, nil
 }
 
-// actionImportJSON 导入 JSON Line 格式
-func actionImportJSON(ctx context.Context, cmd *cli.Command) error {
+// inputSize 返回输入文件大小，用于计算进度百分比
+// stdin 或 gzip 输入无法得知解压后的大小，返回 0
+func inputSize(cmd *cli.Command) int64 {
+	inputPath := cmd.String("input")
+	if inputPath == "" && cmd.Args().Len() > 0 {
+		inputPath = cmd.Args().First()
+	}
+	if inputPath == "" || inputPath == "-" || cmd.Bool("gzip") {
+		return 0
+	}
+	fi, err := os.Stat(inputPath)
+	if err != nil {
+		return 0
+	}
+	return fi.Size()
+}
+
+// getBatchOptions 从 flags 构建分批导入选项
+func getBatchOptions(cmd *cli.Command) (batchOptions, error) {
+	maxBytes, err := parseBytes(cmd.String("batch-size"))
+	if err != nil {
+		return batchOptions{}, fmt.Errorf("invalid --batch-size: %w", err)
+	}
+	if cmd.Int("batch-lines") < 0 || cmd.Int("retries") < 0 {
+		return batchOptions{}, fmt.Errorf("--batch-lines and --retries must not be negative")
+	}
+	return batchOptions{
+		MaxLines: cmd.Int("batch-lines"),
+		MaxBytes: maxBytes,
+		Retries:  cmd.Int("retries"),
+		Backoff:  cmd.Duration("retry-backoff"),
+		Size:     inputSize(cmd),
+		Progress: !cmd.Bool("no-progress"),
+	}, nil
+}
+
+// runBatchImport 分批导入按行组织的格式 (JSON Line、CSV、Prometheus)
+// newSend 根据 Importer 返回发送单个批次的函数
+func runBatchImport(ctx context.Context, cmd *cli.Command, newSend func(vmapi.Importer) sendFunc) error {
+	return runFormatImport(ctx, cmd, lineFormat{}, newSend)
+}
+
+// lineFormat 分批导入前对输入的处理
+type lineFormat struct {
+	// Normalize 将输入整理为每行一条记录 (可选)，用于不按行组织的格式
+	Normalize func(r io.Reader) io.ReadCloser
+	// Transform 逐行校验与转换 (可选)，见 batchOptions.Transform
+	Transform func(line []byte) ([]byte, error)
+	// Relabeled Transform 已执行 --relabel-config 中的规则 (仅 JSON Line)
+	Relabeled bool
+}
+
+// checkRelabel relabel 规则只能用于 JSON Line 导入
+func checkRelabel(cmd *cli.Command, relabeled bool) error {
+	if cmd.String("relabel-config") != "" && !relabeled {
+		return fmt.Errorf("--relabel-config only applies to json import")
+	}
+	return nil
+}
+
+// runFormatImport 与 runBatchImport 相同，但输入先经过 format 整理、校验与转换
+func runFormatImport(ctx context.Context, cmd *cli.Command, format lineFormat, newSend func(vmapi.Importer) sendFunc) error {
+	if err := checkRelabel(cmd, format.Relabeled); err != nil {
+		return err
+	}
+	opts, err := getBatchOptions(cmd)
+	if err != nil {
+		return err
+	}
+	opts.Transform = format.Transform
+
 	client, err := command.NewClient(command.GetConfig(cmd))
 	if err != nil {
 		return fmt.Errorf("failed to create client: %w", err)
@@ -60,37 +135,110 @@ func actionImportJSON(ctx context.Context, cmd *cli.Command) error {
 	}
 	defer func() { _ = r.Close() }()
 
+	// 进度按读取的原始输入计算，与 Transform、Normalize 后的数据大小无关
+	src := &countingReader{r: r}
+	opts.Consumed = src.n.Load
+
+	var input io.Reader = src
+	if format.Normalize != nil {
+		nr := format.Normalize(src)
+		defer func() { _ = nr.Close() }()
+		input = nr
+	}
+
 	importer, ok := client.(vmapi.Importer)
 	if !ok {
 		return fmt.Errorf("client does not support import")
 	}
 
-	return importer.ImportJSON(ctx, r)
+	stats, err := importBatches(ctx, input, newSend(importer), opts)
+	_, _ = fmt.Fprintf(os.Stderr, "Import summary: %s\n", stats)
+	if err != nil {
+		return err
+	}
+	if stats.Failed > 0 {
+		return fmt.Errorf("%d of %d batches failed", stats.Failed, stats.Batches)
+	}
+	return nil
 }
 
-// actionImportCSV 导入 CSV 格式
-func actionImportCSV(ctx context.Context, cmd *cli.Command) error {
-	client, err := command.NewClient(command.GetConfig(cmd))
+// importOptions 从 flags 构建各格式共用的导入选项
+func importOptions(cmd *cli.Command) (*vmapi.ImportOptions, error) {
+	extraLabels, err := command.ExtraLabels(cmd)
 	if err != nil {
-		return fmt.Errorf("failed to create client: %w", err)
+		return nil, err
 	}
+	return &vmapi.ImportOptions{ExtraLabels: extraLabels}, nil
+}
 
-	r, err := getReader(cmd)
+// importMethod Importer 的导入方法
+type importMethod func(ctx context.Context, r io.Reader, opts *vmapi.ImportOptions) error
+
+// bind 绑定导入选项，返回发送单个批次的函数
+func bind(method importMethod, opts *vmapi.ImportOptions) sendFunc {
+	return func(ctx context.Context, r io.Reader) error {
+		return method(ctx, r, opts)
+	}
+}
+
+// actionImportJSON 导入 JSON Line 格式，指定 --relabel-config 时逐行执行 relabel 规则
+func actionImportJSON(ctx context.Context, cmd *cli.Command) error {
+	opts, err := importOptions(cmd)
 	if err != nil {
 		return err
 	}
-	defer func() { _ = r.Close() }()
 
-	importer, ok := client.(vmapi.Importer)
-	if !ok {
-		return fmt.Errorf("client does not support import")
+	var format lineFormat
+	var processor *relabel.Processor
+	if path := cmd.String("relabel-config"); path != "" {
+		rules, err := relabel.LoadFile(path)
+		if err != nil {
+			return err
+		}
+		processor = relabel.NewProcessor(rules)
+		format.Transform = processor.ProcessLine
+		format.Relabeled = true
 	}
 
-	return importer.ImportCSV(ctx, r)
+	err = runFormatImport(ctx, cmd, format, func(importer vmapi.Importer) sendFunc {
+		return bind(importer.ImportJSON, opts)
+	})
+	if processor != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Relabel summary: %s\n", processor)
+	}
+	return err
+}
+
+// actionImportCSV 导入 CSV 格式
+func actionImportCSV(ctx context.Context, cmd *cli.Command) error {
+	opts, err := importOptions(cmd)
+	if err != nil {
+		return err
+	}
+	return runFormatImport(ctx, cmd, lineFormat{Transform: checkCSVLine}, func(importer vmapi.Importer) sendFunc {
+		return bind(importer.ImportCSV, opts)
+	})
+}
+
+// checkCSVLine 拒绝引号未闭合的行
+// VictoriaMetrics 按行解析 CSV，不支持引号内含换行的多行字段；
+// 这类记录按行切分批次时还会被拆到两个批次，因此在发送前报错
+func checkCSVLine(line []byte) ([]byte, error) {
+	if bytes.Count(line, []byte{'"'})%2 != 0 {
+		return nil, fmt.Errorf("unterminated quoted field (multiline CSV fields are not supported)")
+	}
+	return line, nil
 }
 
 // actionImportNative 导入 Native 二进制格式
 func actionImportNative(ctx context.Context, cmd *cli.Command) error {
+	if err := checkRelabel(cmd, false); err != nil {
+		return err
+	}
+	opts, err := importOptions(cmd)
+	if err != nil {
+		return err
+	}
 	client, err := command.NewClient(command.GetConfig(cmd))
 	if err != nil {
 		return fmt.Errorf("failed to create client: %w", err)
@@ -107,31 +255,170 @@ func actionImportNative(ctx context.Context, cmd *cli.Command) error {
 		return fmt.Errorf("client does not support import")
 	}
 
-	return importer.ImportNative(ctx, r)
+	return importer.ImportNative(ctx, r, opts)
 }
 
 // actionImportPrometheus 导入 Prometheus exposition 格式
 func actionImportPrometheus(ctx context.Context, cmd *cli.Command) error {
-	client, err := command.NewClient(command.GetConfig(cmd))
+	opts, err := importOptions(cmd)
 	if err != nil {
-		return fmt.Errorf("failed to create client: %w", err)
+		return err
 	}
+	opts.Job = cmd.String("job")
+	opts.Instance = cmd.String("instance")
 
-	r, err := getReader(cmd)
+	return runBatchImport(ctx, cmd, func(importer vmapi.Importer) sendFunc {
+		return bind(importer.ImportPrometheus, opts)
+	})
+}
+
+// actionImportInflux 导入 Influx line protocol 格式
+// 发送前逐行校验，遇到格式错误的行时中止并报告行号；--dry-run 时只校验不发送
+// 分隔符不是服务端默认的 "_" 时在客户端转换为 JSON Line 导入
+func actionImportInflux(ctx context.Context, cmd *cli.Command) error {
+	opts, err := importOptions(cmd)
 	if err != nil {
 		return err
 	}
-	defer func() { _ = r.Close() }()
+	opts.DB = cmd.String("db")
+	opts.Precision = cmd.String("precision")
+	opts.RetentionPolicy = cmd.String("rp")
+	if _, err := lineproto.InfluxTimestampMillis(0, opts.Precision); err != nil {
+		return fmt.Errorf("invalid --precision: %w", err)
+	}
+	sep := cmd.String("measurement-field-separator")
 
-	importer, ok := client.(vmapi.Importer)
-	if !ok {
-		return fmt.Errorf("client does not support import")
+	if cmd.Bool("dry-run") {
+		r, err := getReader(cmd)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = r.Close() }()
+		return checkInflux(r, os.Stderr)
 	}
 
-	opts := &vmapi.ImportOptions{
-		Job:      cmd.String("job"),
-		Instance: cmd.String("instance"),
+	if sep == influxDefaultSeparator {
+		validate := func(line []byte) ([]byte, error) {
+			p, err := lineproto.ParseInfluxLine(string(line))
+			if p == nil {
+				return nil, err
+			}
+			return line, nil
+		}
+		return runFormatImport(ctx, cmd, lineFormat{Transform: validate}, func(importer vmapi.Importer) sendFunc {
+			return bind(importer.ImportInflux, opts)
+		})
 	}
 
-	return importer.ImportPrometheus(ctx, r, opts)
+	convert := func(line []byte) ([]byte, error) {
+		p, err := lineproto.ParseInfluxLine(string(line))
+		if p == nil {
+			return nil, err
+		}
+		rows, err := p.Rows(sep, opts.DB, opts.Precision, time.Now().UnixMilli())
+		if err != nil {
+			return nil, err
+		}
+		var out []byte
+		for i := range rows {
+			out = rows[i].AppendJSON(out)
+		}
+		return out, nil
+	}
+	return runFormatImport(ctx, cmd, lineFormat{Transform: convert}, func(importer vmapi.Importer) sendFunc {
+		return bind(importer.ImportJSON, opts)
+	})
+}
+
+// influxDefaultSeparator VictoriaMetrics 默认的 measurement 与 field 分隔符 (-influxMeasurementFieldSeparator)
+const influxDefaultSeparator = "_"
+
+// checkInflux 校验全部输入，将每个格式错误的行及行号写入 w
+func checkInflux(r io.Reader, w io.Writer) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64<<10), 64<<20)
+	lines, points, malformed := 0, 0, 0
+	for sc.Scan() {
+		lines++
+		p, err := lineproto.ParseInfluxLine(sc.Text())
+		switch {
+		case err != nil:
+			malformed++
+			_, _ = fmt.Fprintf(w, "line %d: %v\n", lines, err)
+		case p != nil:
+			points++
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("failed to read input at line %d: %w", lines+1, err)
+	}
+	_, _ = fmt.Fprintf(w, "Checked %d lines: %d points, %d malformed\n", lines, points, malformed)
+	if malformed > 0 {
+		return fmt.Errorf("%d malformed lines", malformed)
+	}
+	return nil
+}
+
+// actionImportGraphite 导入 Graphite plaintext 格式
+// 发送前逐行校验并报告格式错误的行号，每个批次由 ImportGraphite 在客户端转换为 JSON Line
+func actionImportGraphite(ctx context.Context, cmd *cli.Command) error {
+	opts, err := importOptions(cmd)
+	if err != nil {
+		return err
+	}
+	for _, s := range command.RepeatedValues(cmd, "template") {
+		t, err := lineproto.ParseGraphiteTemplate(s)
+		if err != nil {
+			return fmt.Errorf("invalid --template: %w", err)
+		}
+		opts.GraphiteTemplates = append(opts.GraphiteTemplates, t)
+	}
+
+	validate := func(line []byte) ([]byte, error) {
+		p, err := lineproto.ParseGraphiteLine(string(line))
+		if p == nil {
+			return nil, err
+		}
+		return line, nil
+	}
+	return runFormatImport(ctx, cmd, lineFormat{Transform: validate}, func(importer vmapi.Importer) sendFunc {
+		return bind(importer.ImportGraphite, opts)
+	})
+}
+
+// actionImportOpenTSDB 导入 OpenTSDB JSON put 格式
+// 输入先整理为每行一个数据点以便分批，每个批次由 ImportOpenTSDB 在客户端转换为 JSON Line
+func actionImportOpenTSDB(ctx context.Context, cmd *cli.Command) error {
+	opts, err := importOptions(cmd)
+	if err != nil {
+		return err
+	}
+	return runFormatImport(ctx, cmd, lineFormat{Normalize: openTSDBLines}, func(importer vmapi.Importer) sendFunc {
+		return bind(importer.ImportOpenTSDB, opts)
+	})
+}
+
+// openTSDBLines 将 OpenTSDB JSON 负载 (对象、数组或多个值首尾相接) 转换为每行一个数据点
+// 解析错误在读取时返回，带有数据点序号
+func openTSDBLines(r io.Reader) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		w := bufio.NewWriter(pw)
+		err := lineproto.DecodeOpenTSDB(r, func(p *lineproto.OpenTSDBPoint) error {
+			if _, err := p.Row(0); err != nil {
+				return err
+			}
+			data, err := json.Marshal(p)
+			if err != nil {
+				return err
+			}
+			_, _ = w.Write(data)
+			return w.WriteByte('\n')
+		})
+		if err == nil {
+			err = w.Flush()
+		}
+		_ = pw.CloseWithError(err)
+	}()
+	return pr
 }
@@ -0,0 +1,268 @@
+package importcmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/lwmacct/251203-vm-metrics/internal/util"
+	"github.com/lwmacct/251203-vm-metrics/internal/vmapi"
+	"golang.org/x/term"
+)
+
+// maxRetryBackoff 重试退避的上限
+const maxRetryBackoff = 30 * time.Second
+
+// sendFunc 发送单个批次
+type sendFunc func(ctx context.Context, r io.Reader) error
+
+// batchOptions 分批导入选项
+type batchOptions struct {
+	MaxLines int           // 每批最大行数，0 表示不限制
+	MaxBytes int64         // 每批最大字节数，0 表示不限制
+	Retries  int           // 每批最大重试次数
+	Backoff  time.Duration // 首次重试等待时间，之后指数增长
+	Size     int64         // 输入总字节数 (未知时为 0)，用于显示进度百分比
+	Progress bool          // 是否显示进度条
+
+	// Consumed 返回已读取的原始输入字节数 (可选)，与 Size 一起计算进度百分比
+	// 按原始输入而不是转换后的批次字节数计算，避免 Transform 改变数据大小时百分比失真
+	Consumed func() int64
+
+	// Transform 在加入批次前校验并转换单行 (可选)
+	// 返回错误时中止导入并报告行号，返回 nil 时跳过该行 (如空行与注释)
+	Transform func(line []byte) ([]byte, error)
+}
+
+// importStats 导入统计
+type importStats struct {
+	Rows    int64
+	Bytes   int64
+	Batches int64
+	Failed  int64
+	Retries int64
+	Elapsed time.Duration
+}
+
+// String 返回统计摘要
+func (s *importStats) String() string {
+	return fmt.Sprintf("rows: %d, bytes: %s, batches: %d, failed: %d, retries: %d, elapsed: %s",
+		s.Rows, util.FormatBytes(s.Bytes), s.Batches, s.Failed, s.Retries, s.Elapsed.Round(time.Millisecond))
+}
+
+// importBatches 将按行组织的输入切分为批次逐批发送
+// 每批在内存中缓冲，因此可以安全地重放重试；单行超过 MaxBytes 时独占一批
+func importBatches(ctx context.Context, r io.Reader, send sendFunc, opts batchOptions) (*importStats, error) {
+	stats := &importStats{}
+	bar := newProgressBar(opts.Size, opts.Consumed, opts.Progress)
+	started := time.Now()
+	defer func() {
+		stats.Elapsed = time.Since(started)
+		bar.clear()
+	}()
+
+	br := bufio.NewReaderSize(r, 1<<20)
+	var buf bytes.Buffer
+	lines := 0
+	lineNo := 0
+
+	flush := func() error {
+		if buf.Len() == 0 {
+			return nil
+		}
+		err := sendWithRetry(ctx, send, buf.Bytes(), opts, stats)
+		stats.Batches++
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			stats.Failed++
+			bar.clear()
+			_, _ = fmt.Fprintf(os.Stderr, "batch %d failed (%d rows): %v\n", stats.Batches, lines, err)
+		} else {
+			stats.Rows += int64(lines)
+		}
+		stats.Bytes += int64(buf.Len())
+		bar.update(stats)
+		buf.Reset()
+		lines = 0
+		return nil
+	}
+
+	for {
+		line, readErr := br.ReadBytes('\n')
+		if len(line) > 0 {
+			lineNo++
+		}
+		if len(line) > 0 && opts.Transform != nil {
+			converted, err := opts.Transform(line)
+			if err != nil {
+				// 之前的批次已经发送，出错行所在的批次不再发送
+				return stats, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			line = converted
+		}
+		if len(line) > 0 {
+			// 加入当前行会超出字节上限时，先发送已有数据
+			if opts.MaxBytes > 0 && buf.Len() > 0 && int64(buf.Len()+len(line)) > opts.MaxBytes {
+				if err := flush(); err != nil {
+					return stats, err
+				}
+			}
+			buf.Write(line)
+			if line[len(line)-1] != '\n' {
+				buf.WriteByte('\n')
+			}
+			if len(bytes.TrimSpace(line)) > 0 {
+				lines++
+			}
+			if opts.MaxLines > 0 && lines >= opts.MaxLines {
+				if err := flush(); err != nil {
+					return stats, err
+				}
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return stats, fmt.Errorf("failed to read input: %w", readErr)
+		}
+	}
+	if err := flush(); err != nil {
+		return stats, err
+	}
+	return stats, nil
+}
+
+// sendWithRetry 发送批次，对网络错误与可重试的状态码按指数退避重试
+// 批次的重试只在这里进行，发送时禁用客户端的自动重试
+func sendWithRetry(ctx context.Context, send sendFunc, data []byte, opts batchOptions, stats *importStats) error {
+	sendCtx := vmapi.WithoutRetry(ctx)
+	backoff := opts.Backoff
+	for attempt := 0; ; attempt++ {
+		err := send(sendCtx, bytes.NewReader(data))
+		if err == nil || attempt >= opts.Retries || !isRetryable(err) || ctx.Err() != nil {
+			return err
+		}
+
+		stats.Retries++
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxRetryBackoff)
+	}
+}
+
+// isRetryable 判断错误是否值得重试
+// 状态码与网络错误的判断与客户端自动重试一致 (--server-retry-status-codes)；
+// 调用方取消或超时 (如 Ctrl-C) 不重试
+func isRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var se *vmapi.StatusError
+	if errors.As(err, &se) {
+		return se.Temporary()
+	}
+	return vmapi.IsNetworkError(err)
+}
+
+// progressBar stderr 进度条，仅在 stderr 为终端时显示
+type progressBar struct {
+	total    int64
+	consumed func() int64
+	enabled  bool
+	last     time.Time
+}
+
+// newProgressBar 创建进度条，total 或 consumed 未知时不显示百分比
+func newProgressBar(total int64, consumed func() int64, enabled bool) *progressBar {
+	return &progressBar{
+		total:    total,
+		consumed: consumed,
+		enabled:  enabled && term.IsTerminal(int(os.Stderr.Fd())),
+	}
+}
+
+// update 刷新进度 (限频 100ms)
+func (p *progressBar) update(s *importStats) {
+	if !p.enabled || time.Since(p.last) < 100*time.Millisecond {
+		return
+	}
+	p.last = time.Now()
+
+	const width = 30
+	bar := ""
+	if p.total > 0 && p.consumed != nil {
+		ratio := min(float64(p.consumed())/float64(p.total), 1)
+		filled := int(ratio * width)
+		bar = fmt.Sprintf("[%s%s] %3.0f%% ", strings.Repeat("=", filled), strings.Repeat(" ", width-filled), ratio*100)
+	}
+	_, _ = fmt.Fprintf(os.Stderr, "\r\x1b[K%s%s, %d rows, %d batches, %d failed",
+		bar, util.FormatBytes(s.Bytes), s.Rows, s.Batches, s.Failed)
+}
+
+// clear 清除进度条所在行
+func (p *progressBar) clear() {
+	if p.enabled {
+		_, _ = fmt.Fprint(os.Stderr, "\r\x1b[K")
+	}
+}
+
+// countingReader 统计已读取的字节数
+// 输入可能经由 Normalize 的 goroutine 读取，因此使用原子计数
+type countingReader struct {
+	r io.Reader
+	n atomic.Int64
+}
+
+// Read 实现 io.Reader
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+// parseBytes 解析字节大小，支持 B/KB/MB/GB 及 KiB/MiB/GiB 后缀 (均按 1024 计算)
+func parseBytes(s string) (int64, error) {
+	s = strings.TrimSpace(strings.ToUpper(s))
+	if s == "" || s == "0" {
+		return 0, nil
+	}
+
+	multipliers := []struct {
+		suffix string
+		value  int64
+	}{
+		{"KIB", 1 << 10}, {"MIB", 1 << 20}, {"GIB", 1 << 30},
+		{"KB", 1 << 10}, {"MB", 1 << 20}, {"GB", 1 << 30},
+		{"K", 1 << 10}, {"M", 1 << 20}, {"G", 1 << 30},
+		{"B", 1},
+	}
+	for _, m := range multipliers {
+		if num, ok := strings.CutSuffix(s, m.suffix); ok {
+			n, err := strconv.ParseFloat(strings.TrimSpace(num), 64)
+			if err != nil || n < 0 {
+				return 0, fmt.Errorf("invalid size: %s", s)
+			}
+			return int64(n * float64(m.value)), nil
+		}
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size: %s", s)
+	}
+	return n, nil
+}
@@ -0,0 +1,154 @@
+package importcmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/lwmacct/251203-vm-metrics/internal/vmapi"
+)
+
+// TestImportBatches 验证按行数/字节数切分批次以及重试策略
+func TestImportBatches(t *testing.T) {
+	input := "a\nbb\nccc\ndddd\neeeee"
+
+	var batches []string
+	calls := 0
+	send := func(_ context.Context, r io.Reader) error {
+		calls++
+		data, _ := io.ReadAll(r)
+		switch {
+		case calls == 1:
+			return &vmapi.StatusError{Op: "import", StatusCode: 503, Retryable: true}
+		case strings.Contains(string(data), "dddd"):
+			return &vmapi.StatusError{Op: "import", StatusCode: 400}
+		}
+		batches = append(batches, string(data))
+		return nil
+	}
+
+	stats, err := importBatches(context.Background(), strings.NewReader(input), send, batchOptions{MaxLines: 2, MaxBytes: 8, Retries: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"a\nbb\n", "ccc\n", "eeeee\n"}
+	if strings.Join(batches, "|") != strings.Join(want, "|") {
+		t.Errorf("batches = %q, want %q", batches, want)
+	}
+	if stats.Batches != 4 || stats.Failed != 1 || stats.Retries != 1 || stats.Rows != 4 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+// TestIsRetryable 验证批次重试只针对可重试的状态码与网络错误，调用方取消时立即停止
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"retryable status", &vmapi.StatusError{StatusCode: 503, Retryable: true}, true},
+		{"bad request", &vmapi.StatusError{StatusCode: 400}, false},
+		{"connection refused", fmt.Errorf("import: %w", &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}), true},
+		{"connection reset", fmt.Errorf("import: %w", syscall.ECONNRESET), true},
+		{"canceled", fmt.Errorf("import: %w", context.Canceled), false},
+		{"deadline exceeded", fmt.Errorf("import: %w", context.DeadlineExceeded), false},
+		{"circuit open", vmapi.ErrCircuitOpen, false},
+		{"other", errors.New("invalid request"), false},
+	}
+	for _, tt := range tests {
+		if got := isRetryable(tt.err); got != tt.want {
+			t.Errorf("%s: isRetryable(%v) = %v, want %v", tt.name, tt.err, got, tt.want)
+		}
+	}
+
+	// Ctrl-C 取消后不再发送后续重试
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	send := func(ctx context.Context, _ io.Reader) error {
+		calls++
+		cancel()
+		return ctx.Err()
+	}
+	err := sendWithRetry(ctx, send, []byte("a\n"), batchOptions{Retries: 5, Backoff: time.Millisecond}, &importStats{})
+	if !errors.Is(err, context.Canceled) || calls != 1 {
+		t.Errorf("err = %v, calls = %d, want canceled after 1 call", err, calls)
+	}
+}
+
+// TestParseBytes 验证字节大小解析
+func TestParseBytes(t *testing.T) {
+	tests := map[string]int64{
+		"":      0,
+		"1024":  1024,
+		"512KB": 512 << 10,
+		"16MB":  16 << 20,
+		"1.5M":  3 << 19,
+		"2GiB":  2 << 30,
+		"100b":  100,
+	}
+	for in, want := range tests {
+		got, err := parseBytes(in)
+		if err != nil || got != want {
+			t.Errorf("parseBytes(%q) = %d, %v, want %d", in, got, err, want)
+		}
+	}
+	if _, err := parseBytes("abc"); err == nil {
+		t.Error("parseBytes(\"abc\") 应返回错误")
+	}
+}
+
+// TestImportBatchesTransform 验证逐行转换以及格式错误时报告行号且不发送所在批次
+func TestImportBatchesTransform(t *testing.T) {
+	input := "a\n\nb\nbad\nc\n"
+	var batches []string
+	send := func(_ context.Context, r io.Reader) error {
+		data, _ := io.ReadAll(r)
+		batches = append(batches, string(data))
+		return nil
+	}
+	transform := func(line []byte) ([]byte, error) {
+		s := strings.TrimSpace(string(line))
+		switch s {
+		case "":
+			return nil, nil
+		case "bad":
+			return nil, errors.New("malformed")
+		}
+		return []byte(strings.ToUpper(s) + "\n"), nil
+	}
+
+	_, err := importBatches(context.Background(), strings.NewReader(input), send, batchOptions{MaxLines: 1, Transform: transform})
+	if err == nil || err.Error() != "line 4: malformed" {
+		t.Fatalf("err = %v, want line 4: malformed", err)
+	}
+	if strings.Join(batches, "|") != "A\n|B\n" {
+		t.Errorf("batches = %q", batches)
+	}
+}
+
+// TestCheckCSVLine 验证引号内换行的多行 CSV 字段在发送前报错，而不是被拆到两个批次
+func TestCheckCSVLine(t *testing.T) {
+	input := "up,1,\"a,b\"\nup,2,\"say \"\"hi\"\"\"\nup,3,\"multi\nline\"\n"
+	var batches []string
+	send := func(_ context.Context, r io.Reader) error {
+		data, _ := io.ReadAll(r)
+		batches = append(batches, string(data))
+		return nil
+	}
+	_, err := importBatches(context.Background(), strings.NewReader(input), send, batchOptions{MaxLines: 1, Transform: checkCSVLine})
+	if err == nil || !strings.HasPrefix(err.Error(), "line 3:") {
+		t.Fatalf("err = %v, want line 3 error", err)
+	}
+	want := []string{"up,1,\"a,b\"\n", "up,2,\"say \"\"hi\"\"\"\n"}
+	if strings.Join(batches, "|") != strings.Join(want, "|") {
+		t.Errorf("batches = %q, want %q", batches, want)
+	}
+}
@@ -3,6 +3,8 @@
 package importcmd
 
 import (
+	"time"
+
 	"github.com/lwmacct/251203-vm-metrics/internal/command"
 	"github.com/lwmacct/251207-go-pkg-version/pkg/version"
 
@@ -21,6 +23,9 @@ var Command = &cli.Command{
 		csvCommand,
 		nativeCommand,
 		prometheusCommand,
+		influxCommand,
+		graphiteCommand,
+		opentsdbCommand,
 		version.Command,
 	},
 	Flags: importFlags(),
@@ -43,6 +48,35 @@ func importFlags() []cli.Flag {
 			Name:  "gzip",
 			Usage: "输入为 gzip 压缩格式",
 		},
+		&cli.StringFlag{
+			Name:  "relabel-config",
+			Usage: "relabel_configs YAML 文件，导入前改写或过滤序列 (仅 json)",
+		},
+		command.ExtraLabelFlag("附加到每个导入序列的标签 (extra_label)，格式 name=value，可重复 (导入接口不支持 extra_filters[]，因此没有 --extra-filter)"),
+		// 分批与重试 (json/csv/prometheus/influx/graphite/opentsdb)
+		&cli.IntFlag{
+			Name:  "batch-lines",
+			Usage: "每批最大行数 (0 表示不限制)",
+		},
+		&cli.StringFlag{
+			Name:  "batch-size",
+			Value: "16MB",
+			Usage: "每批最大字节数，如 512KB, 16MB (0 表示不限制)",
+		},
+		&cli.IntFlag{
+			Name:  "retries",
+			Value: 3,
+			Usage: "每批失败后的最大重试次数 (仅网络错误与 --server-retry-status-codes 中的状态码)，批次不再经过客户端的自动重试",
+		},
+		&cli.DurationFlag{
+			Name:  "retry-backoff",
+			Value: time.Second,
+			Usage: "首次重试等待时间，之后按指数增长 (最大 30s)",
+		},
+		&cli.BoolFlag{
+			Name:  "no-progress",
+			Usage: "不显示进度条",
+		},
 	)
 }
 
@@ -57,7 +91,7 @@ var jsonCommand = &cli.Command{
 // csvCommand csv 子命令
 var csvCommand = &cli.Command{
 	Name:      "csv",
-	Usage:     "导入 CSV 格式",
+	Usage:     "导入 CSV 格式 (每行一条记录，不支持引号内换行的多行字段)",
 	ArgsUsage: "[file]",
 	Action:    actionImportCSV,
 }
@@ -87,3 +121,54 @@ var prometheusCommand = &cli.Command{
 		},
 	},
 }
+
+// influxCommand influx 子命令
+var influxCommand = &cli.Command{
+	Name:      "influx",
+	Usage:     "导入 InfluxDB line protocol 格式",
+	ArgsUsage: "[file]",
+	Action:    actionImportInflux,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "db",
+			Usage: "数据库名，写入为 db 标签 (数据中已有 db 标签时不覆盖)",
+		},
+		&cli.StringFlag{
+			Name:  "precision",
+			Value: "ns",
+			Usage: "时间戳精度: ns, u, ms, s, m, h",
+		},
+		&cli.StringFlag{
+			Name:  "rp",
+			Usage: "retention policy (VictoriaMetrics 忽略该参数，仅为兼容)",
+		},
+		&cli.StringFlag{
+			Name:  "measurement-field-separator",
+			Value: influxDefaultSeparator,
+			Usage: "measurement 与 field 之间的分隔符，非默认值时在客户端转换为 JSON Line 导入",
+		},
+		&cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "只校验输入并报告所有格式错误的行，不发送",
+		},
+	},
+}
+
+// graphiteCommand graphite 子命令
+var graphiteCommand = &cli.Command{
+	Name:      "graphite",
+	Usage:     "导入 Graphite plaintext 格式 (客户端转换为 JSON Line)",
+	ArgsUsage: "[file]",
+	Action:    actionImportGraphite,
+	Flags: []cli.Flag{
+		command.RepeatedFlag("template", "路径到标签的模板，可重复 (不按逗号拆分)，按顺序取第一个匹配的模板，如 'servers.*.cpu.* host=$1 metric=$2'"),
+	},
+}
+
+// opentsdbCommand opentsdb 子命令
+var opentsdbCommand = &cli.Command{
+	Name:      "opentsdb",
+	Usage:     "导入 OpenTSDB JSON put 格式 (客户端转换为 JSON Line)",
+	ArgsUsage: "[file]",
+	Action:    actionImportOpenTSDB,
+}
@@ -0,0 +1,119 @@
+package lint
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/lwmacct/251203-vm-metrics/internal/metricsql"
+	"github.com/urfave/cli/v3"
+	"golang.org/x/term"
+)
+
+// lintResult 检查结果统计
+type lintResult struct {
+	Queries  int
+	Errors   int
+	Warnings int
+}
+
+// actionLint 检查命令行参数、文件或 stdin 中的查询
+func actionLint(ctx context.Context, cmd *cli.Command) error {
+	var queries []query
+	for i, arg := range cmd.Args().Slice() {
+		queries = append(queries, query{Source: fmt.Sprintf("arg%d", i+1), Line: 1, Column: 1, Text: arg})
+	}
+
+	files := cmd.StringSlice("file")
+	if len(files) == 0 && len(queries) == 0 && !term.IsTerminal(int(os.Stdin.Fd())) {
+		files = []string{"-"}
+	}
+	for _, path := range files {
+		qs, err := readQueries(path, os.Stdin)
+		if err != nil {
+			return err
+		}
+		queries = append(queries, qs...)
+	}
+	if len(queries) == 0 {
+		return fmt.Errorf("no query given (pass queries as arguments, --file or stdin)")
+	}
+
+	opts := lintOptions{
+		Format:     cmd.Bool("format"),
+		Width:      cmd.Int("width"),
+		NoWarnings: cmd.Bool("no-warnings"),
+	}
+	result := lintQueries(os.Stdout, os.Stderr, queries, opts)
+
+	_, _ = fmt.Fprintf(os.Stderr, "%d queries checked: %d errors, %d warnings\n", result.Queries, result.Errors, result.Warnings)
+	switch {
+	case result.Errors > 0:
+		return fmt.Errorf("%d of %d queries have syntax errors", result.Errors, result.Queries)
+	case cmd.Bool("strict") && result.Warnings > 0:
+		return errors.New("warnings found (--strict)")
+	}
+	return nil
+}
+
+// lintOptions 检查选项
+type lintOptions struct {
+	Format     bool // 输出格式化后的查询
+	Width      int  // 格式化的单行最大宽度，0 表示始终单行
+	NoWarnings bool // 不报告警告
+}
+
+// lintQueries 逐个解析查询，诊断信息写入 diag，格式化结果写入 out (多个查询之间以空行分隔)
+func lintQueries(out, diag io.Writer, queries []query, opts lintOptions) lintResult {
+	result := lintResult{Queries: len(queries)}
+	formatted := 0
+	for _, q := range queries {
+		expr, err := metricsql.Parse(q.Text)
+		if err != nil {
+			result.Errors++
+			var perr *metricsql.Error
+			if errors.As(err, &perr) {
+				report(diag, q, "error", perr.Pos, perr.Msg)
+			} else {
+				_, _ = fmt.Fprintf(diag, "%s: error: %v\n", q.location(1, 1), err)
+			}
+			continue
+		}
+
+		if !opts.NoWarnings {
+			for _, w := range metricsql.Lint(expr) {
+				result.Warnings++
+				report(diag, q, "warning", w.Pos, w.Msg)
+			}
+		}
+
+		if opts.Format {
+			if formatted > 0 {
+				_, _ = fmt.Fprintln(out)
+			}
+			if opts.Width > 0 {
+				_, _ = fmt.Fprintln(out, metricsql.Prettify(expr, opts.Width))
+			} else {
+				_, _ = fmt.Fprintln(out, metricsql.Format(expr))
+			}
+			formatted++
+		}
+	}
+	return result
+}
+
+// report 输出一条诊断信息及指向出错位置的 ^ 标记:
+//
+//	rules.yaml:12:25: error: unexpected ")" after expression
+//	    rate(foo_total[5m]))
+//	                       ^
+func report(w io.Writer, q query, level string, pos int, msg string) {
+	line, col := metricsql.Location(q.Text, pos)
+	_, _ = fmt.Fprintf(w, "%s: %s: %s\n", q.location(line, col), level, msg)
+	for _, l := range strings.Split(metricsql.Caret(q.Text, pos), "\n") {
+		_, _ = fmt.Fprintf(w, "    %s\n", l)
+	}
+}
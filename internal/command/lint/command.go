@@ -0,0 +1,39 @@
+// Package lint 提供 vm-metrics lint 命令
+package lint
+
+import (
+	"github.com/urfave/cli/v3"
+)
+
+// Command lint 根命令
+// 只做本地语法分析，不读取配置、不连接服务器
+var Command = &cli.Command{
+	Name:      "lint",
+	Usage:     "离线检查 MetricsQL/PromQL 语法并格式化",
+	ArgsUsage: "[query]...",
+	Action:    actionLint,
+	Flags: []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:    "file",
+			Aliases: []string{"f"},
+			Usage:   "从文件读取查询: .yaml/.yml/.json 提取所有 expr 字段 (告警规则、Grafana 面板)，其他文件按空行分隔，- 表示 stdin",
+		},
+		&cli.BoolFlag{
+			Name:  "format",
+			Usage: "将格式化后的查询输出到 stdout",
+		},
+		&cli.IntFlag{
+			Name:  "width",
+			Usage: "格式化时单行最大宽度，超出则拆分为多行 (0 表示始终单行)",
+			Value: 100,
+		},
+		&cli.BoolFlag{
+			Name:  "strict",
+			Usage: "存在警告时也以非零状态退出",
+		},
+		&cli.BoolFlag{
+			Name:  "no-warnings",
+			Usage: "只报告语法错误",
+		},
+	},
+}
@@ -0,0 +1,133 @@
+package lint
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// query 待检查的查询及其来源位置
+type query struct {
+	Source string // 来源: 文件名、stdin 或 arg N
+	Line   int    // 查询首行在来源中的行号 (从 1 开始)
+	Column int    // 查询首字符在来源中的列号，仅作用于首行
+	Text   string
+}
+
+// location 返回查询内偏移 pos 在来源中的位置 source:line:col
+func (q query) location(line, col int) string {
+	if line == 1 {
+		col += q.Column - 1
+	}
+	return fmt.Sprintf("%s:%d:%d", q.Source, q.Line+line-1, col)
+}
+
+// readQueries 从文件读取查询，path 为 - 时读取 stdin
+func readQueries(path string, stdin io.Reader) ([]query, error) {
+	var (
+		data []byte
+		err  error
+	)
+	source := path
+	if path == "-" {
+		source = "stdin"
+		data, err = io.ReadAll(stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", source, err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml", ".json":
+		return extractExprs(source, data)
+	default:
+		return splitQueries(source, string(data)), nil
+	}
+}
+
+// splitQueries 按空行切分文本，每段为一个查询 (允许跨行)，只含注释的段落被忽略
+func splitQueries(source, text string) []query {
+	var (
+		queries []query
+		block   []string
+		start   int
+	)
+	flush := func() {
+		if len(block) == 0 {
+			return
+		}
+		for _, line := range block {
+			if l := strings.TrimSpace(line); l != "" && !strings.HasPrefix(l, "#") {
+				queries = append(queries, query{Source: source, Line: start, Column: 1, Text: strings.Join(block, "\n")})
+				break
+			}
+		}
+		block = nil
+	}
+
+	for i, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		if strings.TrimSpace(line) == "" {
+			flush()
+			continue
+		}
+		if len(block) == 0 {
+			start = i + 1
+		}
+		block = append(block, line)
+	}
+	flush()
+	return queries
+}
+
+// extractExprs 提取 YAML/JSON 文档中所有 expr 字段的字符串值
+// 适用于 Prometheus/vmalert 规则文件与 Grafana 面板 JSON
+func extractExprs(source string, data []byte) ([]query, error) {
+	var queries []query
+	dec := yaml.NewDecoder(strings.NewReader(string(data)))
+	for {
+		var doc yaml.Node
+		if err := dec.Decode(&doc); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("parse %s: %w", source, err)
+		}
+		walkExprs(&doc, func(n *yaml.Node) {
+			q := query{Source: source, Line: n.Line, Column: n.Column, Text: n.Value}
+			switch n.Style {
+			case yaml.LiteralStyle, yaml.FoldedStyle:
+				// 块标量的内容从下一行开始，缩进已被去掉，列号无法还原
+				q.Line++
+				q.Column = 1
+			case yaml.DoubleQuotedStyle, yaml.SingleQuotedStyle:
+				q.Column++
+			}
+			queries = append(queries, q)
+		})
+	}
+	return queries, nil
+}
+
+// walkExprs 递归查找键为 expr 的非空字符串标量
+func walkExprs(n *yaml.Node, fn func(*yaml.Node)) {
+	if n.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			if key.Value == "expr" && value.Kind == yaml.ScalarNode && strings.TrimSpace(value.Value) != "" {
+				fn(value)
+				continue
+			}
+			walkExprs(value, fn)
+		}
+		return
+	}
+	for _, c := range n.Content {
+		walkExprs(c, fn)
+	}
+}
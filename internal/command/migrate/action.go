@@ -0,0 +1,234 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lwmacct/251203-vm-metrics/internal/command"
+	"github.com/lwmacct/251203-vm-metrics/internal/util"
+	"github.com/lwmacct/251203-vm-metrics/internal/vmapi"
+	"github.com/urfave/cli/v3"
+)
+
+// migration 迁移任务
+type migration struct {
+	src         vmapi.Client
+	dst         vmapi.Client
+	exporter    vmapi.Exporter
+	importer    vmapi.Importer
+	match       []string
+	windows     []command.TimeWindow
+	concurrency int
+
+	latencyOffset time.Duration // 目标实例的 -search.latencyOffset，对比前等待
+}
+
+// actionMigrate 将源实例的数据以 native 格式直接写入目标实例
+func actionMigrate(ctx context.Context, cmd *cli.Command) error {
+	match := cmd.Args().Slice()
+	if len(match) == 0 {
+		return fmt.Errorf("at least one match selector is required")
+	}
+
+	m, err := newMigration(cmd, match)
+	if err != nil {
+		return err
+	}
+
+	if !cmd.Bool("verify-only") {
+		if err := m.run(ctx); err != nil {
+			return err
+		}
+	}
+	if cmd.Bool("verify") || cmd.Bool("verify-only") {
+		return m.verify(ctx)
+	}
+	return nil
+}
+
+// newMigration 解析源/目标连接与时间范围
+func newMigration(cmd *cli.Command, match []string) (*migration, error) {
+	cfg := command.GetConfig(cmd)
+
+	srcCfg, err := resolveEndpoint(cmd, cfg, "src", cfg.Migrate.Source, true)
+	if err != nil {
+		return nil, err
+	}
+	dstCfg, err := resolveEndpoint(cmd, cfg, "dst", cfg.Migrate.Destination, false)
+	if err != nil {
+		return nil, err
+	}
+	if sameEndpoint(srcCfg, dstCfg) {
+		return nil, fmt.Errorf("source and destination are the same")
+	}
+
+	src, err := command.NewProfileClient(srcCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create source client: %w", err)
+	}
+	dst, err := command.NewProfileClient(dstCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create destination client: %w", err)
+	}
+	exporter, ok := src.(vmapi.Exporter)
+	if !ok {
+		return nil, fmt.Errorf("source client does not support export")
+	}
+	importer, ok := dst.(vmapi.Importer)
+	if !ok {
+		return nil, fmt.Errorf("destination client does not support import")
+	}
+
+	start, err := command.ParseTimeFlag(cmd, "start")
+	if err != nil {
+		return nil, err
+	}
+	end, err := command.ParseTimeFlag(cmd, "end")
+	if err != nil {
+		return nil, err
+	}
+	if end.IsZero() {
+		end = time.Now()
+	}
+
+	var chunk time.Duration
+	if s := cmd.String("chunk"); s != "" {
+		chunk, err = command.ParseDuration(s)
+		if err != nil || chunk <= 0 {
+			return nil, fmt.Errorf("invalid chunk duration: %s", s)
+		}
+	}
+	if start.IsZero() && (chunk > 0 || cmd.Bool("verify") || cmd.Bool("verify-only")) {
+		return nil, fmt.Errorf("--start is required for --chunk and --verify")
+	}
+	if !start.IsZero() && !start.Before(end) {
+		return nil, fmt.Errorf("--start must be before --end")
+	}
+
+	return &migration{
+		src:         src,
+		dst:         dst,
+		exporter:    exporter,
+		importer:    importer,
+		match:       match,
+		windows:     command.SplitTimeRange(start, end, chunk),
+		concurrency: max(cmd.Int("concurrency"), 1),
+
+		latencyOffset: cmd.Duration("verify-latency-offset"),
+	}, nil
+}
+
+// run 并发迁移所有分片
+// 单个分片失败不影响其他分片，结束时列出失败的时间窗口以便重试
+func (m *migration) run(ctx context.Context) error {
+	started := time.Now()
+	jobs := make(chan int)
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		failed []int
+		total  atomic.Int64
+	)
+
+	for range m.concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				chunkStart := time.Now()
+				n, err := m.migrateWindow(ctx, i)
+				total.Add(n)
+
+				mu.Lock()
+				if err != nil {
+					failed = append(failed, i)
+					_, _ = fmt.Fprintf(os.Stderr, "[%d/%d] %s failed: %v\n", i+1, len(m.windows), m.windowString(i), err)
+				} else {
+					_, _ = fmt.Fprintf(os.Stderr, "[%d/%d] %s %s in %s\n", i+1, len(m.windows), m.windowString(i),
+						util.FormatBytes(n), time.Since(chunkStart).Round(time.Millisecond))
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for i := range m.windows {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	_, _ = fmt.Fprintf(os.Stderr, "Migrated %s in %d chunks (%d failed) in %s\n",
+		util.FormatBytes(total.Load()), len(m.windows), len(failed), time.Since(started).Round(time.Millisecond))
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d chunks failed", len(failed), len(m.windows))
+	}
+	return nil
+}
+
+// migrateWindow 通过管道将单个时间窗口的 ExportNative 输出直接写入 ImportNative
+// 返回传输的字节数
+func (m *migration) migrateWindow(ctx context.Context, i int) (int64, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	opts := &vmapi.ExportOptions{Match: m.match}
+	opts.Start, opts.End = m.windowRange(i)
+
+	pr, pw := io.Pipe()
+	cw := &util.CountingWriter{W: pw}
+	exportDone := make(chan error, 1)
+	go func() {
+		err := m.exporter.ExportNative(ctx, cw, opts)
+		_ = pw.CloseWithError(err)
+		exportDone <- err
+	}()
+
+	importErr := m.importer.ImportNative(ctx, pr, nil)
+	// 导入提前结束时让导出端停止写入
+	_ = pr.CloseWithError(errors.Join(importErr, io.ErrClosedPipe))
+	exportErr := <-exportDone
+
+	// 导出失败时导入端只会看到读取错误，优先返回导出错误
+	if exportErr != nil && !errors.Is(exportErr, io.ErrClosedPipe) {
+		return cw.N, fmt.Errorf("export: %w", exportErr)
+	}
+	if importErr != nil {
+		return cw.N, fmt.Errorf("import: %w", importErr)
+	}
+	return cw.N, nil
+}
+
+// windowRange 返回分片请求的时间范围
+// VictoriaMetrics 的 end 参数包含边界，非最后一个分片提前 1ms 结束以避免样本重复
+func (m *migration) windowRange(i int) (time.Time, time.Time) {
+	w := m.windows[i]
+	if i == len(m.windows)-1 {
+		return w.Start, w.End
+	}
+	return w.Start, w.End.Add(-time.Millisecond)
+}
+
+// windowString 返回分片时间窗口的可读形式
+func (m *migration) windowString(i int) string {
+	w := m.windows[i]
+	if w.Start.IsZero() {
+		return "[-, " + w.End.Format(time.RFC3339) + "]"
+	}
+	return "[" + w.Start.Format(time.RFC3339) + ", " + w.End.Format(time.RFC3339) + ")"
+}
@@ -0,0 +1,118 @@
+package migrate
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lwmacct/251203-vm-metrics/internal/command"
+	"github.com/lwmacct/251203-vm-metrics/internal/vmapi"
+)
+
+// newTestMigration 创建连接到测试服务器的迁移任务
+func newTestMigration(t *testing.T, src, dst *httptest.Server, start, end time.Time, chunk time.Duration) *migration {
+	t.Helper()
+	newClient := func(url string) vmapi.Client {
+		c, err := vmapi.NewClient(&vmapi.ClientConfig{URL: url, Timeout: 5 * time.Second})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+	srcClient, dstClient := newClient(src.URL), newClient(dst.URL)
+	return &migration{
+		src:         srcClient,
+		dst:         dstClient,
+		exporter:    srcClient.(vmapi.Exporter),
+		importer:    dstClient.(vmapi.Importer),
+		match:       []string{`{job="node"}`},
+		windows:     command.SplitTimeRange(start, end, chunk),
+		concurrency: 2,
+	}
+}
+
+// TestMigrateWindows 验证分片的请求范围互不重叠，每个分片的导出内容写入目标
+func TestMigrateWindows(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		exported []string
+		imported []string
+	)
+	src := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/export/native" || r.FormValue("match[]") != `{job="node"}` {
+			http.Error(w, "unexpected request "+r.URL.String(), http.StatusBadRequest)
+			return
+		}
+		window := r.FormValue("start") + "-" + r.FormValue("end")
+		mu.Lock()
+		exported = append(exported, window)
+		mu.Unlock()
+		_, _ = io.WriteString(w, window)
+	}))
+	defer src.Close()
+	dst := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		imported = append(imported, string(data))
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer dst.Close()
+
+	start := time.Unix(1700000000, 0)
+	m := newTestMigration(t, src, dst, start, start.Add(150*time.Minute), time.Hour)
+	if err := m.run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// 非最后一个分片提前 1ms 结束，最后一个分片包含 end
+	want := []string{
+		"1700000000.000-1700003599.999",
+		"1700003600.000-1700007199.999",
+		"1700007200.000-1700009000.000",
+	}
+	slices.Sort(exported)
+	slices.Sort(imported)
+	if !slices.Equal(exported, want) {
+		t.Errorf("exported windows = %q, want %q", exported, want)
+	}
+	if !slices.Equal(imported, want) {
+		t.Errorf("imported bodies = %q, want %q", imported, want)
+	}
+}
+
+// TestMigrateWindowFailure 验证单个分片失败时其余分片继续迁移
+func TestMigrateWindowFailure(t *testing.T) {
+	var calls sync.Map
+	src := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Store(r.FormValue("start"), true)
+		if r.FormValue("start") == "1700003600.000" {
+			http.Error(w, "boom", http.StatusBadRequest)
+			return
+		}
+		_, _ = io.WriteString(w, "data")
+	}))
+	defer src.Close()
+	dst := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer dst.Close()
+
+	start := time.Unix(1700000000, 0)
+	m := newTestMigration(t, src, dst, start, start.Add(3*time.Hour), time.Hour)
+	err := m.run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "1 of 3 chunks failed") {
+		t.Errorf("err = %v, want 1 of 3 chunks failed", err)
+	}
+	n := 0
+	calls.Range(func(_, _ any) bool { n++; return true })
+	if n != 3 {
+		t.Errorf("exported %d windows, want 3", n)
+	}
+}
@@ -0,0 +1,125 @@
+// Package migrate 提供 vm-metrics migrate 命令
+package migrate
+
+import (
+	"time"
+
+	"github.com/lwmacct/251203-vm-metrics/internal/command"
+	"github.com/urfave/cli/v3"
+)
+
+// Command migrate 命令
+var Command = &cli.Command{
+	Name:      "migrate",
+	Usage:     "在两个 VictoriaMetrics 实例之间迁移数据 (native 格式)",
+	ArgsUsage: "<match>...",
+	Before:    command.BeforeLoadConfig,
+	Action:    actionMigrate,
+	Flags:     migrateFlags(),
+}
+
+// migrateFlags 返回迁移命令的 flags (基础 + 源/目标连接 + 迁移参数)
+func migrateFlags() []cli.Flag {
+	flags := command.BaseFlags()
+	flags = append(flags, endpointFlags("src", "源")...)
+	flags = append(flags, endpointFlags("dst", "目标")...)
+	return append(flags,
+		// 迁移参数
+		&cli.StringFlag{
+			Name:  "start",
+			Usage: "开始时间 (如 now-7d, 2024-05-01, RFC3339 或 Unix 时间戳)",
+		},
+		&cli.StringFlag{
+			Name:  "end",
+			Usage: "结束时间 (默认: now)",
+		},
+		&cli.StringFlag{
+			Name:  "chunk",
+			Usage: "按时间窗口分片迁移，如 1h, 1d (需要 --start)",
+		},
+		&cli.IntFlag{
+			Name:  "concurrency",
+			Value: 1,
+			Usage: "并发迁移的分片数",
+		},
+		&cli.BoolFlag{
+			Name:  "verify",
+			Usage: "迁移完成后对比源与目标每个序列的样本数",
+		},
+		&cli.BoolFlag{
+			Name:  "verify-only",
+			Usage: "仅对比样本数，不迁移数据",
+		},
+		&cli.DurationFlag{
+			Name:  "verify-latency-offset",
+			Value: 30 * time.Second,
+			Usage: "目标实例的 -search.latencyOffset，对比前等待迁移范围的结束时间超出该偏移，避免最近的数据尚不可查询",
+		},
+	)
+}
+
+// endpointFlags 返回一端 (源或目标) 的连接 flags，如 --src-url, --dst-auth-token
+func endpointFlags(prefix, desc string) []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  prefix + "-profile",
+			Usage: desc + "使用的 profile 名称 (替代 migrate 配置段)",
+		},
+		&cli.StringFlag{
+			Name:  prefix + "-url",
+			Usage: desc + "服务器地址",
+		},
+		&cli.StringFlag{
+			Name:  prefix + "-path-prefix",
+			Usage: desc + " API 路径前缀",
+		},
+		&cli.DurationFlag{
+			Name:  prefix + "-timeout",
+			Usage: desc + "请求超时时间 (默认: --server-timeout)",
+		},
+		&cli.StringFlag{
+			Name:  prefix + "-select-url",
+			Usage: desc + "集群 vmselect 地址",
+		},
+		&cli.StringFlag{
+			Name:  prefix + "-insert-url",
+			Usage: desc + "集群 vminsert 地址",
+		},
+		&cli.StringFlag{
+			Name:  prefix + "-tenant",
+			Usage: desc + "集群租户 ID",
+		},
+		&cli.StringFlag{
+			Name:  prefix + "-auth-type",
+			Usage: desc + "认证类型: basic, bearer",
+		},
+		&cli.StringFlag{
+			Name:  prefix + "-auth-user",
+			Usage: desc + " Basic 认证用户名",
+		},
+		&cli.StringFlag{
+			Name:  prefix + "-auth-password",
+			Usage: desc + " Basic 认证密码",
+		},
+		&cli.StringFlag{
+			Name:  prefix + "-auth-token",
+			Usage: desc + " Bearer Token",
+		},
+		&cli.StringFlag{
+			Name:  prefix + "-tls-ca",
+			Usage: desc + " CA 证书路径",
+		},
+		&cli.StringFlag{
+			Name:  prefix + "-tls-cert",
+			Usage: desc + "客户端证书路径",
+		},
+		&cli.StringFlag{
+			Name:  prefix + "-tls-key",
+			Usage: desc + "客户端密钥路径",
+		},
+		&cli.BoolFlag{
+			Name:  prefix + "-tls-skip-verify",
+			Usage: desc + "跳过证书验证",
+		},
+	}
+}
@@ -0,0 +1,94 @@
+package migrate
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lwmacct/251203-vm-metrics/internal/config"
+	"github.com/lwmacct/251203-vm-metrics/internal/util"
+	"github.com/urfave/cli/v3"
+)
+
+// resolveEndpoint 计算一端的连接配置，优先级从低到高：
+//  1. 配置文件 migrate.source / migrate.destination 段 (源未设置地址时使用顶层配置)
+//  2. --<prefix>-profile 指定的 profile (替代配置段)
+//  3. --<prefix>-* flags
+func resolveEndpoint(cmd *cli.Command, cfg *config.Config, prefix string, section config.ProfileConfig, fallback bool) (*config.ProfileConfig, error) {
+	p := section
+	if name := cmd.String(prefix + "-profile"); name != "" {
+		profile, ok := cfg.Profiles[name]
+		if !ok {
+			return nil, fmt.Errorf("profile not found: %s", name)
+		}
+		p = profile
+	} else if fallback && !hasAddress(&p) {
+		p = config.ProfileConfig{Server: cfg.Server, Auth: cfg.Auth, TLS: cfg.TLS}
+	}
+
+	setString := func(name string, dst *string) {
+		if cmd.IsSet(prefix + "-" + name) {
+			*dst = cmd.String(prefix + "-" + name)
+		}
+	}
+	setString("url", &p.Server.URL)
+	setString("path-prefix", &p.Server.PathPrefix)
+	setString("select-url", &p.Server.SelectURL)
+	setString("insert-url", &p.Server.InsertURL)
+	setString("tenant", &p.Server.Tenant)
+	setString("auth-type", &p.Auth.Type)
+	setString("auth-user", &p.Auth.User)
+	setString("auth-password", &p.Auth.Password)
+	setString("auth-token", &p.Auth.Token)
+	setString("tls-ca", &p.TLS.CA)
+	setString("tls-cert", &p.TLS.Cert)
+	setString("tls-key", &p.TLS.Key)
+	if cmd.IsSet(prefix + "-tls-skip-verify") {
+		p.TLS.SkipVerify = cmd.Bool(prefix + "-tls-skip-verify")
+	}
+	if cmd.IsSet(prefix + "-timeout") {
+		p.Server.Timeout = cmd.Duration(prefix + "-timeout")
+	}
+	if p.Server.Timeout == 0 {
+		p.Server.Timeout = cfg.Server.Timeout
+	}
+	// migrate 配置段未设置重试策略时沿用顶层配置
+	if p.Server.RetryMaxAttempts == 0 {
+		p.Server.RetryMaxAttempts = cfg.Server.RetryMaxAttempts
+		p.Server.RetryWait = cfg.Server.RetryWait
+		p.Server.RetryMaxWait = cfg.Server.RetryMaxWait
+		p.Server.RetryStatusCodes = cfg.Server.RetryStatusCodes
+		p.Server.RetryNetworkErrors = cfg.Server.RetryNetworkErrors
+		p.Server.BreakerThreshold = cfg.Server.BreakerThreshold
+		p.Server.BreakerCooldown = cfg.Server.BreakerCooldown
+	}
+
+	if !hasAddress(&p) {
+		return nil, fmt.Errorf("%s server url is required (use --%s-url or migrate config section)", prefix, prefix)
+	}
+	return &p, nil
+}
+
+// hasAddress 是否配置了服务器地址
+func hasAddress(p *config.ProfileConfig) bool {
+	return p.Server.URL != "" || p.Server.SelectURL != "" || p.Server.InsertURL != ""
+}
+
+// sameEndpoint 源与目标是否指向同一存储
+// 路径前缀与租户相同，且读地址相同、写地址相同，或源的读地址即目标的写地址 (单机版)；
+// 集群版两端配置同一组 select/insert 地址时也能识别
+func sameEndpoint(a, b *config.ProfileConfig) bool {
+	if a.Server.PathPrefix != b.Server.PathPrefix || a.Server.Tenant != b.Server.Tenant {
+		return false
+	}
+	aRead, aWrite := storageAddrs(a)
+	bRead, bWrite := storageAddrs(b)
+	same := func(x, y string) bool { return x != "" && x == y }
+	return same(aRead, bRead) || same(aWrite, bWrite) || same(aRead, bWrite)
+}
+
+// storageAddrs 返回实际使用的读/写地址，select/insert 未设置时使用 url
+func storageAddrs(p *config.ProfileConfig) (read, write string) {
+	read = strings.TrimSuffix(util.FirstNonEmpty(p.Server.SelectURL, p.Server.URL), "/")
+	write = strings.TrimSuffix(util.FirstNonEmpty(p.Server.InsertURL, p.Server.URL), "/")
+	return read, write
+}
@@ -0,0 +1,41 @@
+package migrate
+
+import (
+	"testing"
+
+	"github.com/lwmacct/251203-vm-metrics/internal/config"
+)
+
+// TestSameEndpoint 验证按实际读写地址、租户与路径前缀识别同一存储
+func TestSameEndpoint(t *testing.T) {
+	server := func(url, selectURL, insertURL, tenant string) *config.ProfileConfig {
+		return &config.ProfileConfig{Server: config.ServerConfig{URL: url, SelectURL: selectURL, InsertURL: insertURL, Tenant: tenant}}
+	}
+	cluster := server("", "http://vmselect:8481", "http://vminsert:8480", "1:0")
+
+	tests := []struct {
+		name     string
+		src, dst *config.ProfileConfig
+		want     bool
+	}{
+		{"same single node", server("http://vm:8428", "", "", ""), server("http://vm:8428/", "", "", ""), true},
+		{"different single node", server("http://vm-a:8428", "", "", ""), server("http://vm-b:8428", "", "", ""), false},
+		{"same cluster", cluster, server("", "http://vmselect:8481", "http://vminsert:8480", "1:0"), true},
+		{"same cluster other tenant", cluster, server("", "http://vmselect:8481", "http://vminsert:8480", "2:0"), false},
+		{"same insert only", cluster, server("", "", "http://vminsert:8480", "1:0"), true},
+		{"url is source select", server("", "http://vm:8428", "", ""), server("", "", "http://vm:8428", ""), true},
+		{"different cluster", cluster, server("", "http://vmselect-b:8481", "http://vminsert-b:8480", "1:0"), false},
+		{"insert only vs select only", server("", "", "http://a:8480", ""), server("", "http://b:8481", "", ""), false},
+	}
+	for _, tt := range tests {
+		if got := sameEndpoint(tt.src, tt.dst); got != tt.want {
+			t.Errorf("%s: sameEndpoint = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	prefixed := server("http://vm:8428", "", "", "")
+	prefixed.Server.PathPrefix = "/vm"
+	if sameEndpoint(server("http://vm:8428", "", "", ""), prefixed) {
+		t.Error("different path prefix should not be the same endpoint")
+	}
+}
@@ -0,0 +1,141 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lwmacct/251203-vm-metrics/internal/util"
+	"github.com/lwmacct/251203-vm-metrics/internal/vmapi"
+)
+
+// maxReportedMismatches 最多列出的不一致序列数
+const maxReportedMismatches = 20
+
+// seriesCount 单个序列的样本数
+type seriesCount struct {
+	metric map[string]string
+	count  float64
+}
+
+// verify 按分片对比源与目标每个序列的样本数
+// 目标中多出的序列 (迁移前已存在的数据) 不视为错误
+func (m *migration) verify(ctx context.Context) error {
+	if err := m.waitSearchable(ctx); err != nil {
+		return err
+	}
+
+	src, err := m.countSamples(ctx, m.src)
+	if err != nil {
+		return fmt.Errorf("verify source: %w", err)
+	}
+	dst, err := m.countSamples(ctx, m.dst)
+	if err != nil {
+		return fmt.Errorf("verify destination: %w", err)
+	}
+
+	keys := make([]string, 0, len(src))
+	for k := range src {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	mismatched := 0
+	for _, k := range keys {
+		want := src[k]
+		var got float64
+		if c, ok := dst[k]; ok {
+			got = c.count
+		}
+		if got == want.count {
+			continue
+		}
+		mismatched++
+		if mismatched <= maxReportedMismatches {
+			_, _ = fmt.Fprintf(os.Stderr, "MISMATCH %s: source=%.0f destination=%.0f\n", formatSeries(want.metric), want.count, got)
+		}
+	}
+	if mismatched > maxReportedMismatches {
+		_, _ = fmt.Fprintf(os.Stderr, "... and %d more\n", mismatched-maxReportedMismatches)
+	}
+
+	_, _ = fmt.Fprintf(os.Stderr, "Verified %d series: %d mismatched\n", len(src), mismatched)
+	if mismatched > 0 {
+		return fmt.Errorf("verification failed: %d of %d series mismatched", mismatched, len(src))
+	}
+	return nil
+}
+
+// waitSearchable 等待刚导入的数据在目标中可查询
+// 先刷新目标缓冲的新数据，再等待迁移范围的结束时间超出 latency offset (服务端不返回比 now-offset 更新的数据)
+func (m *migration) waitSearchable(ctx context.Context) error {
+	if admin, ok := m.dst.(vmapi.Admin); ok {
+		// 集群版的 force_flush 位于 vmstorage，经由 vmselect/vminsert 调用失败时仅依赖等待
+		if err := admin.ForceFlush(ctx); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Warning: failed to flush destination: %v\n", err)
+		}
+	}
+
+	_, end := m.windowRange(len(m.windows) - 1)
+	wait := time.Until(end.Add(m.latencyOffset))
+	if wait <= 0 {
+		return nil
+	}
+	_, _ = fmt.Fprintf(os.Stderr, "Waiting %s for the search latency offset before verifying\n", wait.Round(time.Second))
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(wait):
+		return nil
+	}
+}
+
+// countSamples 通过 count_over_time 统计每个序列在迁移时间范围内的样本数
+// 与迁移使用相同的时间窗口，避免单次查询覆盖过长的时间范围
+func (m *migration) countSamples(ctx context.Context, client vmapi.Client) (map[string]*seriesCount, error) {
+	counts := make(map[string]*seriesCount)
+	for i := range m.windows {
+		start, end := m.windowRange(i)
+		// 回溯窗口为 (end-lookbehind, end]，多加 1ms 以包含 start
+		lookbehind := end.Sub(start) + time.Millisecond
+
+		for _, match := range m.match {
+			query := fmt.Sprintf("count_over_time(%s[%dms]) keep_metric_names", match, lookbehind.Milliseconds())
+			// 刚导入的数据可能与缓存的结果不一致，跳过服务端缓存
+			result, err := client.Query(ctx, query, end, &vmapi.QueryOptions{NoCache: true})
+			if err != nil {
+				return nil, err
+			}
+			for _, s := range result.Samples {
+				key := util.SeriesKey(s.Metric)
+				c, ok := counts[key]
+				if !ok {
+					c = &seriesCount{metric: s.Metric}
+					counts[key] = c
+				}
+				c.count += s.Value.Value
+			}
+		}
+	}
+	return counts, nil
+}
+
+// formatSeries 格式化为 name{k="v", ...}
+func formatSeries(metric map[string]string) string {
+	keys := make([]string, 0, len(metric))
+	for k := range metric {
+		if k != "__name__" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", k, metric[k]))
+	}
+	return metric["__name__"] + "{" + strings.Join(pairs, ", ") + "}"
+}
@@ -0,0 +1,95 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countServer 对 count_over_time 查询按 counts 返回每个序列的样本数，并记录 nocache 参数与 force_flush 调用
+type countServer struct {
+	*httptest.Server
+	counts  map[string]int // job 标签 -> 每个分片的样本数
+	cached  atomic.Int32   // 未带 nocache=1 的查询数
+	flushed atomic.Int32
+}
+
+func newCountServer(counts map[string]int) *countServer {
+	s := &countServer{counts: counts}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/internal/force_flush" {
+			s.flushed.Add(1)
+			return
+		}
+		if r.FormValue("nocache") != "1" {
+			s.cached.Add(1)
+		}
+		var result []string
+		for job, n := range s.counts {
+			result = append(result, fmt.Sprintf(`{"metric":{"__name__":"up","job":%q},"value":[%s,"%d"]}`, job, r.FormValue("time"), n))
+		}
+		_, _ = fmt.Fprintf(w, `{"status":"success","data":{"resultType":"vector","result":[%s]}}`, strings.Join(result, ","))
+	}))
+	return s
+}
+
+// TestVerify 验证按分片累加的样本数对比：缺失或不一致的序列报错，目标多出的序列忽略
+func TestVerify(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	tests := []struct {
+		name    string
+		dst     map[string]int
+		wantErr string
+	}{
+		{"match", map[string]int{"a": 10, "b": 5}, ""},
+		{"extra series in destination", map[string]int{"a": 10, "b": 5, "c": 1}, ""},
+		{"mismatch", map[string]int{"a": 10, "b": 4}, "1 of 2 series mismatched"},
+		{"missing", map[string]int{"a": 10}, "1 of 2 series mismatched"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := newCountServer(map[string]int{"a": 10, "b": 5})
+			defer src.Close()
+			dst := newCountServer(tt.dst)
+			defer dst.Close()
+
+			m := newTestMigration(t, src.Server, dst.Server, start, start.Add(2*time.Hour), time.Hour)
+			err := m.verify(context.Background())
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("verify: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("err = %v, want %q", err, tt.wantErr)
+			}
+			if src.cached.Load() != 0 || dst.cached.Load() != 0 {
+				t.Error("verify 查询应带 nocache=1")
+			}
+			if dst.flushed.Load() != 1 {
+				t.Errorf("destination flushed %d times, want 1", dst.flushed.Load())
+			}
+		})
+	}
+}
+
+// TestVerifyWaitsLatencyOffset 验证迁移范围的结束时间在 latency offset 内时等待后再对比
+func TestVerifyWaitsLatencyOffset(t *testing.T) {
+	src := newCountServer(map[string]int{"a": 1})
+	defer src.Close()
+	dst := newCountServer(map[string]int{"a": 1})
+	defer dst.Close()
+
+	end := time.Now()
+	m := newTestMigration(t, src.Server, dst.Server, end.Add(-time.Hour), end, 0)
+	m.latencyOffset = 200 * time.Millisecond
+	if err := m.verify(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(end) < m.latencyOffset {
+		t.Errorf("verify 应等待到 end + latency offset 之后")
+	}
+}
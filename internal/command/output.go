@@ -0,0 +1,99 @@
+package command
+
+import (
+	"os"
+
+	"github.com/lwmacct/251203-vm-metrics/internal/config"
+	"github.com/lwmacct/251203-vm-metrics/internal/output"
+	"github.com/urfave/cli/v3"
+	"golang.org/x/term"
+)
+
+// OutputDisplayFlags 返回与输出格式无关的展示 flags (时间、数值、标签列)
+// 供 query 与 admin 等输出查询结果的命令共用
+func OutputDisplayFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:    "output-time-format",
+			Aliases: []string{"time-format"},
+			Usage:   "时间戳格式: rfc3339, rfc3339nano, unix, unix_ms, relative (如 3m ago)",
+			Value:   Defaults.Output.TimeFormat,
+		},
+		&cli.BoolFlag{
+			Name:    "output-utc",
+			Aliases: []string{"utc"},
+			Usage:   "时间戳使用 UTC (默认为本地时区)",
+			Value:   Defaults.Output.UTC,
+		},
+		&cli.IntFlag{
+			Name:    "output-precision",
+			Aliases: []string{"precision"},
+			Usage:   "数值保留的小数位数 (-1 表示不限制)",
+			Value:   Defaults.Output.Precision,
+		},
+		&cli.StringFlag{
+			Name:    "output-value-unit",
+			Aliases: []string{"value-unit"},
+			Usage:   "数值单位: si (k/M/G), iec (Ki/Mi/Gi), bytes (KiB/MiB), seconds (3m20s), percent (0-1 的比例)",
+			Value:   Defaults.Output.ValueUnit,
+		},
+		&cli.StringSliceFlag{
+			Name:    "output-columns",
+			Aliases: []string{"columns"},
+			Usage:   "只展示这些标签，每个标签单独一列 (如 pod,namespace)",
+			Value:   Defaults.Output.Columns,
+		},
+		&cli.StringSliceFlag{
+			Name:    "output-without",
+			Aliases: []string{"without"},
+			Usage:   "从 METRIC 列中去掉这些标签 (如 job,instance)",
+			Value:   Defaults.Output.Without,
+		},
+		&cli.BoolFlag{
+			Name:    "output-no-metric-name",
+			Aliases: []string{"no-metric-name"},
+			Usage:   "不展示指标名",
+			Value:   Defaults.Output.NoMetricName,
+		},
+	}
+}
+
+// NewWriter 按配置的输出格式创建输出 Writer
+func NewWriter(cfg *config.Config) (output.Writer, error) {
+	return output.New(cfg.Output.Format, OutputOptions(cfg))
+}
+
+// OutputOptions 从配置构建输出选项
+// 输出不是终端或设置了 NO_COLOR 时禁用颜色
+func OutputOptions(cfg *config.Config) output.Options {
+	var precision *int
+	if cfg.Output.Precision >= 0 {
+		precision = &cfg.Output.Precision
+	}
+	return output.Options{
+		NoHeaders: cfg.Output.NoHeaders,
+		NoColor:   !term.IsTerminal(int(os.Stdout.Fd())) || os.Getenv("NO_COLOR") != "",
+		TypeHint:  cfg.Output.TypeHint,
+
+		TimeFormat: cfg.Output.TimeFormat,
+		UTC:        cfg.Output.UTC,
+		Precision:  precision,
+		ValueUnit:  cfg.Output.ValueUnit,
+
+		Columns:      cfg.Output.Columns,
+		Without:      cfg.Output.Without,
+		NoMetricName: cfg.Output.NoMetricName,
+
+		Layout:      cfg.Output.Layout,
+		HeaderLabel: cfg.Output.HeaderLabel,
+		FillValue:   cfg.Output.FillValue,
+
+		SortBy: cfg.Output.SortBy,
+		Desc:   cfg.Output.Desc,
+		Top:    cfg.Output.Top,
+
+		GraphWidth:    cfg.Output.GraphWidth,
+		GraphHeight:   cfg.Output.GraphHeight,
+		GraphSeparate: cfg.Output.GraphSeparate,
+	}
+}
@@ -6,8 +6,7 @@ import (
 	"time"
 
 	"github.com/lwmacct/251203-vm-metrics/internal/command"
-	"github.com/lwmacct/251203-vm-metrics/internal/config"
-	"github.com/lwmacct/251203-vm-metrics/internal/output"
+	"github.com/lwmacct/251203-vm-metrics/internal/vmapi"
 	"github.com/urfave/cli/v3"
 )
 
@@ -18,6 +17,11 @@ func actionQuery(ctx context.Context, cmd *cli.Command) error {
 		return cli.ShowAppHelp(cmd)
 	}
 
+	// watch 模式
+	if cmd.Duration("watch") > 0 {
+		return actionWatch(ctx, cmd, query)
+	}
+
 	cfg := command.GetConfig(cmd)
 	client, err := command.NewClient(cfg)
 	if err != nil {
@@ -25,43 +29,49 @@ func actionQuery(ctx context.Context, cmd *cli.Command) error {
 	}
 
 	// 解析时间
-	ts, err := command.ParseTime(cmd.String("time"))
+	ts, err := command.ParseTimeFlag(cmd, "time")
 	if err != nil {
 		return fmt.Errorf("invalid time format: %w", err)
 	}
 
-	// 判断是 Instant 还是 Range 查询
-	rangeDuration := cmd.Duration("range")
-	if rangeDuration > 0 {
-		// Range Query
-		end := ts
-		start := end.Add(-rangeDuration)
-		step := cmd.Duration("step")
-
-		result, err := client.QueryRange(ctx, query, start, end, step)
-		if err != nil {
-			return err
-		}
-		w, err := newWriter(cfg)
-		if err != nil {
-			return err
-		}
-		return w.WriteQueryResult(result)
+	opts, err := newRangeOptions(cmd, cfg)
+	if err != nil {
+		return err
 	}
-
-	// Instant Query
-	result, err := client.Query(ctx, query, ts)
+	result, err := runQuery(ctx, client, query, ts, &opts)
 	if err != nil {
 		return err
 	}
 
-	w, err := newWriter(cfg)
+	w, err := command.NewWriter(cfg)
 	if err != nil {
 		return err
 	}
 	return w.WriteQueryResult(result)
 }
 
+// runQuery 执行查询，opts.Range > 0 时执行范围查询 [ts-Range, ts]，否则执行即时查询
+func runQuery(ctx context.Context, client vmapi.Client, query string, ts time.Time, opts *rangeOptions) (*vmapi.QueryResult, error) {
+	if opts.Range > 0 {
+		return opts.queryRange(ctx, client, query, ts)
+	}
+	return client.Query(ctx, query, ts, opts.Extra)
+}
+
+// queryOptions 从 flags 构建 extra_label 与 extra_filters[] 参数
+// 所有读请求 (查询、series、labels、label values、TSDB 状态) 都需要带上，否则会绕过按标签的隔离
+func queryOptions(cmd *cli.Command) (*vmapi.QueryOptions, error) {
+	extraLabels, err := command.ExtraLabels(cmd)
+	if err != nil {
+		return nil, err
+	}
+	extraFilters, err := command.ExtraFilters(cmd)
+	if err != nil {
+		return nil, err
+	}
+	return &vmapi.QueryOptions{ExtraLabels: extraLabels, ExtraFilters: extraFilters}, nil
+}
+
 // actionMetrics 列出所有指标名称
 func actionMetrics(ctx context.Context, cmd *cli.Command) error {
 	cfg := command.GetConfig(cmd)
@@ -70,13 +80,18 @@ func actionMetrics(ctx context.Context, cmd *cli.Command) error {
 		return fmt.Errorf("failed to create client: %w", err)
 	}
 
+	extra, err := queryOptions(cmd)
+	if err != nil {
+		return err
+	}
+
 	// 获取 __name__ 标签的所有值
-	result, err := client.LabelValues(ctx, "__name__", time.Time{}, time.Time{})
+	result, err := client.LabelValues(ctx, "__name__", time.Time{}, time.Time{}, extra)
 	if err != nil {
 		return err
 	}
 
-	w, err := newWriter(cfg)
+	w, err := command.NewWriter(cfg)
 	if err != nil {
 		return err
 	}
@@ -91,12 +106,17 @@ func actionLabels(ctx context.Context, cmd *cli.Command) error {
 		return fmt.Errorf("failed to create client: %w", err)
 	}
 
-	result, err := client.Labels(ctx, time.Time{}, time.Time{})
+	extra, err := queryOptions(cmd)
 	if err != nil {
 		return err
 	}
 
-	w, err := newWriter(cfg)
+	result, err := client.Labels(ctx, time.Time{}, time.Time{}, extra)
+	if err != nil {
+		return err
+	}
+
+	w, err := command.NewWriter(cfg)
 	if err != nil {
 		return err
 	}
@@ -116,12 +136,17 @@ func actionLabelValues(ctx context.Context, cmd *cli.Command) error {
 		return fmt.Errorf("failed to create client: %w", err)
 	}
 
-	result, err := client.LabelValues(ctx, label, time.Time{}, time.Time{})
+	extra, err := queryOptions(cmd)
+	if err != nil {
+		return err
+	}
+
+	result, err := client.LabelValues(ctx, label, time.Time{}, time.Time{}, extra)
 	if err != nil {
 		return err
 	}
 
-	w, err := newWriter(cfg)
+	w, err := command.NewWriter(cfg)
 	if err != nil {
 		return err
 	}
@@ -141,21 +166,60 @@ func actionSeries(ctx context.Context, cmd *cli.Command) error {
 		return fmt.Errorf("failed to create client: %w", err)
 	}
 
-	result, err := client.Series(ctx, []string{match}, time.Time{}, time.Time{})
+	extra, err := queryOptions(cmd)
 	if err != nil {
 		return err
 	}
 
-	w, err := newWriter(cfg)
+	result, err := client.Series(ctx, []string{match}, time.Time{}, time.Time{}, extra)
+	if err != nil {
+		return err
+	}
+
+	w, err := command.NewWriter(cfg)
 	if err != nil {
 		return err
 	}
 	return w.WriteSeries(result.Series)
 }
 
-// newWriter 创建输出 Writer
-func newWriter(cfg *config.Config) (output.Writer, error) {
-	return output.New(cfg.Output.Format, output.Options{
-		NoHeaders: cfg.Output.NoHeaders,
+// actionCardinality 基于 TSDB 状态统计展示基数排行
+func actionCardinality(ctx context.Context, cmd *cli.Command) error {
+	cfg := command.GetConfig(cmd)
+	client, err := command.NewClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	admin, ok := client.(vmapi.Admin)
+	if !ok {
+		return fmt.Errorf("client does not support tsdb status")
+	}
+
+	date, err := command.ParseTimeFlag(cmd, "date")
+	if err != nil {
+		return err
+	}
+	extra, err := queryOptions(cmd)
+	if err != nil {
+		return err
+	}
+
+	status, err := admin.TSDBStatus(ctx, &vmapi.TSDBStatusOptions{
+		TopN:         cmd.Int("top-n"),
+		Date:         date,
+		FocusLabel:   cmd.String("focus-label"),
+		Match:        cmd.Args().Slice(),
+		ExtraLabels:  extra.ExtraLabels,
+		ExtraFilters: extra.ExtraFilters,
 	})
+	if err != nil {
+		return err
+	}
+
+	w, err := command.NewWriter(cfg)
+	if err != nil {
+		return err
+	}
+	return w.WriteTSDBStatus(status)
 }
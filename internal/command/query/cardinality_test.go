@@ -0,0 +1,92 @@
+package query
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// tsdbStatusPayload /api/v1/status/tsdb 的响应样例
+const tsdbStatusPayload = `{"status":"success","data":{
+	"totalSeries":200,"totalLabelValuePairs":50,
+	"seriesCountByMetricName":[{"name":"http_requests_total","value":150},{"name":"up","value":50}],
+	"seriesCountByLabelName":[{"name":"instance","value":200}],
+	"seriesCountByFocusLabelValue":[{"name":"api","value":120}],
+	"seriesCountByLabelValuePair":[{"name":"job=api","value":120}],
+	"labelValueCountByLabelName":[{"name":"instance","value":10}]}}`
+
+// captureStdout 执行 fn 并返回其写入 stdout 的内容
+func captureStdout(t *testing.T, fn func() error) (string, error) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	out := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		out <- string(data)
+	}()
+	err = fn()
+	_ = w.Close()
+	return <-out, err
+}
+
+// TestCardinalityCommand 验证 cardinality 的请求参数，以及 focus label 排行紧跟指标排行、占比按总数计算
+func TestCardinalityCommand(t *testing.T) {
+	var got url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/status/tsdb" {
+			http.NotFound(w, r)
+			return
+		}
+		got = r.URL.Query()
+		_, _ = io.WriteString(w, tsdbStatusPayload)
+	}))
+	defer srv.Close()
+
+	cfgPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(cfgPath, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := captureStdout(t, func() error {
+		return Command.Run(context.Background(), []string{"mc-vmquery", "cardinality",
+			"--config", cfgPath, "--server-url", srv.URL, "-o", "table", "--output-no-headers",
+			"--top-n", "5", "--date", "2024-05-01", "--focus-label", "job", `{job="api"}`})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := url.Values{"topN": {"5"}, "date": {"2024-05-01"}, "focusLabel": {"job"}, "match[]": {`{job="api"}`}}
+	if got.Encode() != want.Encode() {
+		t.Errorf("query = %s, want %s", got.Encode(), want.Encode())
+	}
+
+	wantOut := `Total series: 200, total label=value pairs: 50
+
+http_requests_total  150  75.00%
+up                   50   25.00%
+
+api  120  60.00%
+
+instance  200  100.00%
+
+job=api  120  60.00%
+
+instance  10  20.00%
+`
+	if out != wantOut {
+		t.Errorf("output:\n%s\nwant:\n%s", out, wantOut)
+	}
+}
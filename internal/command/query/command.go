@@ -2,8 +2,6 @@
 package query
 
 import (
-	"time"
-
 	"github.com/lwmacct/251203-vm-metrics/internal/command"
 	"github.com/lwmacct/251207-go-pkg-version/pkg/version"
 
@@ -22,6 +20,8 @@ var Command = &cli.Command{
 		labelsCommand,
 		labelValuesCommand,
 		seriesCommand,
+		cardinalityCommand,
+		replCommand,
 		version.Command,
 	},
 	Flags: queryFlags(),
@@ -34,12 +34,12 @@ func init() {
 
 // queryFlags 返回查询命令的 flags (基础 + 查询特定)
 func queryFlags() []cli.Flag {
-	return append(command.BaseFlags(),
+	flags := append(command.BaseFlags(),
 		// 输出配置
 		&cli.StringFlag{
 			Name:    "output-format",
 			Aliases: []string{"o"},
-			Usage:   "输出格式: table, json, csv, graph",
+			Usage:   "输出格式: table, json, csv, graph, summary, prom (openmetrics), markdown (md), html",
 			Value:   command.Defaults.Output.Format,
 		},
 		&cli.BoolFlag{
@@ -47,21 +47,103 @@ func queryFlags() []cli.Flag {
 			Usage: "禁用表头输出",
 			Value: command.Defaults.Output.NoHeaders,
 		},
+		&cli.StringFlag{
+			Name:  "output-type-hint",
+			Usage: "prom 格式输出 # TYPE 行: auto (按指标名推断), counter, gauge, untyped",
+			Value: command.Defaults.Output.TypeHint,
+		},
+	)
+	flags = append(flags, command.OutputDisplayFlags()...)
+	return append(flags,
+		&cli.StringFlag{
+			Name:    "output-layout",
+			Aliases: []string{"layout"},
+			Usage:   "range query 的 table/csv/markdown/html 布局: long (每点一行) 或 wide (时间戳对齐，每个序列一列)",
+			Value:   command.Defaults.Output.Layout,
+		},
+		&cli.StringFlag{
+			Name:    "output-header-label",
+			Aliases: []string{"header-label"},
+			Usage:   "wide 布局中用作列名的标签 (默认使用完整序列名)",
+			Value:   command.Defaults.Output.HeaderLabel,
+		},
+		&cli.StringFlag{
+			Name:    "output-fill-value",
+			Aliases: []string{"fill-value"},
+			Usage:   "wide 布局中缺失点的填充值 (如 0, NaN，默认留空)",
+			Value:   command.Defaults.Output.FillValue,
+		},
+		&cli.StringFlag{
+			Name:    "output-sort-by",
+			Aliases: []string{"sort-by"},
+			Usage:   "summary 格式的排序列: name, min, max, avg, last, p95, stddev, points",
+			Value:   command.Defaults.Output.SortBy,
+		},
+		&cli.BoolFlag{
+			Name:    "output-desc",
+			Aliases: []string{"desc"},
+			Usage:   "summary 格式降序排序",
+			Value:   command.Defaults.Output.Desc,
+		},
+		&cli.IntFlag{
+			Name:    "output-top",
+			Aliases: []string{"top"},
+			Usage:   "summary 格式只输出排序后的前 N 个序列",
+			Value:   command.Defaults.Output.Top,
+		},
+		&cli.IntFlag{
+			Name:    "output-graph-width",
+			Aliases: []string{"graph-width"},
+			Usage:   "graph 格式绘图区宽度 (列)，默认按终端宽度",
+			Value:   command.Defaults.Output.GraphWidth,
+		},
+		&cli.IntFlag{
+			Name:    "output-graph-height",
+			Aliases: []string{"graph-height"},
+			Usage:   "graph 格式绘图区高度 (行)，默认按终端高度",
+			Value:   command.Defaults.Output.GraphHeight,
+		},
+		&cli.BoolFlag{
+			Name:    "output-graph-separate",
+			Aliases: []string{"graph-separate"},
+			Usage:   "graph 格式每个序列单独绘制一张图 (默认叠加在同一张图中并显示图例)",
+			Value:   command.Defaults.Output.GraphSeparate,
+		},
 		// 查询参数
 		&cli.StringFlag{
 			Name:  "time",
-			Usage: "查询时间点 (RFC3339 格式或 'now')",
+			Usage: "查询时间点 (如 now, now-1h, 2024-05-01 10:00, RFC3339 或 Unix 时间戳)",
 			Value: "now",
 		},
-		&cli.DurationFlag{
+		&cli.StringFlag{
 			Name:  "range",
-			Usage: "范围查询的时间跨度 (如 1h, 30m)",
+			Usage: "范围查询的时间跨度 (如 1h, 30m, 30d)",
 		},
-		&cli.DurationFlag{
+		&cli.StringFlag{
 			Name:  "step",
-			Usage: "范围查询的步长 (如 1m, 15s)",
-			Value: time.Minute,
+			Usage: "范围查询的步长 (如 1m, 15s)，auto 表示按跨度与终端宽度 (或 --max-points) 自动选择",
+			Value: "1m",
+		},
+		&cli.IntFlag{
+			Name:  "max-points",
+			Usage: "--step auto 时每个序列的目标点数 (默认按终端宽度)",
+		},
+		&cli.IntFlag{
+			Name:  "query-max-points-per-series",
+			Usage: "服务端单序列最大点数 (-search.maxPointsPerTimeseries)，超出时告警",
+			Value: command.Defaults.Query.MaxPointsPerSeries,
 		},
+		&cli.BoolFlag{
+			Name:  "query-split",
+			Usage: "超出单序列最大点数时拆分为多个请求并拼接结果",
+			Value: command.Defaults.Query.Split,
+		},
+		&cli.DurationFlag{
+			Name:  "watch",
+			Usage: "按间隔重复执行查询并刷新输出 (如 5s)，Ctrl-C 退出",
+		},
+		command.ExtraLabelFlag("强制附加的标签过滤条件 (extra_label)，格式 name=value，可重复"),
+		command.ExtraFilterFlag(),
 	)
 }
 
@@ -95,3 +177,34 @@ var seriesCommand = &cli.Command{
 	ArgsUsage: "<match>",
 	Action:    actionSeries,
 }
+
+// cardinalityCommand cardinality 子命令
+var cardinalityCommand = &cli.Command{
+	Name:      "cardinality",
+	Usage:     "基数分析: 序列数最多的指标、标签与 label=value 对",
+	ArgsUsage: "[match]...",
+	Action:    actionCardinality,
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:    "top-n",
+			Aliases: []string{"topN"},
+			Usage:   "每类排行返回的条目数",
+			Value:   10,
+		},
+		&cli.StringFlag{
+			Name:  "date",
+			Usage: "统计日期 (如 today, yesterday, 2024-05-01，默认当天)",
+		},
+		&cli.StringFlag{
+			Name:  "focus-label",
+			Usage: "额外统计该标签各个值的序列数",
+		},
+	},
+}
+
+// replCommand repl 子命令
+var replCommand = &cli.Command{
+	Name:   "repl",
+	Usage:  "交互式查询 (行编辑、历史记录、元命令)",
+	Action: actionREPL,
+}
@@ -0,0 +1,452 @@
+package query
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lwmacct/251203-vm-metrics/internal/command"
+	"github.com/lwmacct/251203-vm-metrics/internal/config"
+	"github.com/lwmacct/251203-vm-metrics/internal/output"
+	"github.com/lwmacct/251203-vm-metrics/internal/vmapi"
+	"github.com/lwmacct/251207-go-pkg-version/pkg/version"
+	"github.com/urfave/cli/v3"
+	"golang.org/x/term"
+)
+
+// replPrompt 交互式提示符
+const replPrompt = "metricsql> "
+
+// replHistoryLimit 历史记录最大条数
+const replHistoryLimit = 1000
+
+// replHelp 元命令帮助
+const replHelp = `元命令:
+  .format <fmt>     设置输出格式: table, json, csv, graph, summary, prom, markdown, html
+  .range <dur>      设置范围查询跨度 (如 1h, 1d，0 表示即时查询)
+  .step <dur>       设置范围查询步长 (auto 表示按跨度自动选择)
+  .time <expr>      设置查询时间点 (如 now, now-1d, 2024-05-01 10:00)
+  .labels <metric>  列出指标的所有标签名称
+  .show             显示当前设置
+  .help             显示帮助
+  .exit             退出 (或 Ctrl-D)
+`
+
+// replSession 交互式会话状态
+type replSession struct {
+	client   vmapi.Client
+	cfg      *config.Config
+	out      io.Writer
+	format   string
+	timeExpr string // 每次查询时重新解析，使 "now" 保持最新
+	loc      *time.Location
+	opts     rangeOptions
+
+	// input 终端模式下的输入，执行命令期间将 Ctrl-C 转为取消 (非终端输入时为 nil)
+	input *interruptReader
+	// terminal 终端模式下 out (term.Terminal) 最终写入的终端，graph 按其尺寸绘图
+	terminal *os.File
+}
+
+// actionREPL 启动交互式查询
+func actionREPL(ctx context.Context, cmd *cli.Command) error {
+	cfg := command.GetConfig(cmd)
+	client, err := command.NewClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	loc, err := command.TimeLocation(cmd)
+	if err != nil {
+		return err
+	}
+
+	opts, err := newRangeOptions(cmd, cfg)
+	if err != nil {
+		return err
+	}
+
+	s := &replSession{
+		client:   client,
+		cfg:      cfg,
+		out:      os.Stdout,
+		format:   cfg.Output.Format,
+		timeExpr: cmd.String("time"),
+		loc:      loc,
+		opts:     opts,
+	}
+
+	// 非终端输入 (管道/重定向) 时逐行读取，不启用行编辑
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return s.runLines(ctx, os.Stdin)
+	}
+	return s.runTerminal(ctx)
+}
+
+// runTerminal 在 raw 模式下运行，支持行编辑与历史记录
+func (s *replSession) runTerminal(ctx context.Context) error {
+	fd := int(os.Stdin.Fd())
+	oldState, err := term.MakeRaw(fd)
+	if err != nil {
+		return fmt.Errorf("failed to set terminal raw mode: %w", err)
+	}
+	defer func() { _ = term.Restore(fd, oldState) }()
+
+	// raw 模式下 Ctrl-C 不产生 SIGINT，由 interruptReader 在执行命令期间取消当前命令
+	s.input = newInterruptReader(os.Stdin)
+	t := term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{s.input, os.Stdout}, replPrompt)
+	if w, h, err := term.GetSize(fd); err == nil && w > 0 && h > 0 {
+		_ = t.SetSize(w, h)
+	}
+	t.History = newFileHistory(replHistoryPath(), replHistoryLimit)
+
+	// 输出经由 Terminal 写入，自动将 \n 转换为 \r\n
+	s.out = t
+	s.terminal = os.Stdout
+	_, _ = fmt.Fprintln(s.out, "输入 MetricsQL 查询或 .help 查看元命令，Ctrl-C 取消正在执行的查询，Ctrl-D 退出")
+
+	for {
+		line, err := t.ReadLine()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if done := s.handleLine(ctx, line); done {
+			return nil
+		}
+	}
+}
+
+// runLines 从 Reader 逐行读取并执行
+func (s *replSession) runLines(ctx context.Context, r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if done := s.handleLine(ctx, scanner.Text()); done {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// handleLine 处理单行输入，返回 true 表示退出会话
+func (s *replSession) handleLine(ctx context.Context, line string) bool {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return false
+	}
+
+	// 每个命令使用独立的 context，Ctrl-C 只取消当前命令而不退出会话
+	ctx, cancel := s.input.watch(ctx)
+	defer cancel()
+
+	var err error
+	if strings.HasPrefix(line, ".") {
+		var done bool
+		done, err = s.handleMeta(ctx, line)
+		if done {
+			return true
+		}
+	} else {
+		err = s.query(ctx, line)
+	}
+
+	if err != nil && errors.Is(context.Cause(ctx), errInterrupted) {
+		err = errInterrupted
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(s.out, "Error: %v\n", err)
+	}
+	return false
+}
+
+// handleMeta 处理元命令，返回 true 表示退出会话
+func (s *replSession) handleMeta(ctx context.Context, line string) (bool, error) {
+	name, arg, _ := strings.Cut(line, " ")
+	arg = strings.TrimSpace(arg)
+
+	switch name {
+	case ".exit", ".quit", ".q":
+		return true, nil
+	case ".help", ".h":
+		_, _ = fmt.Fprint(s.out, replHelp)
+	case ".show":
+		s.show()
+	case ".format":
+		if _, err := output.New(arg, output.Options{Writer: io.Discard}); err != nil {
+			return false, err
+		}
+		s.format = arg
+	case ".range":
+		d, err := command.ParseDuration(arg)
+		if err != nil {
+			return false, fmt.Errorf("invalid range: %w", err)
+		}
+		s.opts.Range = d
+		s.opts.Warn = os.Stderr
+	case ".step":
+		d, err := parseStep(arg)
+		if err != nil {
+			return false, err
+		}
+		s.opts.Step = d
+		s.opts.Warn = os.Stderr
+	case ".time":
+		if _, err := command.ParseTimeIn(arg, s.loc); err != nil {
+			return false, err
+		}
+		s.timeExpr = arg
+	case ".labels":
+		return false, s.labels(ctx, arg)
+	default:
+		return false, fmt.Errorf("unknown meta command: %s (use .help)", name)
+	}
+	return false, nil
+}
+
+// show 输出当前会话设置
+func (s *replSession) show() {
+	timeExpr := s.timeExpr
+	if timeExpr == "" {
+		timeExpr = "now"
+	}
+	_, _ = fmt.Fprintf(s.out, "format: %s\nrange:  %s\nstep:   %s\ntime:   %s\n",
+		s.format, s.opts.Range, formatStep(s.opts.Step), timeExpr)
+}
+
+// query 执行查询并输出结果
+func (s *replSession) query(ctx context.Context, query string) error {
+	ts, err := command.ParseTimeIn(s.timeExpr, s.loc)
+	if err != nil {
+		return err
+	}
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+
+	s.opts.Format = s.format
+	result, err := runQuery(ctx, s.client, query, ts, &s.opts)
+	if err != nil {
+		return err
+	}
+
+	w, err := s.newWriter()
+	if err != nil {
+		return err
+	}
+	return w.WriteQueryResult(result)
+}
+
+// labels 列出指标的标签名称
+func (s *replSession) labels(ctx context.Context, metric string) error {
+	if metric == "" {
+		return fmt.Errorf("usage: .labels <metric>")
+	}
+
+	result, err := s.client.Series(ctx, []string{metric}, time.Time{}, time.Time{}, s.opts.Extra)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool)
+	for _, ls := range result.Series {
+		for k := range ls {
+			seen[k] = true
+		}
+	}
+	names := make([]string, 0, len(seen))
+	for k := range seen {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	w, err := s.newWriter()
+	if err != nil {
+		return err
+	}
+	return w.WriteStrings(names)
+}
+
+// newWriter 以当前会话格式创建输出 Writer
+func (s *replSession) newWriter() (output.Writer, error) {
+	opts := command.OutputOptions(s.cfg)
+	opts.Writer = s.out
+	opts.Terminal = s.terminal
+	return output.New(s.format, opts)
+}
+
+// replHistoryPath 返回历史记录文件路径 (~/.<app>_history)
+func replHistoryPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	name := version.GetAppRawName()
+	if name == "" || name == "Unknown" {
+		name = "vm-metrics"
+	}
+	return filepath.Join(home, "."+name+"_history")
+}
+
+// fileHistory 持久化到文件的 term.History 实现
+type fileHistory struct {
+	path    string
+	limit   int
+	entries []string // 按时间顺序，最后一条为最新
+}
+
+// newFileHistory 创建文件历史记录，并加载已有条目
+func newFileHistory(path string, limit int) *fileHistory {
+	h := &fileHistory{path: path, limit: limit}
+	if path == "" {
+		return h
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return h
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line != "" {
+			h.entries = append(h.entries, line)
+		}
+	}
+	// 超出上限时压缩历史文件
+	if len(h.entries) > limit {
+		h.entries = h.entries[len(h.entries)-limit:]
+		_ = os.WriteFile(path, []byte(strings.Join(h.entries, "\n")+"\n"), 0600)
+	}
+	return h
+}
+
+// Add 添加新条目并追加写入文件
+func (h *fileHistory) Add(entry string) {
+	entry = strings.TrimSpace(entry)
+	if entry == "" || (len(h.entries) > 0 && h.entries[len(h.entries)-1] == entry) {
+		return
+	}
+
+	h.entries = append(h.entries, entry)
+	if len(h.entries) > h.limit {
+		h.entries = h.entries[len(h.entries)-h.limit:]
+	}
+
+	if h.path == "" {
+		return
+	}
+	f, err := os.OpenFile(h.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	defer func() { _ = f.Close() }()
+	_, _ = fmt.Fprintln(f, entry)
+}
+
+// Len 返回条目数量
+func (h *fileHistory) Len() int {
+	return len(h.entries)
+}
+
+// At 返回第 idx 条记录，0 为最新
+func (h *fileHistory) At(idx int) string {
+	return h.entries[len(h.entries)-1-idx]
+}
+
+// errInterrupted 命令被 Ctrl-C 取消
+var errInterrupted = errors.New("interrupted")
+
+// interruptReader 在后台 goroutine 中持续读取终端输入
+// 执行命令期间 term.Terminal 不读取输入，由该 goroutine 识别 Ctrl-C (0x03) 并取消当前命令；
+// 其余按键缓冲起来，留给下一次 ReadLine
+type interruptReader struct {
+	mu      sync.Mutex
+	pending []byte
+	err     error
+	cancel  context.CancelCauseFunc // 正在执行的命令，nil 表示空闲
+	ready   chan struct{}
+}
+
+// newInterruptReader 创建 interruptReader 并开始读取 r
+func newInterruptReader(r io.Reader) *interruptReader {
+	ir := &interruptReader{ready: make(chan struct{}, 1)}
+	go ir.loop(r)
+	return ir
+}
+
+// loop 读取输入直到出错
+func (ir *interruptReader) loop(r io.Reader) {
+	buf := make([]byte, 256)
+	for {
+		n, err := r.Read(buf)
+		ir.mu.Lock()
+		chunk := buf[:n]
+		if ir.cancel != nil && bytes.IndexByte(chunk, 0x03) >= 0 {
+			ir.cancel(errInterrupted)
+			chunk = bytes.ReplaceAll(chunk, []byte{0x03}, nil)
+		}
+		ir.pending = append(ir.pending, chunk...)
+		if err != nil {
+			ir.err = err
+		}
+		ir.mu.Unlock()
+
+		select {
+		case ir.ready <- struct{}{}:
+		default:
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// Read 实现 io.Reader，返回已缓冲的输入
+func (ir *interruptReader) Read(p []byte) (int, error) {
+	for {
+		ir.mu.Lock()
+		if len(ir.pending) > 0 {
+			n := copy(p, ir.pending)
+			ir.pending = ir.pending[n:]
+			ir.mu.Unlock()
+			return n, nil
+		}
+		if err := ir.err; err != nil {
+			ir.mu.Unlock()
+			return 0, err
+		}
+		ir.mu.Unlock()
+		<-ir.ready
+	}
+}
+
+// watch 返回执行单个命令的 context，命令执行期间按下 Ctrl-C 时以 errInterrupted 取消
+// 命令结束后必须调用返回的函数；ir 为 nil (非终端输入) 时仅返回可取消的 context
+func (ir *interruptReader) watch(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	if ir == nil {
+		return ctx, func() { cancel(nil) }
+	}
+
+	ir.mu.Lock()
+	ir.cancel = cancel
+	ir.mu.Unlock()
+	return ctx, func() {
+		ir.mu.Lock()
+		ir.cancel = nil
+		ir.mu.Unlock()
+		cancel(nil)
+	}
+}
@@ -0,0 +1,48 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+// TestInterruptReader 验证执行命令期间的 Ctrl-C 取消当前命令，其余输入留给下一次读取；空闲时 Ctrl-C 原样传递
+func TestInterruptReader(t *testing.T) {
+	pr, pw := io.Pipe()
+	ir := newInterruptReader(pr)
+
+	ctx, cancel := ir.watch(context.Background())
+	if _, err := pw.Write([]byte("ab\x03c")); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Ctrl-C 未取消命令")
+	}
+	if !errors.Is(context.Cause(ctx), errInterrupted) {
+		t.Errorf("cause = %v, want errInterrupted", context.Cause(ctx))
+	}
+	cancel()
+
+	buf := make([]byte, 16)
+	n, err := ir.Read(buf)
+	if err != nil || string(buf[:n]) != "abc" {
+		t.Errorf("read = %q, %v, want \"abc\"", buf[:n], err)
+	}
+
+	if _, err := pw.Write([]byte("\x03")); err != nil {
+		t.Fatal(err)
+	}
+	n, err = ir.Read(buf)
+	if err != nil || string(buf[:n]) != "\x03" {
+		t.Errorf("idle read = %q, %v, want Ctrl-C", buf[:n], err)
+	}
+
+	_ = pw.Close()
+	if _, err := ir.Read(buf); !errors.Is(err, io.EOF) {
+		t.Errorf("err = %v, want EOF", err)
+	}
+}
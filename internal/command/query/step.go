@@ -0,0 +1,158 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/lwmacct/251203-vm-metrics/internal/command"
+	"github.com/lwmacct/251203-vm-metrics/internal/config"
+	"github.com/lwmacct/251203-vm-metrics/internal/vmapi"
+	"github.com/urfave/cli/v3"
+	"golang.org/x/term"
+)
+
+// defaultAutoPoints 非终端输出时自动步长的目标点数
+const defaultAutoPoints = 250
+
+// graphAxisWidth 图表左侧 Y 轴标签占用的列数
+const graphAxisWidth = 12
+
+// niceSteps 自动步长的候选值
+var niceSteps = []time.Duration{
+	time.Second, 2 * time.Second, 5 * time.Second, 10 * time.Second, 15 * time.Second, 30 * time.Second,
+	time.Minute, 2 * time.Minute, 5 * time.Minute, 10 * time.Minute, 15 * time.Minute, 30 * time.Minute,
+	time.Hour, 2 * time.Hour, 3 * time.Hour, 6 * time.Hour, 12 * time.Hour,
+	24 * time.Hour, 2 * 24 * time.Hour, 7 * 24 * time.Hour,
+}
+
+// rangeOptions 范围查询参数
+type rangeOptions struct {
+	Range     time.Duration       // 查询跨度，0 表示即时查询
+	Step      time.Duration       // 步长，0 表示自动
+	MaxPoints int                 // 自动步长的目标点数，0 表示按终端宽度
+	Format    string              // 输出格式，图表按绘图区宽度计算自动步长
+	Limit     int                 // 服务端单序列最大点数，0 表示不检查
+	Split     bool                // 超出 Limit 时拆分为多个请求
+	Warn      io.Writer           // 超出 Limit 时的告警输出，告警后置为 nil 避免重复 (watch 模式)
+	Extra     *vmapi.QueryOptions // extra_label 与 extra_filters[] 参数
+}
+
+// newRangeOptions 从命令行与配置构建范围查询参数
+func newRangeOptions(cmd *cli.Command, cfg *config.Config) (rangeOptions, error) {
+	var rangeDur time.Duration
+	if s := cmd.String("range"); s != "" {
+		d, err := command.ParseDuration(s)
+		if err != nil {
+			return rangeOptions{}, fmt.Errorf("invalid range: %w", err)
+		}
+		rangeDur = d
+	}
+	step, err := parseStep(cmd.String("step"))
+	if err != nil {
+		return rangeOptions{}, err
+	}
+	maxPoints := cmd.Int("max-points")
+	if maxPoints == 0 && cfg.Output.Format == "graph" {
+		// 指定了图表宽度时每列一个点
+		maxPoints = cfg.Output.GraphWidth
+	}
+	extra, err := queryOptions(cmd)
+	if err != nil {
+		return rangeOptions{}, err
+	}
+	return rangeOptions{
+		Extra:     extra,
+		Range:     rangeDur,
+		Step:      step,
+		MaxPoints: maxPoints,
+		Format:    cfg.Output.Format,
+		Limit:     cfg.Query.MaxPointsPerSeries,
+		Split:     cfg.Query.Split,
+		Warn:      os.Stderr,
+	}, nil
+}
+
+// resolveStep 返回实际使用的步长
+func (o *rangeOptions) resolveStep() time.Duration {
+	if o.Step > 0 {
+		return o.Step
+	}
+	return autoStep(o.Range, autoPoints(o.MaxPoints, o.Format))
+}
+
+// queryRange 执行 [end-Range, end] 的范围查询
+// 点数超过服务端上限时：开启 Split 则拆分请求，否则先告警再照常发送
+func (o *rangeOptions) queryRange(ctx context.Context, client vmapi.Client, query string, end time.Time) (*vmapi.QueryResult, error) {
+	start := end.Add(-o.Range)
+	step := o.resolveStep()
+
+	points := vmapi.RangePoints(start, end, step)
+	if o.Limit > 0 && points > o.Limit {
+		if o.Split {
+			return vmapi.QueryRangeSplit(ctx, client, query, start, end, step, o.Limit, o.Extra)
+		}
+		if o.Warn != nil {
+			_, _ = fmt.Fprintf(o.Warn, "Warning: range %s / step %s = %d points per series, exceeds the limit of %d; "+
+				"use --step auto, a larger --step or --query-split\n", o.Range, step, points, o.Limit)
+			o.Warn = nil
+		}
+	}
+	return client.QueryRange(ctx, query, start, end, step, o.Extra)
+}
+
+// formatStep 返回步长的显示文本
+func formatStep(step time.Duration) string {
+	if step == 0 {
+		return "auto"
+	}
+	return step.String()
+}
+
+// parseStep 解析 --step，auto 返回 0 表示由范围与目标点数决定
+func parseStep(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.EqualFold(s, "auto") {
+		return 0, nil
+	}
+	d, err := command.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid step: %s (use a duration like 30s, 5m or auto)", s)
+	}
+	return d, nil
+}
+
+// autoStep 返回使 rangeDur 内点数不超过 points 的最小候选步长
+func autoStep(rangeDur time.Duration, points int) time.Duration {
+	if points <= 1 {
+		points = defaultAutoPoints
+	}
+	raw := rangeDur / time.Duration(points-1)
+	for _, step := range niceSteps {
+		if step >= raw {
+			return step
+		}
+	}
+	// 超出候选范围时按天取整
+	day := 24 * time.Hour
+	return (raw + day - 1) / day * day
+}
+
+// autoPoints 返回自动步长的目标点数
+// 优先使用 --max-points，否则按终端宽度 (图表扣除 Y 轴标签宽度)，非终端时使用默认值
+func autoPoints(maxPoints int, format string) int {
+	if maxPoints > 0 {
+		return maxPoints
+	}
+	width, _, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil || width <= 0 {
+		return defaultAutoPoints
+	}
+	if format == "graph" {
+		width -= graphAxisWidth
+	}
+	return max(width, 10)
+}
@@ -0,0 +1,184 @@
+package query
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/lwmacct/251203-vm-metrics/internal/command"
+	"github.com/lwmacct/251203-vm-metrics/internal/config"
+	"github.com/lwmacct/251203-vm-metrics/internal/output"
+	"github.com/lwmacct/251203-vm-metrics/internal/util"
+	"github.com/lwmacct/251203-vm-metrics/internal/vmapi"
+	"github.com/urfave/cli/v3"
+	"golang.org/x/term"
+)
+
+// ansiClearScreen 光标归位并清屏
+const ansiClearScreen = "\x1b[H\x1b[2J"
+
+// watcher watch 模式状态
+type watcher struct {
+	client   vmapi.Client
+	cfg      *config.Config
+	query    string
+	interval time.Duration
+	timeExpr string
+	loc      *time.Location
+	opts     rangeOptions
+
+	out     io.Writer
+	redraw  bool               // 是否原地重绘 (table/graph 且输出为终端)
+	noColor bool               // 禁用变化高亮
+	last    map[string]float64 // 上一轮每个序列的最新值
+	changed map[string]bool    // 本轮值发生变化的序列
+}
+
+// actionWatch 按固定间隔重复执行查询，直到 Ctrl-C
+func actionWatch(ctx context.Context, cmd *cli.Command, query string) error {
+	cfg := command.GetConfig(cmd)
+	client, err := command.NewClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	// 提前校验时间表达式，避免每轮重复报错
+	loc, err := command.TimeLocation(cmd)
+	if err != nil {
+		return err
+	}
+	if _, err := command.ParseTimeIn(cmd.String("time"), loc); err != nil {
+		return fmt.Errorf("invalid time format: %w", err)
+	}
+
+	opts, err := newRangeOptions(cmd, cfg)
+	if err != nil {
+		return err
+	}
+
+	isTerminal := term.IsTerminal(int(os.Stdout.Fd()))
+	format := cfg.Output.Format
+	w := &watcher{
+		client:   client,
+		cfg:      cfg,
+		query:    query,
+		interval: cmd.Duration("watch"),
+		timeExpr: cmd.String("time"),
+		loc:      loc,
+		opts:     opts,
+		out:      os.Stdout,
+		redraw:   isTerminal && (format == "" || format == "table" || format == "graph"),
+		noColor:  !isTerminal || os.Getenv("NO_COLOR") != "",
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return w.run(ctx)
+}
+
+// run 立即执行一次，之后每个 interval 执行一次
+func (w *watcher) run(ctx context.Context) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.tick(ctx); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// tick 执行一轮查询并刷新输出
+// 查询错误只显示在输出中，不中断 watch
+func (w *watcher) tick(ctx context.Context) error {
+	var buf bytes.Buffer
+	if w.redraw {
+		buf.WriteString(ansiClearScreen)
+		_, _ = fmt.Fprintf(&buf, "Every %s: %s    %s\n\n", w.interval, w.query, time.Now().Format(time.RFC3339))
+	}
+
+	if err := w.render(ctx, &buf); err != nil {
+		if errors.Is(err, context.Canceled) {
+			return err
+		}
+		_, _ = fmt.Fprintf(&buf, "Error: %v\n", err)
+	}
+
+	_, err := w.out.Write(buf.Bytes())
+	return err
+}
+
+// render 执行查询并将结果写入 buf
+func (w *watcher) render(ctx context.Context, buf *bytes.Buffer) error {
+	ts, err := command.ParseTimeIn(w.timeExpr, w.loc)
+	if err != nil {
+		return err
+	}
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+
+	result, err := runQuery(ctx, w.client, w.query, ts, &w.opts)
+	if err != nil {
+		return err
+	}
+	w.track(result)
+
+	opts := command.OutputOptions(w.cfg)
+	opts.Writer = buf
+	if f, ok := w.out.(*os.File); ok {
+		// 先写入缓冲区再整屏输出，graph 仍按实际输出的终端尺寸绘图
+		opts.Terminal = f
+	}
+	opts.NoColor = w.noColor
+	opts.Highlight = func(metric map[string]string) bool {
+		return w.changed[util.SeriesKey(metric)]
+	}
+
+	writer, err := output.New(w.cfg.Output.Format, opts)
+	if err != nil {
+		return err
+	}
+	return writer.WriteQueryResult(result)
+}
+
+// track 对比上一轮结果，记录值发生变化的序列
+// 第一轮没有对比基准，不标记任何变化
+func (w *watcher) track(result *vmapi.QueryResult) {
+	current := make(map[string]float64, len(result.Samples))
+	for _, s := range result.Samples {
+		v := s.Value
+		if len(s.Values) > 0 {
+			v = s.Values[len(s.Values)-1]
+		}
+		current[util.SeriesKey(s.Metric)] = v.Value
+	}
+
+	w.changed = make(map[string]bool)
+	if w.last != nil {
+		for k, v := range current {
+			prev, ok := w.last[k]
+			if !ok || (prev != v && !(math.IsNaN(prev) && math.IsNaN(v))) {
+				w.changed[k] = true
+			}
+		}
+	}
+	w.last = current
+}
@@ -0,0 +1,132 @@
+package query
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lwmacct/251203-vm-metrics/internal/config"
+	"github.com/lwmacct/251203-vm-metrics/internal/util"
+	"github.com/lwmacct/251203-vm-metrics/internal/vmapi"
+)
+
+// TestWatchTrack 验证相邻两轮之间的变化检测
+func TestWatchTrack(t *testing.T) {
+	a := map[string]string{"job": "a"}
+	b := map[string]string{"job": "b"}
+	vector := func(va, vb float64) *vmapi.QueryResult {
+		return &vmapi.QueryResult{ResultType: "vector", Samples: []vmapi.Sample{
+			{Metric: a, Value: vmapi.SampleValue{Value: va}},
+			{Metric: b, Value: vmapi.SampleValue{Value: vb}},
+		}}
+	}
+	nan := math.NaN()
+
+	tests := []struct {
+		name    string
+		ticks   []*vmapi.QueryResult
+		changed []map[string]string // 最后一轮应标记为变化的序列
+	}{
+		{"first tick", []*vmapi.QueryResult{vector(1, 2)}, nil},
+		{"unchanged", []*vmapi.QueryResult{vector(1, 2), vector(1, 2)}, nil},
+		{"value changed", []*vmapi.QueryResult{vector(1, 2), vector(1, 3)}, []map[string]string{b}},
+		{"nan to nan", []*vmapi.QueryResult{vector(nan, 2), vector(nan, 2)}, nil},
+		{"nan to value", []*vmapi.QueryResult{vector(nan, 2), vector(1, 2)}, []map[string]string{a}},
+		{"new series", []*vmapi.QueryResult{
+			{Samples: []vmapi.Sample{{Metric: a, Value: vmapi.SampleValue{Value: 1}}}},
+			vector(1, 2),
+		}, []map[string]string{b}},
+		{"range uses last point", []*vmapi.QueryResult{
+			{Samples: []vmapi.Sample{{Metric: a, Values: []vmapi.SampleValue{{Value: 5}, {Value: 1}}}}},
+			{Samples: []vmapi.Sample{{Metric: a, Values: []vmapi.SampleValue{{Value: 7}, {Value: 1}}}}},
+		}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &watcher{}
+			for _, r := range tt.ticks {
+				w.track(r)
+			}
+			if len(w.changed) != len(tt.changed) {
+				t.Fatalf("changed = %v, want %v", w.changed, tt.changed)
+			}
+			for _, m := range tt.changed {
+				if !w.changed[util.SeriesKey(m)] {
+					t.Errorf("series %v should be marked changed", m)
+				}
+			}
+		})
+	}
+}
+
+// TestWatchTick 验证每轮输出写入给定的 Writer，第一轮不高亮，之后只高亮值变化的序列
+func TestWatchTick(t *testing.T) {
+	rounds := [][2]string{{"1", "NaN"}, {"2", "NaN"}}
+	n := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		v := rounds[min(n, len(rounds)-1)]
+		n++
+		_, _ = fmt.Fprintf(w, `{"status":"success","data":{"resultType":"vector","result":[`+
+			`{"metric":{"job":"a"},"value":[1700000000,%q]},{"metric":{"job":"b"},"value":[1700000000,%q]}]}}`, v[0], v[1])
+	}))
+	defer srv.Close()
+
+	client, err := vmapi.NewClient(&vmapi.ClientConfig{URL: srv.URL, Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.DefaultConfig()
+	cfg.Output.Format = "table"
+
+	var out bytes.Buffer
+	w := &watcher{
+		client:   client,
+		cfg:      &cfg,
+		query:    "up",
+		interval: time.Second,
+		loc:      time.UTC,
+		out:      &out,
+		redraw:   true,
+	}
+
+	if err := w.tick(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	first := out.String()
+	if !strings.HasPrefix(first, ansiClearScreen+"Every 1s: up") {
+		t.Errorf("redraw header missing: %q", first)
+	}
+	if strings.Contains(first, "\x1b[33m") {
+		t.Errorf("first tick should not highlight: %q", first)
+	}
+
+	out.Reset()
+	if err := w.tick(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	rows := 0
+	for _, line := range strings.Split(out.String(), "\n") {
+		highlighted := strings.Contains(line, "\x1b[33m")
+		switch {
+		case strings.Contains(line, `job="a"`):
+			rows++
+			if !highlighted {
+				t.Errorf("changed series a should be highlighted: %q", line)
+			}
+		case strings.Contains(line, `job="b"`):
+			rows++
+			if highlighted {
+				t.Errorf("NaN to NaN should not be highlighted: %q", line)
+			}
+		}
+	}
+	if rows != 2 {
+		t.Errorf("found %d series rows, want 2:\n%s", rows, out.String())
+	}
+}
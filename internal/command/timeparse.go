@@ -0,0 +1,266 @@
+package command
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v3"
+)
+
+// 带时区的时间格式，按原时区解析
+var zonedTimeLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC3339,
+}
+
+// 不带时区的时间格式，按 --tz 指定的时区解析
+var localTimeLayouts = []string{
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// durationTokenRe 匹配扩展时长中的单个 <数值><单位> 片段
+var durationTokenRe = regexp.MustCompile(`^(\d+(?:\.\d+)?)(ns|us|µs|ms|s|m|h|d|w|y)`)
+
+// 扩展时长单位
+var durationUnits = map[string]time.Duration{
+	"ns": time.Nanosecond,
+	"us": time.Microsecond,
+	"µs": time.Microsecond,
+	"ms": time.Millisecond,
+	"s":  time.Second,
+	"m":  time.Minute,
+	"h":  time.Hour,
+	"d":  24 * time.Hour,
+	"w":  7 * 24 * time.Hour,
+	"y":  365 * 24 * time.Hour,
+}
+
+// ParseTime 解析时间表达式 (使用本地时区)，支持的格式见 ParseTimeIn
+func ParseTime(s string) (time.Time, error) {
+	return ParseTimeIn(s, time.Local)
+}
+
+// ParseTimeIn 在指定时区解析时间表达式，支持多种格式
+// - 空字符串: 返回零值
+// - "now": 返回当前时间
+// - 相对时间: now-6h, now+30m, now-1d12h, now-2w
+// - 对齐: now/d (当天零点), now-1d/d, now/h, now/w (周一), now/M, now/y
+// - 关键字: today (now/d), yesterday (now-1d/d)
+// - RFC3339: 如 "2024-01-01T00:00:00Z"
+// - 本地时间: 如 "2024-05-01", "2024-05-01 10:00", "2024-05-01T10:00:00"
+// - Unix 时间戳: 按数量级自动识别秒、毫秒、微秒、纳秒，如 "1704067200", "1704067200123"
+func ParseTimeIn(s string, loc *time.Location) (time.Time, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+	return parseTimeAt(s, time.Now().In(loc))
+}
+
+// ParseTimeFlag 解析时间类型 flag，时区取自 --tz
+func ParseTimeFlag(cmd *cli.Command, name string) (time.Time, error) {
+	loc, err := TimeLocation(cmd)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return ParseTimeIn(cmd.String(name), loc)
+}
+
+// TimeLocation 返回 --tz 指定的时区，未指定时返回本地时区
+func TimeLocation(cmd *cli.Command) (*time.Location, error) {
+	tz := cmd.String("tz")
+	if tz == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone: %s", tz)
+	}
+	return loc, nil
+}
+
+// ParseDuration 解析时长，在 time.ParseDuration 基础上支持 d (天)、w (周)、y (365 天)
+// 如 "1d", "2w", "1d12h", "1.5h"
+func ParseDuration(s string) (time.Duration, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return d, nil
+	}
+
+	rest := strings.TrimSpace(s)
+	if rest == "" {
+		return 0, fmt.Errorf("invalid duration: %q", s)
+	}
+
+	var total time.Duration
+	for rest != "" {
+		m := durationTokenRe.FindStringSubmatch(rest)
+		if m == nil {
+			return 0, fmt.Errorf("invalid duration: %q", s)
+		}
+		n, err := strconv.ParseFloat(m[1], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration: %q", s)
+		}
+		total += time.Duration(n * float64(durationUnits[m[2]]))
+		rest = rest[len(m[0]):]
+	}
+	return total, nil
+}
+
+// parseTimeAt 以 now 为基准解析时间表达式，时区取自 now.Location()
+func parseTimeAt(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	loc := now.Location()
+
+	switch strings.ToLower(s) {
+	case "":
+		return time.Time{}, nil
+	case "today":
+		return snapTime(now, "d")
+	case "yesterday":
+		return snapTime(now.AddDate(0, 0, -1), "d")
+	}
+
+	if strings.HasPrefix(s, "now") {
+		return parseRelativeTime(s, now)
+	}
+
+	if t, ok := parseEpoch(s, loc); ok {
+		return t, nil
+	}
+
+	for _, layout := range zonedTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	for _, layout := range localTimeLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time format: %s (use now[+-]<duration>[/unit], RFC3339, YYYY-MM-DD[ HH:MM[:SS]] or Unix timestamp)", s)
+}
+
+// parseRelativeTime 解析 now[+-]<duration>[/unit] 形式的相对时间
+func parseRelativeTime(s string, now time.Time) (time.Time, error) {
+	expr := strings.TrimPrefix(s, "now")
+	snap := ""
+	if i := strings.LastIndex(expr, "/"); i >= 0 {
+		expr, snap = expr[:i], expr[i+1:]
+	}
+
+	t := now
+	if expr != "" {
+		sign := expr[0]
+		if sign != '+' && sign != '-' {
+			return time.Time{}, fmt.Errorf("invalid relative time: %s (use now-1h, now+30m, now/d)", s)
+		}
+		d, err := ParseDuration(expr[1:])
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid relative time: %s: %w", s, err)
+		}
+		if sign == '-' {
+			d = -d
+		}
+		t = t.Add(d)
+	}
+
+	if snap == "" {
+		return t, nil
+	}
+	return snapTime(t, snap)
+}
+
+// snapTime 将时间向下对齐到指定单位的起点 (按 t 所在时区)
+// 单位: s, m, h, d, w (周一), M (月), y (年)
+func snapTime(t time.Time, unit string) (time.Time, error) {
+	loc := t.Location()
+	y, mo, d := t.Date()
+
+	switch unit {
+	case "s":
+		return time.Date(y, mo, d, t.Hour(), t.Minute(), t.Second(), 0, loc), nil
+	case "m":
+		return time.Date(y, mo, d, t.Hour(), t.Minute(), 0, 0, loc), nil
+	case "h":
+		return time.Date(y, mo, d, t.Hour(), 0, 0, 0, loc), nil
+	case "d":
+		return time.Date(y, mo, d, 0, 0, 0, 0, loc), nil
+	case "w":
+		offset := (int(t.Weekday()) + 6) % 7 // 周一为一周的开始
+		return time.Date(y, mo, d-offset, 0, 0, 0, 0, loc), nil
+	case "M":
+		return time.Date(y, mo, 1, 0, 0, 0, 0, loc), nil
+	case "y":
+		return time.Date(y, time.January, 1, 0, 0, 0, 0, loc), nil
+	default:
+		return time.Time{}, fmt.Errorf("invalid snap unit: %s (use s, m, h, d, w, M, y)", unit)
+	}
+}
+
+// parseEpoch 解析 Unix 时间戳
+// 整数按数量级识别精度：< 1e11 秒，< 1e14 毫秒，< 1e17 微秒，否则纳秒
+// 小数按秒解析，如 "1704067200.5"
+func parseEpoch(s string, loc *time.Location) (time.Time, bool) {
+	if strings.Contains(s, ".") {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil || f < 0 {
+			return time.Time{}, false
+		}
+		sec := int64(f)
+		return time.Unix(sec, int64((f-float64(sec))*1e9)).In(loc), true
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return time.Time{}, false
+	}
+
+	switch {
+	case n < 1e11:
+		return time.Unix(n, 0).In(loc), true
+	case n < 1e14:
+		return time.UnixMilli(n).In(loc), true
+	case n < 1e17:
+		return time.UnixMicro(n).In(loc), true
+	default:
+		return time.Unix(0, n).In(loc), true
+	}
+}
+
+// TimeWindow 时间窗口 [Start, End)
+type TimeWindow struct {
+	Start time.Time
+	End   time.Time
+}
+
+// SplitTimeRange 将 [start, end) 按 chunk 切分为连续的时间窗口
+// 最后一个窗口可能短于 chunk；不足 1 秒的尾部 (通常来自两次解析 "now" 的时间差) 并入前一个窗口
+func SplitTimeRange(start, end time.Time, chunk time.Duration) []TimeWindow {
+	if chunk <= 0 || !start.Before(end) {
+		return []TimeWindow{{Start: start, End: end}}
+	}
+
+	var windows []TimeWindow
+	for ws := start; ws.Before(end); ws = ws.Add(chunk) {
+		we := ws.Add(chunk)
+		if we.After(end) || end.Sub(we) < time.Second {
+			we = end
+		}
+		windows = append(windows, TimeWindow{Start: ws, End: we})
+		if we.Equal(end) {
+			break
+		}
+	}
+	return windows
+}
@@ -0,0 +1,77 @@
+package command
+
+import (
+	"testing"
+	"time"
+)
+
+// TestParseTimeAt 验证各类时间表达式的解析结果
+func TestParseTimeAt(t *testing.T) {
+	loc := time.FixedZone("UTC+8", 8*3600)
+	// 2024-05-15 (周三) 13:45:30.5 UTC+8
+	now := time.Date(2024, 5, 15, 13, 45, 30, 500_000_000, loc)
+
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"", time.Time{}},
+		{"now", now},
+		{"now-6h", now.Add(-6 * time.Hour)},
+		{"now+30m", now.Add(30 * time.Minute)},
+		{"now-1d12h", now.Add(-36 * time.Hour)},
+		{"now-2w", now.Add(-14 * 24 * time.Hour)},
+		{"now/d", time.Date(2024, 5, 15, 0, 0, 0, 0, loc)},
+		{"now-1d/d", time.Date(2024, 5, 14, 0, 0, 0, 0, loc)},
+		{"now/h", time.Date(2024, 5, 15, 13, 0, 0, 0, loc)},
+		{"now/w", time.Date(2024, 5, 13, 0, 0, 0, 0, loc)},
+		{"now/M", time.Date(2024, 5, 1, 0, 0, 0, 0, loc)},
+		{"today", time.Date(2024, 5, 15, 0, 0, 0, 0, loc)},
+		{"yesterday", time.Date(2024, 5, 14, 0, 0, 0, 0, loc)},
+		{"2024-05-01", time.Date(2024, 5, 1, 0, 0, 0, 0, loc)},
+		{"2024-05-01 10:00", time.Date(2024, 5, 1, 10, 0, 0, 0, loc)},
+		{"2024-05-01T10:00:05", time.Date(2024, 5, 1, 10, 0, 5, 0, loc)},
+		{"2024-01-01T00:00:00Z", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"1704067200", time.Unix(1704067200, 0)},
+		{"1704067200.5", time.Unix(1704067200, 500_000_000)},
+		{"1704067200123", time.UnixMilli(1704067200123)},
+		{"1704067200123456", time.UnixMicro(1704067200123456)},
+		{"1704067200123456789", time.Unix(0, 1704067200123456789)},
+	}
+
+	for _, tt := range tests {
+		got, err := parseTimeAt(tt.in, now)
+		if err != nil {
+			t.Errorf("parseTimeAt(%q) error: %v", tt.in, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseTimeAt(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"later", "now-", "now*1h", "now/q", "2024-13-01"} {
+		if _, err := parseTimeAt(in, now); err == nil {
+			t.Errorf("parseTimeAt(%q) 应返回错误", in)
+		}
+	}
+}
+
+// TestSplitTimeRange 验证时间窗口切分
+func TestSplitTimeRange(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	windows := SplitTimeRange(start, start.Add(150*time.Minute), time.Hour)
+	if len(windows) != 3 {
+		t.Fatalf("len = %d, want 3", len(windows))
+	}
+	if !windows[2].Start.Equal(start.Add(2*time.Hour)) || !windows[2].End.Equal(start.Add(150*time.Minute)) {
+		t.Errorf("最后一个窗口 = %+v", windows[2])
+	}
+
+	// 不足 1 秒的尾部并入前一个窗口
+	windows = SplitTimeRange(start, start.Add(time.Hour+time.Millisecond), time.Hour)
+	if len(windows) != 1 || !windows[0].End.Equal(start.Add(time.Hour+time.Millisecond)) {
+		t.Errorf("windows = %+v, want 单个窗口", windows)
+	}
+}
@@ -3,18 +3,25 @@
 // 配置加载优先级 (从低到高)：
 //  1. 默认值 - DefaultConfig() 函数中定义
 //  2. 配置文件 - 通过 --config 指定，或按顺序搜索默认路径
-//  3. 环境变量 - 以 <AppRawName> 为前缀，下划线分隔嵌套路径
-//  4. CLI flags - 最高优先级
+//  3. Profile - current_profile (或 --profile) 指定的 profiles.<name> 覆盖顶层 server/auth/tls
+//  4. 环境变量 - 以 <AppRawName> 为前缀，下划线分隔嵌套路径
+//  5. CLI flags - 最高优先级
 package config
 
 import "time"
 
 // Config 应用配置
 type Config struct {
+	CurrentProfile string                   `koanf:"current_profile" comment:"当前使用的 profile 名称 (为空则使用顶层配置)"`
+	Profiles       map[string]ProfileConfig `koanf:"profiles" comment:"命名 profile，每个包含 server/auth/tls 配置"`
+
 	Server ServerConfig `koanf:"server" comment:"服务器配置"`
 	Auth   AuthConfig   `koanf:"auth" comment:"认证配置"`
 	TLS    TLSConfig    `koanf:"tls" comment:"TLS 配置"`
 	Output OutputConfig `koanf:"output" comment:"输出配置"`
+	Query  QueryConfig  `koanf:"query" comment:"范围查询配置"`
+
+	Migrate MigrateConfig `koanf:"migrate" comment:"migrate 命令的源/目标连接配置"`
 }
 
 // ServerConfig 服务器配置
@@ -22,6 +29,17 @@ type ServerConfig struct {
 	URL        string        `koanf:"url" comment:"VictoriaMetrics 服务器地址"`
 	PathPrefix string        `koanf:"path_prefix" comment:"API 路径前缀 (如 /victoria)"`
 	Timeout    time.Duration `koanf:"timeout" comment:"请求超时时间"`
+	SelectURL  string        `koanf:"select_url" comment:"集群模式 vmselect 地址 (默认: url)"`
+	InsertURL  string        `koanf:"insert_url" comment:"集群模式 vminsert 地址 (默认: url)"`
+	Tenant     string        `koanf:"tenant" comment:"集群模式租户 ID: accountID 或 accountID:projectID"`
+
+	RetryMaxAttempts   int           `koanf:"retry_max_attempts" comment:"最大尝试次数 (含首次)，1 表示不重试"`
+	RetryWait          time.Duration `koanf:"retry_wait" comment:"首次重试等待时间，之后指数增长并加入随机抖动"`
+	RetryMaxWait       time.Duration `koanf:"retry_max_wait" comment:"单次重试等待上限 (同时限制 Retry-After)"`
+	RetryStatusCodes   []int         `koanf:"retry_status_codes" comment:"需要重试的 HTTP 状态码"`
+	RetryNetworkErrors bool          `koanf:"retry_network_errors" comment:"是否重试网络错误 (连接失败、连接被重置)"`
+	BreakerThreshold   int           `koanf:"breaker_threshold" comment:"连续失败多少次后熔断 (0 表示禁用)"`
+	BreakerCooldown    time.Duration `koanf:"breaker_cooldown" comment:"熔断持续时间"`
 }
 
 // AuthConfig 认证配置
@@ -42,8 +60,42 @@ type TLSConfig struct {
 
 // OutputConfig 输出配置
 type OutputConfig struct {
-	Format    string `koanf:"format" comment:"输出格式: table, json, csv, graph"`
+	Format    string `koanf:"format" comment:"输出格式: table, json, csv, graph, summary, prom, markdown, html"`
 	NoHeaders bool   `koanf:"no_headers" comment:"禁用表头输出"`
+	TypeHint  string `koanf:"type_hint" comment:"prom 格式的 # TYPE 提示: 空表示不输出，auto 按指标名推断，或 counter/gauge/untyped"`
+
+	TimeFormat string `koanf:"time_format" comment:"时间戳格式: rfc3339, rfc3339nano, unix, unix_ms, relative"`
+	UTC        bool   `koanf:"utc" comment:"时间戳使用 UTC (默认为本地时区)"`
+	Precision  int    `koanf:"precision" comment:"数值保留的小数位数，-1 表示不限制"`
+	ValueUnit  string `koanf:"value_unit" comment:"数值单位: si, iec, bytes, seconds, percent (0-1 的比例)，为空时原样输出"`
+
+	Columns      []string `koanf:"columns" comment:"只展示这些标签，每个标签单独一列 (table/csv/markdown/html)"`
+	Without      []string `koanf:"without" comment:"从 METRIC 列中去掉这些标签 (未指定 columns 时生效)"`
+	NoMetricName bool     `koanf:"no_metric_name" comment:"不展示指标名"`
+
+	Layout      string `koanf:"layout" comment:"range query 的 table/csv/markdown/html 布局: long (每点一行) 或 wide (时间戳对齐，每个序列一列)"`
+	HeaderLabel string `koanf:"header_label" comment:"wide 布局中用作列名的标签 (为空或重复时使用完整序列名)"`
+	FillValue   string `koanf:"fill_value" comment:"wide 布局中缺失点的填充值"`
+
+	SortBy string `koanf:"sort_by" comment:"summary 格式的排序列: name, min, max, avg, last, p95, stddev, points"`
+	Desc   bool   `koanf:"desc" comment:"summary 格式降序排序"`
+	Top    int    `koanf:"top" comment:"summary 格式只输出排序后的前 N 个序列，0 表示不限制"`
+
+	GraphWidth    int  `koanf:"graph_width" comment:"graph 格式绘图区宽度 (列)，0 表示按终端宽度"`
+	GraphHeight   int  `koanf:"graph_height" comment:"graph 格式绘图区高度 (行)，0 表示按终端高度"`
+	GraphSeparate bool `koanf:"graph_separate" comment:"graph 格式每个序列单独绘制一张图 (默认叠加在同一张图中)"`
+}
+
+// QueryConfig 范围查询配置
+type QueryConfig struct {
+	MaxPointsPerSeries int  `koanf:"max_points_per_series" comment:"服务端单序列最大点数 (对应 -search.maxPointsPerTimeseries)，范围查询超出时告警"`
+	Split              bool `koanf:"split" comment:"超出单序列最大点数时拆分为多个请求并拼接结果"`
+}
+
+// MigrateConfig migrate 命令的源与目标连接配置
+type MigrateConfig struct {
+	Source      ProfileConfig `koanf:"source" comment:"源服务器 (未设置 url 时使用顶层配置)"`
+	Destination ProfileConfig `koanf:"destination" comment:"目标服务器"`
 }
 
 // DefaultConfig 返回默认配置
@@ -53,6 +105,13 @@ func DefaultConfig() Config {
 		Server: ServerConfig{
 			URL:     "http://localhost:8428",
 			Timeout: 30 * time.Second,
+
+			RetryMaxAttempts:   3,
+			RetryWait:          500 * time.Millisecond,
+			RetryMaxWait:       10 * time.Second,
+			RetryStatusCodes:   []int{429, 502, 503, 504},
+			RetryNetworkErrors: true,
+			BreakerCooldown:    30 * time.Second,
 		},
 		Auth: AuthConfig{
 			Type: "",
@@ -63,6 +122,13 @@ func DefaultConfig() Config {
 		Output: OutputConfig{
 			Format:    "table",
 			NoHeaders: false,
+			Layout:    "long",
+
+			TimeFormat: "rfc3339",
+			Precision:  -1,
+		},
+		Query: QueryConfig{
+			MaxPointsPerSeries: 30000,
 		},
 	}
 }
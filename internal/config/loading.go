@@ -35,12 +35,38 @@ func defaultConfigPaths(appRawName string) []string {
 	return paths
 }
 
+// FindConfigFile 返回实际使用的配置文件路径
+// 优先返回 configPath，否则返回默认搜索路径中第一个存在的文件，均不存在时返回空字符串
+func FindConfigFile(configPath, appRawName string) string {
+	if configPath != "" {
+		return configPath
+	}
+	for _, path := range defaultConfigPaths(appRawName) {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
 // Load 加载配置，按优先级合并：
 // 1. 默认值 (最低优先级)
 // 2. 配置文件 (通过 configPath 指定，或搜索默认路径)
-// 3. 环境变量前缀
-// 4. CLI flags (最高优先级)
+// 3. Profile (--profile 或 current_profile 指定)
+// 4. 环境变量前缀
+// 5. CLI flags (最高优先级)
 func Load(cmd *cli.Command, configPath, AppRawName string) (*Config, error) {
+	return load(cmd, configPath, AppRawName, true)
+}
+
+// LoadWithoutProfile 与 Load 相同，但跳过 profile (第 3 步)，顶层 server/auth/tls 保持配置文件中的值
+// 供 config 子命令使用：current_profile 指向已删除的 profile 时仍能加载，并通过 use-profile 修复
+func LoadWithoutProfile(cmd *cli.Command, configPath, AppRawName string) (*Config, error) {
+	return load(cmd, configPath, AppRawName, false)
+}
+
+// load 加载配置，withProfile 为 false 时不应用 profile
+func load(cmd *cli.Command, configPath, AppRawName string, withProfile bool) (*Config, error) {
 	if AppRawName == "" || AppRawName == "Unknown" {
 		AppRawName = "app"
 	}
@@ -78,7 +104,18 @@ func Load(cmd *cli.Command, configPath, AppRawName string) (*Config, error) {
 		slog.Debug("No config file found, using defaults and env vars")
 	}
 
-	// 3️⃣ 加载环境变量
+	// 3️⃣ 应用 profile
+	if withProfile {
+		profile := ""
+		if cmd != nil && cmd.IsSet("profile") {
+			profile = cmd.String("profile")
+		}
+		if err := applyProfile(profile, k); err != nil {
+			return nil, err
+		}
+	}
+
+	// 4️⃣ 加载环境变量
 	if err := k.Load(env.Provider(".", env.Opt{
 		Prefix: EnvPrefix,
 		TransformFunc: func(key, value string) (string, any) {
@@ -90,7 +127,7 @@ func Load(cmd *cli.Command, configPath, AppRawName string) (*Config, error) {
 		return nil, fmt.Errorf("failed to load environment variables: %w", err)
 	}
 
-	// 4️⃣ 加载 CLI flags (最高优先级，仅当用户明确指定时)
+	// 5️⃣ 加载 CLI flags (最高优先级，仅当用户明确指定时)
 	if cmd != nil {
 		applyCLIFlags(cmd, k)
 	}
@@ -120,8 +157,13 @@ func Load(cmd *cli.Command, configPath, AppRawName string) (*Config, error) {
 //   - 时间类型: time.Duration, time.Time
 //   - 切片类型: []string, []int, []int64, []float64 等
 //   - Map 类型: map[string]string
+//
+// --profile 与 current_profile 名称不对应，单独映射
 func applyCLIFlags(cmd *cli.Command, k *koanf.Koanf) {
 	applyCLIFlagsRecursive(cmd, k, reflect.TypeOf(Config{}), "")
+	if cmd.IsSet("profile") {
+		_ = k.Set("current_profile", cmd.String("profile"))
+	}
 }
 
 // applyCLIFlagsRecursive 递归遍历结构体字段应用 CLI flags
@@ -88,7 +88,7 @@ func TestConfigKeysValid(t *testing.T) {
 	// 收集无效的配置键
 	var invalidKeys []string
 	for _, key := range configKeys {
-		if !validKeyMap[key] {
+		if !validKeyMap[key] && !isValidProfileKey(key, validKeyMap) {
 			invalidKeys = append(invalidKeys, key)
 		}
 	}
@@ -102,6 +102,21 @@ func TestConfigKeysValid(t *testing.T) {
 	}
 }
 
+// isValidProfileKey 检查 profiles.<name>.<key> 形式的配置键
+// profile 内部只允许 server/auth/tls 段中的有效配置项
+func isValidProfileKey(key string, validKeyMap map[string]bool) bool {
+	parts := strings.SplitN(key, ".", 3)
+	if len(parts) != 3 || parts[0] != "profiles" {
+		return false
+	}
+	for _, section := range profileSections {
+		if strings.HasPrefix(parts[2], section+".") {
+			return validKeyMap[parts[2]]
+		}
+	}
+	return false
+}
+
 // loadYAMLKeys 加载 YAML 文件并返回所有配置键的扁平化列表
 func loadYAMLKeys(path string) ([]string, error) {
 	k := koanf.New(".")
@@ -0,0 +1,153 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/knadh/koanf/providers/structs"
+	"github.com/knadh/koanf/v2"
+	"go.yaml.in/yaml/v3"
+)
+
+// redactedValue 敏感字段脱敏后的占位值
+const redactedValue = "******"
+
+// ProfileConfig 命名 profile，包含一套完整的连接配置
+type ProfileConfig struct {
+	Server ServerConfig `koanf:"server" comment:"服务器配置"`
+	Auth   AuthConfig   `koanf:"auth" comment:"认证配置"`
+	TLS    TLSConfig    `koanf:"tls" comment:"TLS 配置"`
+}
+
+// ProfileNames 返回按字母排序的 profile 名称列表
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Redacted 返回敏感字段 (密码、Token) 脱敏后的配置副本
+func (c *Config) Redacted() Config {
+	out := *c
+	out.Auth = out.Auth.redacted()
+	out.Migrate.Source.Auth = out.Migrate.Source.Auth.redacted()
+	out.Migrate.Destination.Auth = out.Migrate.Destination.Auth.redacted()
+
+	if c.Profiles != nil {
+		out.Profiles = make(map[string]ProfileConfig, len(c.Profiles))
+		for name, p := range c.Profiles {
+			p.Auth = p.Auth.redacted()
+			out.Profiles[name] = p
+		}
+	}
+	return out
+}
+
+// redacted 返回脱敏后的认证配置
+func (a AuthConfig) redacted() AuthConfig {
+	if a.Password != "" {
+		a.Password = redactedValue
+	}
+	if a.Token != "" {
+		a.Token = redactedValue
+	}
+	return a
+}
+
+// profileSections profile 覆盖的顶层配置段
+var profileSections = []string{"server", "auth", "tls"}
+
+// applyProfile 使用选中的 profile 替换顶层 server/auth/tls 配置
+// profile 名称优先取 --profile flag，其次取配置文件中的 current_profile
+// profile 是完整的连接配置：未设置的字段回退到默认值，而不是继承顶层配置，
+// 以免顶层的凭据被带到其他环境
+func applyProfile(profile string, k *koanf.Koanf) error {
+	if profile == "" {
+		profile = k.String("current_profile")
+	}
+	if profile == "" {
+		return nil
+	}
+
+	key := "profiles." + profile
+	if !k.Exists(key) {
+		return fmt.Errorf("profile not found: %s", profile)
+	}
+
+	defaults := koanf.New(".")
+	if err := defaults.Load(structs.Provider(DefaultConfig(), "koanf"), nil); err != nil {
+		return fmt.Errorf("failed to load default config: %w", err)
+	}
+
+	selected := k.Cut(key)
+	for _, section := range profileSections {
+		k.Delete(section)
+		if err := k.MergeAt(defaults.Cut(section), section); err != nil {
+			return fmt.Errorf("failed to apply profile %s: %w", profile, err)
+		}
+	}
+
+	_ = k.Set("current_profile", profile)
+	return k.Merge(selected)
+}
+
+// SetCurrentProfile 修改配置文件中的 current_profile
+// 通过 yaml.Node 原地修改，保留文件中的注释与字段顺序
+func SetCurrentProfile(path, profile string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	if len(doc.Content) == 0 {
+		doc.Kind = yaml.DocumentNode
+		doc.Content = []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("invalid config file %s: top level is not a mapping", path)
+	}
+
+	value := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: profile}
+	found := false
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "current_profile" {
+			value.LineComment = root.Content[i+1].LineComment
+			root.Content[i+1] = value
+			found = true
+			break
+		}
+	}
+	if !found {
+		key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "current_profile"}
+		// 文件头注释保持在最前面
+		if len(root.Content) > 0 {
+			key.HeadComment, root.Content[0].HeadComment = root.Content[0].HeadComment, ""
+		}
+		root.Content = append([]*yaml.Node{key, value}, root.Content...)
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return fmt.Errorf("failed to encode config file: %w", err)
+	}
+	_ = enc.Close()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to stat config file: %w", err)
+	}
+	return os.WriteFile(path, buf.Bytes(), info.Mode().Perm())
+}
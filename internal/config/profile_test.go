@@ -0,0 +1,116 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli/v3"
+)
+
+// TestLoadProfile 验证 profile 替换顶层 server/auth/tls，且不继承顶层凭据
+func TestLoadProfile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `# 测试配置
+server:
+  url: http://top:8428
+auth:
+  type: bearer
+  token: top-secret
+profiles:
+  prod:
+    server:
+      url: http://prod:8481
+      tenant: "1:0"
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("写入配置文件失败: %v", err)
+	}
+
+	cfg, err := Load(nil, path, "test")
+	if err != nil {
+		t.Fatalf("加载配置失败: %v", err)
+	}
+	if cfg.Server.URL != "http://top:8428" || cfg.Auth.Token != "top-secret" {
+		t.Errorf("未选择 profile 时应使用顶层配置, got %+v %+v", cfg.Server, cfg.Auth)
+	}
+
+	// 切换 profile 后重新加载
+	if err := SetCurrentProfile(path, "prod"); err != nil {
+		t.Fatalf("设置 current_profile 失败: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.HasPrefix(string(data), "# 测试配置\ncurrent_profile: prod\n") {
+		t.Errorf("current_profile 写入位置不正确:\n%s", data)
+	}
+
+	cfg, err = Load(nil, path, "test")
+	if err != nil {
+		t.Fatalf("加载配置失败: %v", err)
+	}
+	if cfg.CurrentProfile != "prod" {
+		t.Errorf("CurrentProfile = %q, want prod", cfg.CurrentProfile)
+	}
+	if cfg.Server.URL != "http://prod:8481" || cfg.Server.Tenant != "1:0" {
+		t.Errorf("profile server 未生效: %+v", cfg.Server)
+	}
+	if cfg.Server.Timeout != DefaultConfig().Server.Timeout {
+		t.Errorf("profile 未设置的字段应回退默认值, got %v", cfg.Server.Timeout)
+	}
+	if cfg.Auth.Token != "" || cfg.Auth.Type != "" {
+		t.Errorf("profile 不应继承顶层认证配置: %+v", cfg.Auth)
+	}
+	if got := cfg.Redacted().Auth.Token; got != "" {
+		t.Errorf("空 token 不应被脱敏, got %q", got)
+	}
+
+	// 不存在的 profile
+	if err := SetCurrentProfile(path, "missing"); err != nil {
+		t.Fatalf("设置 current_profile 失败: %v", err)
+	}
+	if _, err := Load(nil, path, "test"); err == nil {
+		t.Error("不存在的 profile 应返回错误")
+	}
+}
+
+// TestLoadProfileFlag 验证 --profile 选择 profile 并写入 CurrentProfile，其他 CLI flags 仍覆盖 profile
+func TestLoadProfileFlag(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := `server:
+  url: http://top:8428
+profiles:
+  prod:
+    server:
+      url: http://prod:8481
+      tenant: "1:0"
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("写入配置文件失败: %v", err)
+	}
+
+	var cfg *Config
+	cmd := &cli.Command{
+		Name: "test",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "profile"},
+			&cli.StringFlag{Name: "server-tenant"},
+		},
+		Action: func(_ context.Context, cmd *cli.Command) error {
+			var err error
+			cfg, err = Load(cmd, path, "test")
+			return err
+		},
+	}
+	if err := cmd.Run(context.Background(), []string{"test", "--profile", "prod", "--server-tenant", "2"}); err != nil {
+		t.Fatalf("加载配置失败: %v", err)
+	}
+	if cfg.CurrentProfile != "prod" {
+		t.Errorf("CurrentProfile = %q, want prod", cfg.CurrentProfile)
+	}
+	if cfg.Server.URL != "http://prod:8481" || cfg.Server.Tenant != "2" {
+		t.Errorf("server = %+v, want prod url with tenant from flag", cfg.Server)
+	}
+}
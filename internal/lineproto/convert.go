@@ -0,0 +1,383 @@
+package lineproto
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 转换支持的格式
+const (
+	FormatJSON       = "json"       // JSON Line (/api/v1/export 与 /api/v1/import)
+	FormatCSV        = "csv"        // CSV，列由 CSVFormat 定义 (/api/v1/export/csv 的 format 参数)
+	FormatPrometheus = "prometheus" // Prometheus exposition，时间戳为毫秒
+	FormatInflux     = "influx"     // Influx line protocol
+)
+
+// Formats 支持的格式
+var Formats = []string{FormatJSON, FormatCSV, FormatPrometheus, FormatInflux}
+
+// DefaultCSVFormat 与 mc-vmexport csv 默认的列定义一致
+const DefaultCSVFormat = "__name__,__value__,__timestamp__:unix_s"
+
+// ConvertOptions 读写选项
+type ConvertOptions struct {
+	CSVFormat       string // CSV 列定义，为空时使用 DefaultCSVFormat
+	InfluxPrecision string // Influx 时间戳精度，为空时为 ns
+	InfluxField     string // 写出 Influx 时的字段名，为空时为 value
+	Now             func() time.Time
+}
+
+// RowReader 逐行读取时间序列，结束时返回 io.EOF
+type RowReader interface {
+	Read() (*Row, error)
+}
+
+// RowWriter 逐行写出时间序列
+type RowWriter interface {
+	Write(row *Row) error
+	Flush() error
+}
+
+// NewRowReader 创建指定格式的 Reader，错误带有行号
+func NewRowReader(format string, r io.Reader, opts ConvertOptions) (RowReader, error) {
+	opts = opts.withDefaults()
+	switch format {
+	case FormatJSON:
+		return &lineReader{sc: newScanner(r), parse: parseJSONRow}, nil
+	case FormatCSV:
+		columns, err := ParseCSVFormat(opts.CSVFormat)
+		if err != nil {
+			return nil, err
+		}
+		cr := csv.NewReader(bufio.NewReaderSize(r, 1<<20))
+		cr.FieldsPerRecord = len(columns)
+		cr.ReuseRecord = true
+		return &csvReader{r: cr, columns: columns, now: opts.Now}, nil
+	case FormatPrometheus:
+		return &lineReader{sc: newScanner(r), parse: func(line string) ([]Row, error) {
+			s, err := ParsePromLine(line)
+			if s == nil {
+				return nil, err
+			}
+			ts := s.Timestamp
+			if !s.HasTimestamp {
+				ts = opts.Now().UnixMilli()
+			}
+			return []Row{{Metric: s.Labels, Values: []float64{s.Value}, Timestamps: []int64{ts}}}, nil
+		}}, nil
+	case FormatInflux:
+		if _, err := InfluxTimestampMillis(0, opts.InfluxPrecision); err != nil {
+			return nil, err
+		}
+		return &lineReader{sc: newScanner(r), parse: func(line string) ([]Row, error) {
+			p, err := ParseInfluxLine(line)
+			if p == nil {
+				return nil, err
+			}
+			return p.Rows("_", "", opts.InfluxPrecision, opts.Now().UnixMilli())
+		}}, nil
+	}
+	return nil, unsupportedFormat(format)
+}
+
+// NewRowWriter 创建指定格式的 Writer
+func NewRowWriter(format string, w io.Writer, opts ConvertOptions) (RowWriter, error) {
+	opts = opts.withDefaults()
+	bw := bufio.NewWriterSize(w, 1<<16)
+	switch format {
+	case FormatJSON:
+		return &lineWriter{w: bw, appendRow: func(dst []byte, row *Row) ([]byte, error) {
+			return row.AppendJSON(dst), nil
+		}}, nil
+	case FormatCSV:
+		columns, err := ParseCSVFormat(opts.CSVFormat)
+		if err != nil {
+			return nil, err
+		}
+		return &csvWriter{bw: bw, w: csv.NewWriter(bw), columns: columns}, nil
+	case FormatPrometheus:
+		return &lineWriter{w: bw, appendRow: func(dst []byte, row *Row) ([]byte, error) {
+			for i, v := range row.Values {
+				dst = AppendPromSample(dst, row.Metric, v, row.Timestamps[i])
+			}
+			return dst, nil
+		}}, nil
+	case FormatInflux:
+		if _, err := InfluxTimestampFromMillis(0, opts.InfluxPrecision); err != nil {
+			return nil, err
+		}
+		return &lineWriter{w: bw, appendRow: func(dst []byte, row *Row) ([]byte, error) {
+			if row.Metric["__name__"] == "" {
+				return dst, fmt.Errorf("series %v has no metric name", row.Metric)
+			}
+			for i, v := range row.Values {
+				// line protocol 不支持 NaN 与 ±Inf
+				if math.IsNaN(v) || math.IsInf(v, 0) {
+					continue
+				}
+				ts, _ := InfluxTimestampFromMillis(row.Timestamps[i], opts.InfluxPrecision)
+				dst = AppendInfluxPoint(dst, row.Metric, opts.InfluxField, v, ts)
+			}
+			return dst, nil
+		}}, nil
+	}
+	return nil, unsupportedFormat(format)
+}
+
+// withDefaults 填充默认值
+func (o ConvertOptions) withDefaults() ConvertOptions {
+	if o.CSVFormat == "" {
+		o.CSVFormat = DefaultCSVFormat
+	}
+	if o.InfluxPrecision == "" {
+		o.InfluxPrecision = "ns"
+	}
+	if o.InfluxField == "" {
+		o.InfluxField = "value"
+	}
+	if o.Now == nil {
+		o.Now = time.Now
+	}
+	return o
+}
+
+// unsupportedFormat 返回不支持的格式错误
+func unsupportedFormat(format string) error {
+	return fmt.Errorf("unsupported format: %s (use %s)", format, strings.Join(Formats, ", "))
+}
+
+// newScanner 创建按行读取的 Scanner，单行最大 64MB (JSON Line 的一行可能包含大量样本)
+func newScanner(r io.Reader) *bufio.Scanner {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64<<10), 64<<20)
+	return sc
+}
+
+// lineReader 按行解析的 Reader，一行可以产生多个序列 (如 Influx 的多个字段)
+type lineReader struct {
+	sc      *bufio.Scanner
+	parse   func(line string) ([]Row, error)
+	line    int
+	pending []Row
+}
+
+// Read 实现 RowReader
+func (r *lineReader) Read() (*Row, error) {
+	for len(r.pending) == 0 {
+		if !r.sc.Scan() {
+			if err := r.sc.Err(); err != nil {
+				return nil, fmt.Errorf("line %d: %w", r.line+1, err)
+			}
+			return nil, io.EOF
+		}
+		r.line++
+		rows, err := r.parse(r.sc.Text())
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", r.line, err)
+		}
+		r.pending = rows
+	}
+	row := &r.pending[0]
+	r.pending = r.pending[1:]
+	return row, nil
+}
+
+// parseJSONRow 解析一行 JSON Line
+func parseJSONRow(line string) ([]Row, error) {
+	if strings.TrimSpace(line) == "" {
+		return nil, nil
+	}
+	var row Row
+	if err := UnmarshalJSONLine([]byte(line), &row); err != nil {
+		return nil, err
+	}
+	if len(row.Values) != len(row.Timestamps) {
+		return nil, fmt.Errorf("%d values but %d timestamps", len(row.Values), len(row.Timestamps))
+	}
+	return []Row{row}, nil
+}
+
+// lineWriter 每个序列追加若干行后写出
+type lineWriter struct {
+	w         *bufio.Writer
+	appendRow func(dst []byte, row *Row) ([]byte, error)
+	buf       []byte
+}
+
+// Write 实现 RowWriter
+func (w *lineWriter) Write(row *Row) error {
+	var err error
+	if w.buf, err = w.appendRow(w.buf[:0], row); err != nil {
+		return err
+	}
+	_, err = w.w.Write(w.buf)
+	return err
+}
+
+// Flush 实现 RowWriter
+func (w *lineWriter) Flush() error {
+	return w.w.Flush()
+}
+
+// CSVColumn CSV 列定义中的一列
+type CSVColumn struct {
+	Label      string // 标签名，__name__ 为指标名，__value__ 与 __timestamp__ 为样本
+	TimeFormat string // __timestamp__ 列的格式: unix_s, unix_ms, unix_ns, rfc3339 或 custom:<layout>
+}
+
+// ParseCSVFormat 解析 CSV 列定义，如 __name__,__value__,__timestamp__:unix_s,host
+// 格式与 /api/v1/export/csv 的 format 参数一致，必须包含 __value__ 列
+func ParseCSVFormat(format string) ([]CSVColumn, error) {
+	var columns []CSVColumn
+	hasValue := false
+	for _, f := range strings.Split(format, ",") {
+		name, tf, _ := strings.Cut(strings.TrimSpace(f), ":")
+		switch {
+		case name == "":
+			return nil, fmt.Errorf("invalid csv format %q: empty column", format)
+		case name == "__value__":
+			hasValue = true
+		case name == "__timestamp__":
+			switch {
+			case tf == "":
+				tf = "unix_s"
+			case tf == "unix_s", tf == "unix_ms", tf == "unix_ns", tf == "rfc3339", strings.HasPrefix(tf, "custom:"):
+			default:
+				return nil, fmt.Errorf("invalid csv format %q: unsupported timestamp format %s (use unix_s, unix_ms, unix_ns, rfc3339 or custom:<layout>)", format, tf)
+			}
+		}
+		columns = append(columns, CSVColumn{Label: name, TimeFormat: tf})
+	}
+	if !hasValue {
+		return nil, fmt.Errorf("invalid csv format %q: missing __value__ column", format)
+	}
+	return columns, nil
+}
+
+// formatCSVTime 按列格式输出毫秒时间戳，rfc3339 与 custom 使用 UTC
+func formatCSVTime(ms int64, format string) string {
+	switch format {
+	case "unix_ms":
+		return strconv.FormatInt(ms, 10)
+	case "unix_ns":
+		return strconv.FormatInt(ms*int64(time.Millisecond), 10)
+	case "rfc3339":
+		return time.UnixMilli(ms).UTC().Format(time.RFC3339)
+	}
+	if layout, ok := strings.CutPrefix(format, "custom:"); ok {
+		return time.UnixMilli(ms).UTC().Format(layout)
+	}
+	return strconv.FormatInt(ms/1000, 10)
+}
+
+// parseCSVTime 按列格式解析时间戳，返回毫秒
+func parseCSVTime(s, format string) (int64, error) {
+	var layout string
+	switch format {
+	case "unix_s", "unix_ms", "unix_ns":
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid timestamp %q", s)
+		}
+		scale := map[string]float64{"unix_s": 1e3, "unix_ms": 1, "unix_ns": 1e-6}[format]
+		return int64(math.Round(n * scale)), nil
+	case "rfc3339":
+		layout = time.RFC3339
+	default:
+		layout = strings.TrimPrefix(format, "custom:")
+	}
+	t, err := time.Parse(layout, s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timestamp %q: %w", s, err)
+	}
+	return t.UnixMilli(), nil
+}
+
+// csvReader 每条记录一个样本；没有 __timestamp__ 列时使用当前时间
+type csvReader struct {
+	r       *csv.Reader
+	columns []CSVColumn
+	now     func() time.Time
+}
+
+// Read 实现 RowReader
+func (r *csvReader) Read() (*Row, error) {
+	record, err := r.r.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, io.EOF
+	}
+	if err != nil {
+		return nil, err // csv.ParseError 已带有行号
+	}
+	line, _ := r.r.FieldPos(0)
+
+	row := &Row{Metric: make(map[string]string, len(r.columns))}
+	var v float64
+	ts := int64(-1)
+	for i, c := range r.columns {
+		switch c.Label {
+		case "__value__":
+			if v, err = strconv.ParseFloat(record[i], 64); err != nil {
+				return nil, fmt.Errorf("line %d: invalid value %q", line, record[i])
+			}
+		case "__timestamp__":
+			if ts, err = parseCSVTime(record[i], c.TimeFormat); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+		default:
+			if record[i] != "" {
+				row.Metric[c.Label] = record[i]
+			}
+		}
+	}
+	if ts < 0 {
+		ts = r.now().UnixMilli()
+	}
+	row.Values, row.Timestamps = []float64{v}, []int64{ts}
+	return row, nil
+}
+
+// csvWriter 每个样本一条记录
+type csvWriter struct {
+	bw      *bufio.Writer
+	w       *csv.Writer
+	columns []CSVColumn
+	record  []string
+}
+
+// Write 实现 RowWriter
+func (w *csvWriter) Write(row *Row) error {
+	for i, v := range row.Values {
+		w.record = w.record[:0]
+		for _, c := range w.columns {
+			switch c.Label {
+			case "__value__":
+				w.record = append(w.record, strconv.FormatFloat(v, 'g', -1, 64))
+			case "__timestamp__":
+				w.record = append(w.record, formatCSVTime(row.Timestamps[i], c.TimeFormat))
+			default:
+				w.record = append(w.record, row.Metric[c.Label])
+			}
+		}
+		if err := w.w.Write(w.record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Flush 实现 RowWriter
+func (w *csvWriter) Flush() error {
+	w.w.Flush()
+	if err := w.w.Error(); err != nil {
+		return err
+	}
+	return w.bw.Flush()
+}
@@ -0,0 +1,159 @@
+package lineproto
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// convertString 用 Reader/Writer 转换整个字符串
+func convertString(t *testing.T, in, from, to string, readOpts, writeOpts ConvertOptions) (string, error) {
+	t.Helper()
+	rr, err := NewRowReader(from, strings.NewReader(in), readOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out strings.Builder
+	rw, err := NewRowWriter(to, &out, writeOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for {
+		row, err := rr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return out.String(), err
+		}
+		if err := rw.Write(row); err != nil {
+			return out.String(), err
+		}
+	}
+	err = rw.Flush()
+	return out.String(), err
+}
+
+// TestConvert 验证各格式之间的转换
+func TestConvert(t *testing.T) {
+	jsonl := `{"metric":{"__name__":"cpu","host":"a b","note":"x\"y"},"values":[1.5,2],"timestamps":[1700000000000,1700000015500]}
+`
+	now := func() time.Time { return time.UnixMilli(42) }
+
+	tests := []struct {
+		name, in, from, to  string
+		readOpts, writeOpts ConvertOptions
+		want                string
+	}{
+		{
+			name: "json to prometheus", in: jsonl, from: FormatJSON, to: FormatPrometheus,
+			want: "cpu{host=\"a b\",note=\"x\\\"y\"} 1.5 1700000000000\ncpu{host=\"a b\",note=\"x\\\"y\"} 2 1700000015500\n",
+		},
+		{
+			name: "json to csv", in: jsonl, from: FormatJSON, to: FormatCSV,
+			writeOpts: ConvertOptions{CSVFormat: "__name__,host,__value__,__timestamp__:rfc3339"},
+			want:      "cpu,a b,1.5,2023-11-14T22:13:20Z\ncpu,a b,2,2023-11-14T22:13:35Z\n",
+		},
+		{
+			name: "json to influx", in: jsonl, from: FormatJSON, to: FormatInflux,
+			writeOpts: ConvertOptions{InfluxPrecision: "s"},
+			want:      "cpu,host=a\\ b,note=x\"y value=1.5 1700000000\ncpu,host=a\\ b,note=x\"y value=2 1700000015\n",
+		},
+		{
+			name: "prometheus to json", from: FormatPrometheus, to: FormatJSON,
+			in: "# TYPE up gauge\n{__name__=\"up\", job=\"a,b\"} 1\nrate_total{x=\"\\n\"} NaN 7\n",
+			want: `{"metric":{"__name__":"up","job":"a,b"},"values":[1],"timestamps":[42]}` + "\n" +
+				`{"metric":{"__name__":"rate_total","x":"\n"},"values":[null],"timestamps":[7]}` + "\n",
+		},
+		{
+			name: "csv to json", from: FormatCSV, to: FormatJSON,
+			in:       "1.5,web,1700000000500\n",
+			readOpts: ConvertOptions{CSVFormat: "__value__,host,__timestamp__:unix_ms"},
+			want:     `{"metric":{"host":"web"},"values":[1.5],"timestamps":[1700000000500]}` + "\n",
+		},
+		{
+			name: "influx to json", from: FormatInflux, to: FormatJSON,
+			in:       "disk,dev=sda used=3i,free=5 1700000000\n",
+			readOpts: ConvertOptions{InfluxPrecision: "s"},
+			want: `{"metric":{"__name__":"disk_used","dev":"sda"},"values":[3],"timestamps":[1700000000000]}` + "\n" +
+				`{"metric":{"__name__":"disk_free","dev":"sda"},"values":[5],"timestamps":[1700000000000]}` + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.readOpts.Now = now
+			got, err := convertString(t, tt.in, tt.from, tt.to, tt.readOpts, tt.writeOpts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestConvertNonFinite 验证 export 输出的 null 与 "Infinity" 值在各格式间保持为 NaN 与 ±Inf
+func TestConvertNonFinite(t *testing.T) {
+	// /api/v1/export 的实际输出: staleness 标记为 null，±Inf 为字符串
+	export := `{"metric":{"__name__":"up","job":"node"},"values":[1,null,"Infinity","-Infinity"],"timestamps":[1700000000000,1700000015000,1700000030000,1700000045000]}` + "\n"
+
+	prom, err := convertString(t, export, FormatJSON, FormatPrometheus, ConvertOptions{}, ConvertOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantProm := "up{job=\"node\"} 1 1700000000000\nup{job=\"node\"} NaN 1700000015000\n" +
+		"up{job=\"node\"} +Inf 1700000030000\nup{job=\"node\"} -Inf 1700000045000\n"
+	if prom != wantProm {
+		t.Errorf("json to prometheus got\n%s\nwant\n%s", prom, wantProm)
+	}
+
+	csvOpts := ConvertOptions{CSVFormat: "__name__,job,__value__,__timestamp__:unix_ms"}
+	for _, to := range []string{FormatJSON, FormatPrometheus, FormatCSV} {
+		mid, err := convertString(t, export, FormatJSON, to, ConvertOptions{}, csvOpts)
+		if err != nil {
+			t.Fatalf("json to %s: %v", to, err)
+		}
+		back, err := convertString(t, mid, to, FormatJSON, csvOpts, ConvertOptions{})
+		if err != nil {
+			t.Fatalf("%s to json: %v", to, err)
+		}
+		if to == FormatJSON {
+			if back != export {
+				t.Errorf("json round trip got\n%s\nwant\n%s", back, export)
+			}
+			continue
+		}
+		// 逐点格式每个点一行
+		if strings.Count(back, "null") != 1 || strings.Count(back, `"Infinity"`) != 1 || strings.Count(back, `"-Infinity"`) != 1 {
+			t.Errorf("%s round trip lost non-finite values:\n%s", to, back)
+		}
+	}
+}
+
+// TestConvertErrors 验证读取错误带有行号
+func TestConvertErrors(t *testing.T) {
+	tests := []struct {
+		in, from, want string
+	}{
+		{"{\"metric\":{},\"values\":[1],\"timestamps\":[]}\n", FormatJSON, "line 1: 1 values but 0 timestamps"},
+		{"up 1\n\nup{job=\"a\" 1\n", FormatPrometheus, "line 3: label job: expected , or } after value"},
+		{"up,1,1\nup,x,2\n", FormatCSV, `line 2: invalid value "x"`},
+		{"up,1\n", FormatCSV, "wrong number of fields"},
+	}
+	for _, tt := range tests {
+		_, err := convertString(t, tt.in, tt.from, FormatJSON, ConvertOptions{}, ConvertOptions{})
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s %q: err = %v, want %q", tt.from, tt.in, err, tt.want)
+		}
+	}
+
+	if _, err := ParseCSVFormat("__name__,__timestamp__:unix_s"); err == nil {
+		t.Error("ParseCSVFormat 应要求 __value__ 列")
+	}
+	if _, err := ParseCSVFormat("__value__,__timestamp__:iso"); err == nil {
+		t.Error("ParseCSVFormat 应拒绝不支持的时间戳格式")
+	}
+}
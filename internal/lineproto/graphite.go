@@ -0,0 +1,133 @@
+package lineproto
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// GraphitePoint 一行 Graphite plaintext 数据
+type GraphitePoint struct {
+	Path      string
+	Tags      []Tag
+	Value     float64
+	Timestamp int64 // 毫秒，未指定 (或为 -1) 时为 0
+}
+
+// ParseGraphiteLine 解析一行 Graphite plaintext: path[;tag=value...] value [timestamp]
+// 时间戳单位为秒，可以带小数；空行与 # 注释行返回 nil
+func ParseGraphiteLine(line string) (*GraphitePoint, error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+		return nil, nil
+	}
+	if len(fields) < 2 || len(fields) > 3 {
+		return nil, fmt.Errorf("expected \"path value [timestamp]\", got %d fields", len(fields))
+	}
+
+	parts := strings.Split(fields[0], ";")
+	p := &GraphitePoint{Path: parts[0]}
+	if p.Path == "" {
+		return nil, fmt.Errorf("missing metric path")
+	}
+	for _, part := range parts[1:] {
+		k, v, ok := strings.Cut(part, "=")
+		if !ok || k == "" || v == "" {
+			return nil, fmt.Errorf("invalid tag %q: expected key=value", part)
+		}
+		p.Tags = append(p.Tags, Tag{Key: k, Value: v})
+	}
+
+	var err error
+	if p.Value, err = strconv.ParseFloat(fields[1], 64); err != nil {
+		return nil, fmt.Errorf("invalid value %q", fields[1])
+	}
+	if len(fields) == 3 {
+		ts, err := strconv.ParseFloat(fields[2], 64)
+		if err != nil || math.IsNaN(ts) || math.IsInf(ts, 0) {
+			return nil, fmt.Errorf("invalid timestamp %q", fields[2])
+		}
+		if ts != -1 {
+			p.Timestamp = int64(math.Round(ts * 1000))
+		}
+	}
+	return p, nil
+}
+
+// Row 将数据点转换为 JSON Line，指标名为路径，标签原样保留
+// 路径匹配 templates 中的第一个模板时按模板提取标签；没有时间戳时使用 now (毫秒)
+func (p *GraphitePoint) Row(templates []*GraphiteTemplate, now int64) Row {
+	metric := make(map[string]string, len(p.Tags)+1)
+	metric["__name__"] = p.Path
+	for _, t := range templates {
+		if labels, ok := t.Apply(p.Path); ok {
+			for k, v := range labels {
+				metric[k] = v
+			}
+			break
+		}
+	}
+	for _, t := range p.Tags {
+		metric[t.Key] = t.Value
+	}
+
+	ts := p.Timestamp
+	if ts == 0 {
+		ts = now
+	}
+	return Row{Metric: metric, Values: []float64{p.Value}, Timestamps: []int64{ts}}
+}
+
+// GraphiteTemplate 路径到标签的模板，如 "servers.*.cpu.* host=$1 metric=$2"
+// 模式按 . 分段，* 匹配一段中的任意字符，每个 * 依次对应 $1, $2 ...
+// metric (或 __name__) 标签设置指标名，未设置时指标名保持为完整路径
+type GraphiteTemplate struct {
+	pattern *regexp.Regexp
+	labels  []Tag // 值中可以引用 $N 或 ${N}
+}
+
+// ParseGraphiteTemplate 解析模板
+func ParseGraphiteTemplate(s string) (*GraphiteTemplate, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty graphite template")
+	}
+
+	nodes := strings.Split(fields[0], ".")
+	for i, node := range nodes {
+		if node == "" {
+			return nil, fmt.Errorf("invalid graphite template %q: empty path node", s)
+		}
+		nodes[i] = strings.ReplaceAll(regexp.QuoteMeta(node), `\*`, `([^.]*)`)
+	}
+	t := &GraphiteTemplate{pattern: regexp.MustCompile("^" + strings.Join(nodes, `\.`) + "$")}
+
+	for _, f := range fields[1:] {
+		k, v, ok := strings.Cut(f, "=")
+		if !ok || k == "" {
+			return nil, fmt.Errorf("invalid graphite template %q: expected label=value, got %q", s, f)
+		}
+		if k == "metric" {
+			k = "__name__"
+		}
+		t.labels = append(t.labels, Tag{Key: k, Value: v})
+	}
+	return t, nil
+}
+
+// Apply 路径匹配模板时返回提取的标签，值为空的标签被忽略
+func (t *GraphiteTemplate) Apply(path string) (map[string]string, bool) {
+	match := t.pattern.FindStringSubmatchIndex(path)
+	if match == nil {
+		return nil, false
+	}
+	labels := make(map[string]string, len(t.labels))
+	for _, l := range t.labels {
+		if v := string(t.pattern.ExpandString(nil, l.Value, path, match)); v != "" {
+			labels[l.Key] = v
+		}
+	}
+	return labels, true
+}
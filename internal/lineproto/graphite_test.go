@@ -0,0 +1,58 @@
+package lineproto
+
+import (
+	"maps"
+	"strings"
+	"testing"
+)
+
+// TestGraphite 验证 plaintext 解析、tag 语法与路径模板
+func TestGraphite(t *testing.T) {
+	templates := []*GraphiteTemplate{}
+	for _, s := range []string{"servers.*.cpu.* host=$1 metric=cpu_$2", "servers.*.* host=$1 metric=${2}_total"} {
+		tmpl, err := ParseGraphiteTemplate(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		templates = append(templates, tmpl)
+	}
+
+	tests := []struct {
+		line string
+		want map[string]string
+		ts   int64
+	}{
+		{"servers.web01.cpu.idle 95.5 1700000000", map[string]string{"__name__": "cpu_idle", "host": "web01"}, 1700000000000},
+		{"servers.web01.requests 3 1700000000.25", map[string]string{"__name__": "requests_total", "host": "web01"}, 1700000000250},
+		{"app.latency;env=prod;dc=eu 1.5 -1", map[string]string{"__name__": "app.latency", "env": "prod", "dc": "eu"}, 42},
+		{"app.latency 1.5", map[string]string{"__name__": "app.latency"}, 42},
+	}
+	for _, tt := range tests {
+		p, err := ParseGraphiteLine(tt.line)
+		if err != nil {
+			t.Fatalf("ParseGraphiteLine(%q): %v", tt.line, err)
+		}
+		row := p.Row(templates, 42)
+		if !maps.Equal(row.Metric, tt.want) || row.Timestamps[0] != tt.ts {
+			t.Errorf("%q -> %v @%d, want %v @%d", tt.line, row.Metric, row.Timestamps[0], tt.want, tt.ts)
+		}
+	}
+
+	if p, err := ParseGraphiteLine("  # comment"); p != nil || err != nil {
+		t.Errorf("comment = %v, %v", p, err)
+	}
+	bad := map[string]string{
+		"cpu":             "got 1 fields",
+		"cpu abc":         "invalid value",
+		"cpu 1 yesterday": "invalid timestamp",
+		"cpu;host 1":      "invalid tag",
+	}
+	for line, msg := range bad {
+		if _, err := ParseGraphiteLine(line); err == nil || !strings.Contains(err.Error(), msg) {
+			t.Errorf("ParseGraphiteLine(%q) error = %v, want %q", line, err, msg)
+		}
+	}
+	if _, err := ParseGraphiteTemplate("servers..cpu host=$1"); err == nil {
+		t.Error("ParseGraphiteTemplate 应拒绝空路径段")
+	}
+}
@@ -0,0 +1,339 @@
+// Package lineproto 提供按行组织的文本写入协议 (Influx line protocol 等) 的解析，
+// 以及向 VictoriaMetrics JSON Line 导入格式的转换
+package lineproto
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// InfluxPoint 一行 Influx line protocol 数据
+type InfluxPoint struct {
+	Measurement  string
+	Tags         []Tag
+	Fields       []Field
+	Timestamp    int64 // 原始时间戳，单位由 precision 决定
+	HasTimestamp bool
+}
+
+// Tag 标签
+type Tag struct {
+	Key   string
+	Value string
+}
+
+// Field 字段，字符串字段不是数值 (导入 VictoriaMetrics 时被忽略)
+type Field struct {
+	Key       string
+	Value     float64
+	IsNumeric bool
+}
+
+// InfluxPrecisions 支持的时间戳精度 (与 /write 的 precision 参数一致)
+var InfluxPrecisions = []string{"ns", "u", "us", "ms", "s", "m", "h"}
+
+// InfluxTimestampMillis 按精度将时间戳转换为毫秒
+func InfluxTimestampMillis(ts int64, precision string) (int64, error) {
+	switch precision {
+	case "", "ns", "n":
+		return ts / int64(time.Millisecond), nil
+	case "u", "us", "µ":
+		return ts / 1000, nil
+	case "ms":
+		return ts, nil
+	case "s":
+		return ts * 1000, nil
+	case "m":
+		return ts * 60 * 1000, nil
+	case "h":
+		return ts * 3600 * 1000, nil
+	}
+	return 0, fmt.Errorf("unsupported precision: %s (use %s)", precision, strings.Join(InfluxPrecisions, ", "))
+}
+
+// InfluxTimestampFromMillis 按精度将毫秒时间戳转换为 line protocol 时间戳 (不足一个单位的部分被截断)
+func InfluxTimestampFromMillis(ms int64, precision string) (int64, error) {
+	switch precision {
+	case "", "ns", "n":
+		return ms * int64(time.Millisecond), nil
+	case "u", "us", "µ":
+		return ms * 1000, nil
+	case "ms":
+		return ms, nil
+	case "s":
+		return ms / 1000, nil
+	case "m":
+		return ms / (60 * 1000), nil
+	case "h":
+		return ms / (3600 * 1000), nil
+	}
+	return 0, fmt.Errorf("unsupported precision: %s (use %s)", precision, strings.Join(InfluxPrecisions, ", "))
+}
+
+// AppendInfluxPoint 以 line protocol 追加一行 (含换行符): 指标名为 measurement，标签按名称排序，字段名为 field
+func AppendInfluxPoint(dst []byte, labels map[string]string, field string, v float64, ts int64) []byte {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		if k != "__name__" && labels[k] != "" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	dst = append(dst, influxMeasurementEscaper.Replace(labels["__name__"])...)
+	for _, k := range keys {
+		dst = append(dst, ',')
+		dst = append(dst, influxKeyEscaper.Replace(k)...)
+		dst = append(dst, '=')
+		dst = append(dst, influxKeyEscaper.Replace(labels[k])...)
+	}
+	dst = append(dst, ' ')
+	dst = append(dst, influxKeyEscaper.Replace(field)...)
+	dst = append(dst, '=')
+	dst = strconv.AppendFloat(dst, v, 'g', -1, 64)
+	dst = append(dst, ' ')
+	dst = strconv.AppendInt(dst, ts, 10)
+	return append(dst, '\n')
+}
+
+// line protocol 转义: measurement 转义逗号与空格，标签与字段名另外转义等号
+var (
+	influxMeasurementEscaper = strings.NewReplacer(`,`, `\,`, ` `, `\ `)
+	influxKeyEscaper         = strings.NewReplacer(`,`, `\,`, `=`, `\=`, ` `, `\ `)
+)
+
+// ParseInfluxLine 解析一行 line protocol: measurement[,tag=value...] field=value[,field=value...] [timestamp]
+// 空行与 # 注释行返回 nil
+func ParseInfluxLine(line string) (*InfluxPoint, error) {
+	line = strings.TrimRight(line, "\r\n")
+	if strings.TrimSpace(line) == "" || strings.HasPrefix(strings.TrimLeft(line, " \t"), "#") {
+		return nil, nil
+	}
+
+	p := &InfluxPoint{}
+	s := &influxScanner{s: line}
+
+	// measurement 与标签: 直到第一个未转义的空格
+	key, err := s.until(" ", ",= ")
+	if err != nil {
+		return nil, err
+	}
+	parts := splitEscaped(key, ',')
+	p.Measurement = unescapeInflux(parts[0])
+	if p.Measurement == "" {
+		return nil, fmt.Errorf("missing measurement")
+	}
+	for _, part := range parts[1:] {
+		k, v, ok := cutEscaped(part, '=')
+		if !ok || k == "" || v == "" {
+			return nil, fmt.Errorf("invalid tag %q: expected key=value", part)
+		}
+		p.Tags = append(p.Tags, Tag{Key: unescapeInflux(k), Value: unescapeInflux(v)})
+	}
+
+	// 字段
+	if !s.skip(' ') {
+		return nil, fmt.Errorf("missing fields")
+	}
+	for {
+		k, err := s.until("= ,", " ,=")
+		if err != nil {
+			return nil, err
+		}
+		if k == "" || !s.skip('=') {
+			return nil, fmt.Errorf("invalid field at column %d: expected key=value", s.pos+1)
+		}
+		f := Field{Key: unescapeInflux(k)}
+		if s.peek() == '"' {
+			if err := s.quoted(); err != nil {
+				return nil, err
+			}
+		} else {
+			raw, _ := s.until(", ", "")
+			if f.Value, err = parseInfluxValue(raw); err != nil {
+				return nil, fmt.Errorf("invalid value for field %q: %w", f.Key, err)
+			}
+			f.IsNumeric = true
+		}
+		p.Fields = append(p.Fields, f)
+		if !s.skip(',') {
+			break
+		}
+	}
+
+	// 可选的时间戳
+	if s.skip(' ') {
+		raw := strings.TrimSpace(s.rest())
+		if raw != "" {
+			ts, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid timestamp %q", raw)
+			}
+			p.Timestamp, p.HasTimestamp = ts, true
+		}
+	} else if s.pos < len(s.s) {
+		return nil, fmt.Errorf("unexpected %q at column %d", s.s[s.pos], s.pos+1)
+	}
+	return p, nil
+}
+
+// Rows 将数据点转换为 JSON Line: 每个数值字段一个序列，指标名为 measurement + sep + field
+// 字符串字段被忽略；db 非空且没有 db 标签时追加 db 标签；没有时间戳时使用 now (毫秒)
+func (p *InfluxPoint) Rows(sep, db, precision string, now int64) ([]Row, error) {
+	ts := now
+	if p.HasTimestamp {
+		var err error
+		if ts, err = InfluxTimestampMillis(p.Timestamp, precision); err != nil {
+			return nil, err
+		}
+	}
+
+	rows := make([]Row, 0, len(p.Fields))
+	for _, f := range p.Fields {
+		if !f.IsNumeric {
+			continue
+		}
+		metric := make(map[string]string, len(p.Tags)+2)
+		for _, t := range p.Tags {
+			metric[t.Key] = t.Value
+		}
+		if _, ok := metric["db"]; !ok && db != "" {
+			metric["db"] = db
+		}
+		metric["__name__"] = p.Measurement + sep + f.Key
+		rows = append(rows, Row{Metric: metric, Values: []float64{f.Value}, Timestamps: []int64{ts}})
+	}
+	return rows, nil
+}
+
+// parseInfluxValue 解析非字符串字段值: 浮点数、整数 (i 后缀)、无符号整数 (u 后缀) 或布尔值
+func parseInfluxValue(raw string) (float64, error) {
+	switch raw {
+	case "":
+		return 0, fmt.Errorf("empty value")
+	case "t", "T", "true", "True", "TRUE":
+		return 1, nil
+	case "f", "F", "false", "False", "FALSE":
+		return 0, nil
+	}
+	switch raw[len(raw)-1] {
+	case 'i':
+		n, err := strconv.ParseInt(raw[:len(raw)-1], 10, 64)
+		return float64(n), err
+	case 'u':
+		n, err := strconv.ParseUint(raw[:len(raw)-1], 10, 64)
+		return float64(n), err
+	}
+	return strconv.ParseFloat(raw, 64)
+}
+
+// influxScanner 逐字符扫描一行，处理反斜杠转义与字符串字段
+type influxScanner struct {
+	s   string
+	pos int
+}
+
+// until 读取到 stops 中任一未转义字符 (不消费该字符) 或行尾
+// invalid 中的字符出现在结果开头时视为语法错误
+func (sc *influxScanner) until(stops, invalid string) (string, error) {
+	start := sc.pos
+	for sc.pos < len(sc.s) {
+		c := sc.s[sc.pos]
+		if c == '\\' && sc.pos+1 < len(sc.s) {
+			sc.pos += 2
+			continue
+		}
+		if strings.IndexByte(stops, c) >= 0 {
+			break
+		}
+		sc.pos++
+	}
+	out := sc.s[start:sc.pos]
+	if out == "" && sc.pos < len(sc.s) && strings.IndexByte(invalid, sc.s[sc.pos]) >= 0 {
+		return "", fmt.Errorf("unexpected %q at column %d", sc.s[sc.pos], sc.pos+1)
+	}
+	return out, nil
+}
+
+// quoted 跳过双引号字符串字段值
+func (sc *influxScanner) quoted() error {
+	start := sc.pos
+	sc.pos++
+	for sc.pos < len(sc.s) {
+		switch sc.s[sc.pos] {
+		case '\\':
+			sc.pos += 2
+			continue
+		case '"':
+			sc.pos++
+			return nil
+		}
+		sc.pos++
+	}
+	return fmt.Errorf("unterminated string starting at column %d", start+1)
+}
+
+// skip 当前字符为 c 时消费并返回 true
+func (sc *influxScanner) skip(c byte) bool {
+	if sc.pos < len(sc.s) && sc.s[sc.pos] == c {
+		sc.pos++
+		return true
+	}
+	return false
+}
+
+// peek 返回当前字符，行尾返回 0
+func (sc *influxScanner) peek() byte {
+	if sc.pos < len(sc.s) {
+		return sc.s[sc.pos]
+	}
+	return 0
+}
+
+// rest 返回剩余内容
+func (sc *influxScanner) rest() string {
+	return sc.s[sc.pos:]
+}
+
+// splitEscaped 按未转义的 sep 切分
+func splitEscaped(s string, sep byte) []string {
+	var parts []string
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case sep:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// cutEscaped 按第一个未转义的 sep 切分
+func cutEscaped(s string, sep byte) (string, string, bool) {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case sep:
+			return s[:i], s[i+1:], true
+		}
+	}
+	return s, "", false
+}
+
+// influxUnescaper 去掉逗号、等号、空格与反斜杠前的转义
+var influxUnescaper = strings.NewReplacer(`\,`, ",", `\=`, "=", `\ `, " ", `\\`, `\`)
+
+// unescapeInflux 去除转义
+func unescapeInflux(s string) string {
+	if strings.IndexByte(s, '\\') < 0 {
+		return s
+	}
+	return influxUnescaper.Replace(s)
+}
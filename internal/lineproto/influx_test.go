@@ -0,0 +1,76 @@
+package lineproto
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestParseInfluxLine 验证转义、字段类型、时间戳与错误位置
+func TestParseInfluxLine(t *testing.T) {
+	p, err := ParseInfluxLine(`cpu\ load,host=a\,b,region=us\=west idle=1.5,busy=3i,up=t,msg="x, y=z" 1700000000000000000`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Measurement != "cpu load" || len(p.Tags) != 2 || p.Tags[0].Value != "a,b" || p.Tags[1].Value != "us=west" {
+		t.Errorf("measurement/tags = %q %+v", p.Measurement, p.Tags)
+	}
+	want := []Field{{"idle", 1.5, true}, {"busy", 3, true}, {"up", 1, true}, {"msg", 0, false}}
+	if len(p.Fields) != len(want) {
+		t.Fatalf("fields = %+v", p.Fields)
+	}
+	for i, f := range want {
+		if p.Fields[i] != f {
+			t.Errorf("field %d = %+v, want %+v", i, p.Fields[i], f)
+		}
+	}
+	if !p.HasTimestamp || p.Timestamp != 1700000000000000000 {
+		t.Errorf("timestamp = %d", p.Timestamp)
+	}
+
+	for _, line := range []string{"", "   ", "# comment"} {
+		if p, err := ParseInfluxLine(line); p != nil || err != nil {
+			t.Errorf("ParseInfluxLine(%q) = %v, %v, want nil", line, p, err)
+		}
+	}
+
+	bad := map[string]string{
+		"cpu":                   "missing fields",
+		",host=a value=1":       "missing measurement",
+		"cpu,host value=1":      "invalid tag",
+		"cpu value=abc":         `field "value"`,
+		"cpu value=1 12x":       "invalid timestamp",
+		`cpu msg="unterminated`: "unterminated string",
+		"cpu =1":                "column 5",
+	}
+	for line, msg := range bad {
+		if _, err := ParseInfluxLine(line); err == nil || !strings.Contains(err.Error(), msg) {
+			t.Errorf("ParseInfluxLine(%q) error = %v, want %q", line, err, msg)
+		}
+	}
+}
+
+// TestInfluxRows 验证转换为 JSON Line
+func TestInfluxRows(t *testing.T) {
+	p, err := ParseInfluxLine(`cpu,host=a usage=0.5,msg="skip",idle=2 1700000000`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := p.Rows(".", "telegraf", "s", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []byte
+	for i := range rows {
+		got = rows[i].AppendJSON(got)
+	}
+	want := `{"metric":{"__name__":"cpu.usage","db":"telegraf","host":"a"},"values":[0.5],"timestamps":[1700000000000]}
+{"metric":{"__name__":"cpu.idle","db":"telegraf","host":"a"},"values":[2],"timestamps":[1700000000000]}
+`
+	if string(got) != want {
+		t.Errorf("rows =\n%s\nwant\n%s", got, want)
+	}
+
+	if _, err := InfluxTimestampMillis(1, "d"); err == nil {
+		t.Error("InfluxTimestampMillis 应拒绝不支持的精度")
+	}
+}
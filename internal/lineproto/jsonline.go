@@ -0,0 +1,94 @@
+package lineproto
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Row VictoriaMetrics JSON Line 格式的一行 (/api/v1/import 与 /api/v1/export)
+type Row struct {
+	Metric     map[string]string `json:"metric"`
+	Values     []float64         `json:"values"`
+	Timestamps []int64           `json:"timestamps"` // 毫秒
+}
+
+// AppendJSON 将 Row 序列化为一行 JSON (含换行符) 追加到 dst
+// 非有限值与 /api/v1/export 一致: NaN (含 staleness 标记) 写为 null，±Inf 写为 "Infinity"/"-Infinity"
+func (r *Row) AppendJSON(dst []byte) []byte {
+	metric, _ := json.Marshal(r.Metric) // map[string]string 不会序列化失败
+	dst = append(dst, `{"metric":`...)
+	dst = append(dst, metric...)
+
+	dst = append(dst, `,"values":[`...)
+	for i, v := range r.Values {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = appendJSONFloat(dst, v)
+	}
+
+	dst = append(dst, `],"timestamps":[`...)
+	for i, ts := range r.Timestamps {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = strconv.AppendInt(dst, ts, 10)
+	}
+	return append(dst, "]}\n"...)
+}
+
+// appendJSONFloat 以 VictoriaMetrics JSON Line 的方式追加一个值
+func appendJSONFloat(dst []byte, v float64) []byte {
+	switch {
+	case math.IsNaN(v):
+		return append(dst, "null"...)
+	case math.IsInf(v, 1):
+		return append(dst, `"Infinity"`...)
+	case math.IsInf(v, -1):
+		return append(dst, `"-Infinity"`...)
+	}
+	return strconv.AppendFloat(dst, v, 'g', -1, 64)
+}
+
+// UnmarshalJSONLine 解析一行 JSON Line，支持 export 输出的 null 与 "Infinity"/"-Infinity" 值
+func UnmarshalJSONLine(data []byte, row *Row) error {
+	var raw struct {
+		Metric     map[string]string `json:"metric"`
+		Values     []jsonFloat       `json:"values"`
+		Timestamps []int64           `json:"timestamps"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	row.Metric = raw.Metric
+	row.Values = make([]float64, len(raw.Values))
+	for i, v := range raw.Values {
+		row.Values[i] = float64(v)
+	}
+	row.Timestamps = raw.Timestamps
+	return nil
+}
+
+// jsonFloat JSON Line 中的值: 数值、null (NaN) 或 "Infinity"/"-Infinity"/"NaN"
+type jsonFloat float64
+
+// UnmarshalJSON 实现 json.Unmarshaler
+func (f *jsonFloat) UnmarshalJSON(data []byte) error {
+	switch s := string(data); s {
+	case "null", `"NaN"`:
+		*f = jsonFloat(math.NaN())
+	case `"Infinity"`, `"+Infinity"`:
+		*f = jsonFloat(math.Inf(1))
+	case `"-Infinity"`:
+		*f = jsonFloat(math.Inf(-1))
+	default:
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return fmt.Errorf("invalid value %s", s)
+		}
+		*f = jsonFloat(v)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package lineproto
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// OpenTSDBPoint OpenTSDB /api/put 的一个数据点
+type OpenTSDBPoint struct {
+	Metric    string            `json:"metric"`
+	Timestamp int64             `json:"timestamp"` // 秒或毫秒
+	Value     json.Number       `json:"value"`     // 数值或数值字符串
+	Tags      map[string]string `json:"tags"`
+}
+
+// openTSDBMillisThreshold 大于该值的时间戳视为毫秒 (与 OpenTSDB 一致)
+const openTSDBMillisThreshold = 1 << 32
+
+// Row 校验数据点并转换为 JSON Line；没有时间戳时使用 now (毫秒)
+func (p *OpenTSDBPoint) Row(now int64) (Row, error) {
+	if p.Metric == "" {
+		return Row{}, fmt.Errorf("missing metric")
+	}
+	v, err := strconv.ParseFloat(p.Value.String(), 64)
+	if err != nil {
+		return Row{}, fmt.Errorf("invalid value %q for metric %s", p.Value, p.Metric)
+	}
+
+	ts := p.Timestamp
+	switch {
+	case ts <= 0:
+		ts = now
+	case ts < openTSDBMillisThreshold:
+		ts *= 1000
+	}
+
+	metric := make(map[string]string, len(p.Tags)+1)
+	for k, v := range p.Tags {
+		metric[k] = v
+	}
+	metric["__name__"] = p.Metric
+	return Row{Metric: metric, Values: []float64{v}, Timestamps: []int64{ts}}, nil
+}
+
+// DecodeOpenTSDB 解析 OpenTSDB JSON put 负载: 单个对象、对象数组，或多个这样的值首尾相接
+// 每个数据点调用一次 fn，fn 返回错误时停止；解析错误带有数据点序号 (从 1 开始)
+func DecodeOpenTSDB(r io.Reader, fn func(p *OpenTSDBPoint) error) error {
+	dec := json.NewDecoder(bufio.NewReader(r))
+	dec.UseNumber()
+	n := 0
+	for {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("point %d: %w", n+1, err)
+		}
+
+		if len(raw) == 0 || raw[0] != '[' {
+			n++
+			var p OpenTSDBPoint
+			if err := json.Unmarshal(raw, &p); err != nil {
+				return fmt.Errorf("point %d: %w", n, err)
+			}
+			if err := fn(&p); err != nil {
+				return fmt.Errorf("point %d: %w", n, err)
+			}
+			continue
+		}
+
+		// 数组逐个元素解析，以便错误带有准确的序号
+		elems := json.NewDecoder(bytes.NewReader(raw))
+		_, _ = elems.Token() // [
+		for elems.More() {
+			n++
+			var p OpenTSDBPoint
+			if err := elems.Decode(&p); err != nil {
+				return fmt.Errorf("point %d: %w", n, err)
+			}
+			if err := fn(&p); err != nil {
+				return fmt.Errorf("point %d: %w", n, err)
+			}
+		}
+	}
+}
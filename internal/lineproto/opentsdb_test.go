@@ -0,0 +1,37 @@
+package lineproto
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestDecodeOpenTSDB 验证对象、数组与首尾相接的负载，以及秒/毫秒时间戳
+func TestDecodeOpenTSDB(t *testing.T) {
+	input := `{"metric":"sys.cpu","timestamp":1700000000,"value":18,"tags":{"host":"web01"}}
+[{"metric":"sys.mem","timestamp":1700000000123,"value":"2.5","tags":{}},{"metric":"sys.up","value":1}]`
+
+	var got []byte
+	err := DecodeOpenTSDB(strings.NewReader(input), func(p *OpenTSDBPoint) error {
+		row, err := p.Row(42)
+		got = row.AppendJSON(got)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"metric":{"__name__":"sys.cpu","host":"web01"},"values":[18],"timestamps":[1700000000000]}
+{"metric":{"__name__":"sys.mem"},"values":[2.5],"timestamps":[1700000000123]}
+{"metric":{"__name__":"sys.up"},"values":[1],"timestamps":[42]}
+`
+	if string(got) != want {
+		t.Errorf("rows =\n%s\nwant\n%s", got, want)
+	}
+
+	err = DecodeOpenTSDB(strings.NewReader(`[{"metric":"a","value":1},{"value":2}]`), func(p *OpenTSDBPoint) error {
+		_, err := p.Row(0)
+		return err
+	})
+	if err == nil || err.Error() != "point 2: missing metric" {
+		t.Errorf("err = %v, want point 2: missing metric", err)
+	}
+}
@@ -0,0 +1,164 @@
+package lineproto
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PromSample Prometheus exposition 格式的一行样本
+type PromSample struct {
+	Labels       map[string]string // 含 __name__
+	Value        float64
+	Timestamp    int64 // 毫秒
+	HasTimestamp bool
+}
+
+// ParsePromLine 解析一行 Prometheus exposition: name{k="v",...} value [timestamp_ms]
+// 也支持 {__name__="name",...} 形式；空行与 # 注释 (HELP/TYPE) 行返回 nil
+func ParsePromLine(line string) (*PromSample, error) {
+	line = strings.TrimSpace(line)
+	if line == "" || line[0] == '#' {
+		return nil, nil
+	}
+
+	s := &PromSample{Labels: map[string]string{}}
+	i := strings.IndexAny(line, "{ \t")
+	if i < 0 {
+		return nil, fmt.Errorf("missing value")
+	}
+	if i > 0 {
+		s.Labels["__name__"] = line[:i]
+	}
+	rest := line[i:]
+	if rest[0] == '{' {
+		var err error
+		if rest, err = parsePromLabels(rest[1:], s.Labels); err != nil {
+			return nil, err
+		}
+	}
+	if s.Labels["__name__"] == "" {
+		return nil, fmt.Errorf("missing metric name")
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) == 0 || len(fields) > 2 {
+		return nil, fmt.Errorf("expected \"value [timestamp]\" after series, got %q", strings.TrimSpace(rest))
+	}
+	v, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value %q", fields[0])
+	}
+	s.Value = v
+	if len(fields) == 2 {
+		ts, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timestamp %q", fields[1])
+		}
+		s.Timestamp, s.HasTimestamp = ts, true
+	}
+	return s, nil
+}
+
+// parsePromLabels 解析 { 之后的标签直到 }，返回剩余内容
+func parsePromLabels(s string, labels map[string]string) (string, error) {
+	for {
+		s = strings.TrimLeft(s, " \t")
+		if strings.HasPrefix(s, "}") {
+			return s[1:], nil
+		}
+
+		eq := strings.IndexByte(s, '=')
+		if eq <= 0 {
+			return "", fmt.Errorf("invalid label near %q: expected name=\"value\"", truncate(s, 20))
+		}
+		name := strings.TrimSpace(s[:eq])
+		s = strings.TrimLeft(s[eq+1:], " \t")
+		if !strings.HasPrefix(s, `"`) {
+			return "", fmt.Errorf("label %s: value must be quoted", name)
+		}
+
+		var b strings.Builder
+		i := 1
+		for ; i < len(s) && s[i] != '"'; i++ {
+			if s[i] == '\\' && i+1 < len(s) {
+				i++
+				switch s[i] {
+				case 'n':
+					b.WriteByte('\n')
+				default:
+					b.WriteByte(s[i])
+				}
+				continue
+			}
+			b.WriteByte(s[i])
+		}
+		if i >= len(s) {
+			return "", fmt.Errorf("label %s: unterminated value", name)
+		}
+		labels[name] = b.String()
+
+		s = strings.TrimLeft(s[i+1:], " \t")
+		switch {
+		case strings.HasPrefix(s, ","):
+			s = s[1:]
+		case strings.HasPrefix(s, "}"):
+		default:
+			return "", fmt.Errorf("label %s: expected , or } after value", name)
+		}
+	}
+}
+
+// truncate 截断过长的错误上下文
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n] + "..."
+	}
+	return s
+}
+
+// AppendPromSample 以 exposition 格式追加一行样本 (含换行符)，标签按名称排序
+func AppendPromSample(dst []byte, labels map[string]string, v float64, ts int64) []byte {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		if k != "__name__" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	dst = append(dst, labels["__name__"]...)
+	if len(keys) > 0 {
+		dst = append(dst, '{')
+		for i, k := range keys {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = append(dst, k...)
+			dst = append(dst, `="`...)
+			dst = append(dst, promLabelEscaper.Replace(labels[k])...)
+			dst = append(dst, '"')
+		}
+		dst = append(dst, '}')
+	}
+
+	dst = append(dst, ' ')
+	switch {
+	case math.IsNaN(v):
+		dst = append(dst, "NaN"...)
+	case math.IsInf(v, 1):
+		dst = append(dst, "+Inf"...)
+	case math.IsInf(v, -1):
+		dst = append(dst, "-Inf"...)
+	default:
+		dst = strconv.AppendFloat(dst, v, 'g', -1, 64)
+	}
+	dst = append(dst, ' ')
+	dst = strconv.AppendInt(dst, ts, 10)
+	return append(dst, '\n')
+}
+
+// promLabelEscaper 文本格式的标签值只转义反斜杠、双引号与换行
+var promLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
@@ -0,0 +1,161 @@
+package metricsql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Expr 语法树节点
+type Expr interface {
+	// Pos 返回节点在查询中的起始字节偏移
+	Pos() int
+}
+
+// NumberExpr 数字字面量 (时长作为数字使用时也是 NumberExpr，如 5m 表示 300)
+type NumberExpr struct {
+	Text   string // 原始文本，格式化时原样输出
+	Offset int
+}
+
+// StringExpr 字符串字面量
+type StringExpr struct {
+	Value  string // 去掉引号与转义后的值
+	Offset int
+}
+
+// LabelMatcher 标签匹配条件
+type LabelMatcher struct {
+	Label string
+	Op    string // = != =~ !~
+	Value string
+}
+
+// MetricExpr 序列选择器: name{label="value", ...}
+// MetricsQL 支持 {a="1" or b="2"} 形式的多组过滤条件，每组对应 Filters 中的一项
+type MetricExpr struct {
+	Name    string
+	Filters [][]LabelMatcher
+	Offset  int
+}
+
+// RollupExpr 带时间窗口或修饰符的表达式: expr[window:step] offset d @ t
+type RollupExpr struct {
+	Expr      Expr
+	Window    string // range 窗口，如 5m
+	Step      string // subquery 步长，仅 subquery 有效
+	Subquery  bool   // 是否为 subquery 形式 [window:step]
+	OffsetStr string // offset 时长，可以为负
+	At        Expr   // @ 修饰符
+}
+
+// FuncExpr 函数调用
+type FuncExpr struct {
+	Name            string
+	Args            []Expr
+	KeepMetricNames bool
+	Offset          int
+}
+
+// AggrExpr 聚合函数调用: sum by (job) (expr) limit 10
+type AggrExpr struct {
+	Name     string
+	Args     []Expr
+	Modifier string // by | without | ""
+	Labels   []string
+	Limit    string
+	Offset   int
+}
+
+// BinaryExpr 二元运算
+type BinaryExpr struct {
+	Op              string
+	LHS, RHS        Expr
+	Bool            bool
+	GroupModifier   string // on | ignoring | ""
+	GroupLabels     []string
+	JoinModifier    string // group_left | group_right | ""
+	JoinLabels      []string
+	KeepMetricNames bool
+}
+
+// UnaryExpr 一元运算: -expr
+type UnaryExpr struct {
+	Op     string
+	Expr   Expr
+	Offset int
+}
+
+// ParensExpr 括号表达式，多个参数时为 MetricsQL 的 union
+type ParensExpr struct {
+	Args   []Expr
+	Offset int
+}
+
+// WithExpr MetricsQL WITH 模板: WITH (a = expr, f(x) = expr) expr
+type WithExpr struct {
+	Defs   []WithDef
+	Expr   Expr
+	Offset int
+}
+
+// WithDef WITH 模板中的一个定义
+type WithDef struct {
+	Name string
+	Args []string
+	Expr Expr
+}
+
+func (e *NumberExpr) Pos() int { return e.Offset }
+func (e *StringExpr) Pos() int { return e.Offset }
+func (e *MetricExpr) Pos() int { return e.Offset }
+func (e *RollupExpr) Pos() int { return e.Expr.Pos() }
+func (e *FuncExpr) Pos() int   { return e.Offset }
+func (e *AggrExpr) Pos() int   { return e.Offset }
+func (e *BinaryExpr) Pos() int { return e.LHS.Pos() }
+func (e *UnaryExpr) Pos() int  { return e.Offset }
+func (e *ParensExpr) Pos() int { return e.Offset }
+func (e *WithExpr) Pos() int   { return e.Offset }
+
+// Error 解析错误，Pos 为出错位置的字节偏移
+type Error struct {
+	Pos int
+	Msg string
+}
+
+// Error 实现 error 接口
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Msg, e.Pos)
+}
+
+// Location 返回偏移 pos 在 query 中的行号与列号 (均从 1 开始，列按字符计)
+func Location(query string, pos int) (line, col int) {
+	if pos > len(query) {
+		pos = len(query)
+	}
+	before := query[:pos]
+	line = strings.Count(before, "\n") + 1
+	if i := strings.LastIndexByte(before, '\n'); i >= 0 {
+		before = before[i+1:]
+	}
+	return line, len([]rune(before)) + 1
+}
+
+// Caret 返回 pos 所在的查询行及其下方指向该列的 ^ 标记 (两行，无结尾换行)
+func Caret(query string, pos int) string {
+	line, col := Location(query, pos)
+	text := strings.Split(query, "\n")[line-1]
+
+	// 保留制表符，保证 ^ 与终端显示对齐
+	var pad strings.Builder
+	for i, r := range []rune(text) {
+		if i >= col-1 {
+			break
+		}
+		if r == '\t' {
+			pad.WriteRune('\t')
+		} else {
+			pad.WriteRune(' ')
+		}
+	}
+	return text + "\n" + pad.String() + "^"
+}
@@ -0,0 +1,221 @@
+package metricsql
+
+import (
+	"strconv"
+	"strings"
+)
+
+// indentUnit 多行格式化时每层的缩进
+const indentUnit = "  "
+
+// Format 将表达式格式化为单行规范形式
+func Format(e Expr) string {
+	var b strings.Builder
+	format(&b, e)
+	return b.String()
+}
+
+// Prettify 格式化表达式，单行超过 width 时按结构拆分为多行
+func Prettify(e Expr, width int) string {
+	var b strings.Builder
+	prettify(&b, e, 0, width)
+	return b.String()
+}
+
+func format(b *strings.Builder, e Expr) {
+	switch e := e.(type) {
+	case *NumberExpr:
+		b.WriteString(e.Text)
+	case *StringExpr:
+		b.WriteString(strconv.Quote(e.Value))
+	case *MetricExpr:
+		b.WriteString(e.Name)
+		if len(e.Filters) > 0 {
+			b.WriteByte('{')
+			for i, group := range e.Filters {
+				if i > 0 {
+					b.WriteString(" or ")
+				}
+				for j, m := range group {
+					if j > 0 {
+						b.WriteString(", ")
+					}
+					b.WriteString(m.Label)
+					b.WriteString(m.Op)
+					b.WriteString(strconv.Quote(m.Value))
+				}
+			}
+			b.WriteByte('}')
+		}
+	case *RollupExpr:
+		format(b, e.Expr)
+		b.WriteString(rollupSuffix(e))
+	case *FuncExpr:
+		b.WriteString(e.Name)
+		formatArgs(b, e.Args)
+		if e.KeepMetricNames {
+			b.WriteString(" keep_metric_names")
+		}
+	case *AggrExpr:
+		b.WriteString(aggrPrefix(e))
+		formatArgs(b, e.Args)
+		if e.Limit != "" {
+			b.WriteString(" limit " + e.Limit)
+		}
+	case *BinaryExpr:
+		format(b, e.LHS)
+		b.WriteString(" " + binaryOp(e) + " ")
+		format(b, e.RHS)
+		if e.KeepMetricNames {
+			b.WriteString(" keep_metric_names")
+		}
+	case *UnaryExpr:
+		b.WriteString(e.Op)
+		format(b, e.Expr)
+	case *ParensExpr:
+		formatArgs(b, e.Args)
+	case *WithExpr:
+		b.WriteString("WITH (")
+		for i, def := range e.Defs {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(withDefName(def) + " = ")
+			format(b, def.Expr)
+		}
+		b.WriteString(") ")
+		format(b, e.Expr)
+	}
+}
+
+func formatArgs(b *strings.Builder, args []Expr) {
+	b.WriteByte('(')
+	for i, arg := range args {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		format(b, arg)
+	}
+	b.WriteByte(')')
+}
+
+// rollupSuffix 返回 [window:step] offset d @ t 部分
+func rollupSuffix(e *RollupExpr) string {
+	var b strings.Builder
+	if e.Window != "" || e.Subquery {
+		b.WriteString("[" + e.Window)
+		if e.Subquery {
+			b.WriteString(":" + e.Step)
+		}
+		b.WriteByte(']')
+	}
+	if e.OffsetStr != "" {
+		b.WriteString(" offset " + e.OffsetStr)
+	}
+	if e.At != nil {
+		b.WriteString(" @ " + Format(e.At))
+	}
+	return b.String()
+}
+
+// aggrPrefix 返回聚合名及 by/without 修饰，如 "sum by (job) "
+func aggrPrefix(e *AggrExpr) string {
+	if e.Modifier == "" {
+		return e.Name
+	}
+	return e.Name + " " + e.Modifier + " (" + strings.Join(e.Labels, ", ") + ") "
+}
+
+// binaryOp 返回运算符及其修饰，如 "/ on (job) group_left (team)"
+func binaryOp(e *BinaryExpr) string {
+	op := e.Op
+	if e.Bool {
+		op += " bool"
+	}
+	if e.GroupModifier != "" {
+		op += " " + e.GroupModifier + " (" + strings.Join(e.GroupLabels, ", ") + ")"
+	}
+	if e.JoinModifier != "" {
+		op += " " + e.JoinModifier
+		if len(e.JoinLabels) > 0 {
+			op += " (" + strings.Join(e.JoinLabels, ", ") + ")"
+		}
+	}
+	return op
+}
+
+func withDefName(def WithDef) string {
+	if len(def.Args) == 0 {
+		return def.Name
+	}
+	return def.Name + "(" + strings.Join(def.Args, ", ") + ")"
+}
+
+// prettify 写入以 depth 层缩进开头的表达式，放不下一行时递归拆分
+func prettify(b *strings.Builder, e Expr, depth, width int) {
+	indent := strings.Repeat(indentUnit, depth)
+	single := Format(e)
+	if len(indent)+len(single) <= width {
+		b.WriteString(indent + single)
+		return
+	}
+
+	switch e := e.(type) {
+	case *BinaryExpr:
+		prettify(b, e.LHS, depth, width)
+		b.WriteString("\n" + indent + binaryOp(e) + "\n")
+		prettify(b, e.RHS, depth, width)
+		if e.KeepMetricNames {
+			b.WriteString(" keep_metric_names")
+		}
+	case *RollupExpr:
+		prettify(b, e.Expr, depth, width)
+		b.WriteString(rollupSuffix(e))
+	case *FuncExpr:
+		b.WriteString(indent + e.Name)
+		prettifyArgs(b, e.Args, depth, width)
+		if e.KeepMetricNames {
+			b.WriteString(" keep_metric_names")
+		}
+	case *AggrExpr:
+		b.WriteString(indent + aggrPrefix(e))
+		prettifyArgs(b, e.Args, depth, width)
+		if e.Limit != "" {
+			b.WriteString(" limit " + e.Limit)
+		}
+	case *UnaryExpr:
+		b.WriteString(indent + e.Op + "\n")
+		prettify(b, e.Expr, depth, width)
+	case *ParensExpr:
+		b.WriteString(indent)
+		prettifyArgs(b, e.Args, depth, width)
+	case *WithExpr:
+		b.WriteString(indent + "WITH (\n")
+		for i, def := range e.Defs {
+			inner := strings.Repeat(indentUnit, depth+1)
+			b.WriteString(inner + withDefName(def) + " =\n")
+			prettify(b, def.Expr, depth+2, width)
+			if i < len(e.Defs)-1 {
+				b.WriteByte(',')
+			}
+			b.WriteByte('\n')
+		}
+		b.WriteString(indent + ")\n")
+		prettify(b, e.Expr, depth, width)
+	default:
+		b.WriteString(indent + single)
+	}
+}
+
+// prettifyArgs 写入 (\n  arg,\n  arg\n)，括号紧跟在当前行末尾
+func prettifyArgs(b *strings.Builder, args []Expr, depth, width int) {
+	b.WriteString("(\n")
+	for i, arg := range args {
+		prettify(b, arg, depth+1, width)
+		if i < len(args)-1 {
+			b.WriteByte(',')
+		}
+		b.WriteByte('\n')
+	}
+	b.WriteString(strings.Repeat(indentUnit, depth) + ")")
+}
@@ -0,0 +1,254 @@
+// Package metricsql 提供离线的 PromQL/MetricsQL 语法分析、格式化与常见错误检查
+//
+// 解析器覆盖日常查询使用的语法：选择器、range/subquery、offset/@、函数、
+// 聚合 (by/without/limit)、二元运算及 on/ignoring/group_left/group_right/bool 修饰、
+// keep_metric_names、union (a, b) 以及 WITH 模板。
+package metricsql
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// tokenKind 词法单元类型
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenNumber
+	tokenDuration
+	tokenString
+	tokenOp    // 运算符与比较符: + - * / % ^ == != > < >= <= =~ !~ =
+	tokenPunct // 标点: ( ) { } [ ] , : @
+)
+
+// token 词法单元
+type token struct {
+	kind tokenKind
+	text string // 原始文本
+	pos  int    // 在查询中的字节偏移
+}
+
+// String 返回用于错误信息的描述
+func (t token) String() string {
+	if t.kind == tokenEOF {
+		return "end of input"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// durationUnits 时长单位，i 表示 step 的倍数 (MetricsQL)
+var durationUnits = []string{"ms", "s", "m", "h", "d", "w", "y", "i"}
+
+// lex 将查询切分为词法单元，忽略空白与 # 注释
+func lex(query string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(query) {
+		c := query[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '#':
+			for i < len(query) && query[i] != '\n' {
+				i++
+			}
+		case isIdentStart(c) && (c != ':' || startsName(query[i+1:])):
+			start := i
+			for i < len(query) && isIdentChar(query[i]) {
+				// Grafana 变量 ($__interval) 后的 ':' 属于 subquery 语法
+				if query[i] == ':' && c == '$' {
+					break
+				}
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: query[start:i], pos: start})
+		case isDigit(c) || (c == '.' && i+1 < len(query) && isDigit(query[i+1])):
+			tok, err := lexNumber(query, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, tok)
+			i += len(tok.text)
+		case c == '"' || c == '\'' || c == '`':
+			end, err := scanString(query, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenString, text: query[i:end], pos: i})
+			i = end
+		default:
+			if op := matchOp(query[i:]); op != "" {
+				tokens = append(tokens, token{kind: tokenOp, text: op, pos: i})
+				i += len(op)
+				continue
+			}
+			if strings.IndexByte("(){}[],:@", c) >= 0 {
+				tokens = append(tokens, token{kind: tokenPunct, text: string(c), pos: i})
+				i++
+				continue
+			}
+			r, _ := utf8.DecodeRuneInString(query[i:])
+			return nil, &Error{Pos: i, Msg: fmt.Sprintf("unexpected character %q", r)}
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(query)}), nil
+}
+
+// lexNumber 解析数字或时长
+// 支持十进制、科学计数法、十六进制、K/M/G/T 与 Ki/Mi/Gi/Ti 后缀，以及 5m、1h30m 形式的时长
+func lexNumber(query string, start int) (token, error) {
+	i := start
+	if strings.HasPrefix(query[i:], "0x") || strings.HasPrefix(query[i:], "0X") {
+		i += 2
+		for i < len(query) && isHexDigit(query[i]) {
+			i++
+		}
+		return token{kind: tokenNumber, text: query[start:i], pos: start}, nil
+	}
+
+	i = scanDecimal(query, i)
+
+	// 时长: 数字后紧跟时间单位，可以由多段组成 (1h30m)
+	if unit := matchDurationUnit(query[i:]); unit != "" {
+		for unit != "" {
+			i += len(unit)
+			if i >= len(query) || !(isDigit(query[i]) || query[i] == '.') {
+				break
+			}
+			next := scanDecimal(query, i)
+			unit = matchDurationUnit(query[next:])
+			if unit == "" {
+				return token{}, &Error{Pos: i, Msg: "invalid duration"}
+			}
+			i = next
+		}
+		if i < len(query) && query[i] != ':' && isIdentChar(query[i]) {
+			return token{}, &Error{Pos: start, Msg: fmt.Sprintf("invalid duration %q", query[start:identEnd(query, i)])}
+		}
+		return token{kind: tokenDuration, text: query[start:i], pos: start}, nil
+	}
+
+	// 科学计数法
+	if i < len(query) && (query[i] == 'e' || query[i] == 'E') {
+		j := i + 1
+		if j < len(query) && (query[j] == '+' || query[j] == '-') {
+			j++
+		}
+		if j < len(query) && isDigit(query[j]) {
+			for j < len(query) && isDigit(query[j]) {
+				j++
+			}
+			i = j
+		}
+	}
+
+	// 数量级后缀
+	for _, suffix := range []string{"Ki", "Mi", "Gi", "Ti", "K", "M", "G", "T"} {
+		if strings.HasPrefix(query[i:], suffix) {
+			i += len(suffix)
+			break
+		}
+	}
+
+	if i < len(query) && query[i] != ':' && isIdentChar(query[i]) {
+		return token{}, &Error{Pos: start, Msg: fmt.Sprintf("invalid number %q", query[start:identEnd(query, i)])}
+	}
+	return token{kind: tokenNumber, text: query[start:i], pos: start}, nil
+}
+
+// scanDecimal 扫描十进制数字 (可带小数点)，返回结束位置
+func scanDecimal(query string, i int) int {
+	for i < len(query) && isDigit(query[i]) {
+		i++
+	}
+	if i < len(query) && query[i] == '.' {
+		i++
+		for i < len(query) && isDigit(query[i]) {
+			i++
+		}
+	}
+	return i
+}
+
+// matchDurationUnit 匹配开头的时间单位，单位后不能紧跟字母 (避免把 1min 识别为 1m)
+func matchDurationUnit(s string) string {
+	for _, unit := range durationUnits {
+		if strings.HasPrefix(s, unit) {
+			rest := s[len(unit):]
+			if rest != "" && unicode.IsLetter(rune(rest[0])) {
+				continue
+			}
+			return unit
+		}
+	}
+	return ""
+}
+
+// scanString 扫描字符串字面量，返回结束位置 (不含)
+func scanString(query string, start int) (int, error) {
+	quote := query[start]
+	i := start + 1
+	for i < len(query) {
+		switch query[i] {
+		case '\\':
+			if quote != '`' {
+				i += 2
+				continue
+			}
+		case quote:
+			return i + 1, nil
+		case '\n':
+			if quote != '`' {
+				return 0, &Error{Pos: start, Msg: "unterminated string"}
+			}
+		}
+		i++
+	}
+	return 0, &Error{Pos: start, Msg: "unterminated string"}
+}
+
+// operators 按长度降序排列，保证最长匹配
+var operators = []string{"==", "!=", ">=", "<=", "=~", "!~", "+", "-", "*", "/", "%", "^", ">", "<", "="}
+
+// matchOp 匹配开头的运算符
+func matchOp(s string) string {
+	for _, op := range operators {
+		if strings.HasPrefix(s, op) {
+			return op
+		}
+	}
+	return ""
+}
+
+// identEnd 返回从 i 开始的标识符结束位置
+func identEnd(query string, i int) int {
+	for i < len(query) && isIdentChar(query[i]) {
+		i++
+	}
+	return i
+}
+
+// startsName 判断 ':' 之后是否为名称的剩余部分 (如 :node_cpu:rate5m)，否则 ':' 为 subquery 分隔符
+func startsName(rest string) bool {
+	return rest != "" && (rest[0] == '_' || rest[0] == ':' || (rest[0] >= 'a' && rest[0] <= 'z') || (rest[0] >= 'A' && rest[0] <= 'Z'))
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c == '$' || c == ':' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || isDigit(c) || c == '.'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isHexDigit(c byte) bool {
+	return isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
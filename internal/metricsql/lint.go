@@ -0,0 +1,207 @@
+package metricsql
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Warning 语法正确但可能有误的写法
+type Warning struct {
+	Pos int
+	Msg string
+}
+
+// rollupFuncs 需要 range 窗口的函数 (*_over_time 与 rollup* 按前后缀判断)
+var rollupFuncs = map[string]bool{
+	"rate": true, "irate": true, "increase": true, "increase_pure": true, "increase_prometheus": true,
+	"delta": true, "idelta": true, "delta_prometheus": true, "deriv": true, "deriv_fast": true, "ideriv": true,
+	"changes": true, "changes_prometheus": true, "resets": true, "predict_linear": true,
+	"holt_winters": true, "double_exponential_smoothing": true, "lag": true, "lifetime": true,
+	"increases_over_time": true, "decreases_over_time": true, "default_rollup": true,
+	"timestamp_with_name": true, "tfirst_over_time": true, "tlast_change_over_time": true,
+	"tmin_over_time": true, "tmax_over_time": true, "scrape_interval": true,
+}
+
+// counterFuncs 只适用于 counter 的函数
+var counterFuncs = map[string]bool{
+	"rate": true, "irate": true, "increase": true, "increase_pure": true, "increase_prometheus": true,
+}
+
+// gaugeFuncs 只适用于 gauge 的函数，作用于 counter 时应改用 rate/increase
+var gaugeFuncs = map[string]bool{
+	"delta": true, "idelta": true, "deriv": true, "predict_linear": true,
+}
+
+// counterSuffixes 符合 counter 命名约定的指标后缀
+var counterSuffixes = []string{"_total", "_count", "_sum", "_bucket"}
+
+// IsRollupFunc 是否为需要 range 窗口的函数
+func IsRollupFunc(name string) bool {
+	name = strings.ToLower(name)
+	return rollupFuncs[name] || strings.HasSuffix(name, "_over_time") || strings.HasPrefix(name, "rollup")
+}
+
+// Lint 检查常见错误写法，按位置排序返回
+func Lint(e Expr) []Warning {
+	l := &linter{}
+	l.walk(e, nil)
+	sort.SliceStable(l.warnings, func(i, j int) bool {
+		return l.warnings[i].Pos < l.warnings[j].Pos
+	})
+	return l.warnings
+}
+
+type linter struct {
+	warnings  []Warning
+	templates map[string]bool // WITH 定义的模板名及参数名，不按指标名检查
+	inWithDef bool            // 正在检查 WITH 模板定义，range 窗口可能在展开后才落入 rollup 函数
+}
+
+func (l *linter) warnf(pos int, format string, args ...any) {
+	l.warnings = append(l.warnings, Warning{Pos: pos, Msg: fmt.Sprintf(format, args...)})
+}
+
+// walk 遍历语法树，parent 为直接包含该节点的函数调用 (没有时为 nil)
+func (l *linter) walk(e Expr, parent *FuncExpr) {
+	switch e := e.(type) {
+	case *FuncExpr:
+		if IsRollupFunc(e.Name) {
+			l.checkRollupFunc(e)
+		}
+		for _, arg := range e.Args {
+			l.walk(arg, e)
+		}
+	case *RollupExpr:
+		l.checkRange(e, parent)
+		l.walk(e.Expr, nil)
+	case *AggrExpr:
+		for _, arg := range e.Args {
+			l.walk(arg, nil)
+		}
+	case *BinaryExpr:
+		l.walk(e.LHS, nil)
+		l.walk(e.RHS, nil)
+	case *UnaryExpr:
+		l.walk(e.Expr, nil)
+	case *ParensExpr:
+		for _, arg := range e.Args {
+			l.walk(arg, nil)
+		}
+	case *WithExpr:
+		if l.templates == nil {
+			l.templates = make(map[string]bool)
+		}
+		for _, def := range e.Defs {
+			l.templates[def.Name] = true
+			for _, arg := range def.Args {
+				l.templates[arg] = true
+			}
+		}
+		l.inWithDef = true
+		for _, def := range e.Defs {
+			l.walk(def.Expr, nil)
+		}
+		l.inWithDef = false
+		l.walk(e.Expr, nil)
+	}
+}
+
+// checkRollupFunc 检查 rollup 函数的序列参数
+func (l *linter) checkRollupFunc(fe *FuncExpr) {
+	arg := seriesArg(fe)
+	if arg == nil {
+		return
+	}
+
+	var re *RollupExpr
+	switch a := arg.(type) {
+	case *MetricExpr:
+		if l.templates[a.Name] {
+			return
+		}
+		l.warnf(a.Pos(), "%s() without range selector: MetricsQL picks the window automatically, Prometheus rejects it; use e.g. %s[5m]", fe.Name, Format(a))
+		l.checkCounterName(fe, a)
+		return
+	case *RollupExpr:
+		re = a
+	default:
+		return
+	}
+
+	if re.Window == "" && !re.Subquery {
+		l.warnf(re.Pos(), "%s() without range selector: MetricsQL picks the window automatically, Prometheus rejects it", fe.Name)
+	}
+	switch inner := re.Expr.(type) {
+	case *MetricExpr:
+		l.checkCounterName(fe, inner)
+	case *AggrExpr:
+		if counterFuncs[fe.Name] && re.Subquery {
+			l.warnf(inner.Pos(), "%s() over %s(): aggregate after %s(), e.g. %s(%s(...))", fe.Name, inner.Name, fe.Name, inner.Name, fe.Name)
+		}
+	}
+}
+
+// checkCounterName 根据指标名后缀检查 counter/gauge 函数的使用
+func (l *linter) checkCounterName(fe *FuncExpr, me *MetricExpr) {
+	name := metricName(me)
+	// 记录规则产生的指标 (level:metric:operations) 不遵循后缀约定
+	if name == "" || strings.Contains(name, ":") || l.templates[name] {
+		return
+	}
+	isCounter := false
+	for _, suffix := range counterSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			isCounter = true
+			break
+		}
+	}
+
+	switch {
+	case counterFuncs[fe.Name] && !isCounter:
+		l.warnf(me.Pos(), "%s() on %q which does not look like a counter (no _total suffix); for gauges use deriv() or delta()", fe.Name, name)
+	case gaugeFuncs[fe.Name] && isCounter:
+		l.warnf(me.Pos(), "%s() on counter %q; use rate() or increase() instead", fe.Name, name)
+	}
+}
+
+// checkRange 检查 range 窗口的位置
+func (l *linter) checkRange(re *RollupExpr, parent *FuncExpr) {
+	if re.Window == "" || re.Subquery {
+		return
+	}
+	if _, ok := re.Expr.(*MetricExpr); !ok {
+		l.warnf(re.Pos(), "range selector [%s] applied to an expression; use subquery syntax [%s:]", re.Window, re.Window)
+		return
+	}
+	if !l.inWithDef && (parent == nil || !IsRollupFunc(parent.Name)) {
+		l.warnf(re.Pos(), "range selector [%s] outside a rollup function: MetricsQL wraps it in default_rollup(), Prometheus rejects it", re.Window)
+	}
+}
+
+// seriesArg 返回 rollup 函数中作为序列输入的参数，即第一个选择器或 rollup 参数
+// (quantile_over_time 等函数的标量参数在前)
+func seriesArg(fe *FuncExpr) Expr {
+	for _, arg := range fe.Args {
+		switch arg.(type) {
+		case *MetricExpr, *RollupExpr:
+			return arg
+		}
+	}
+	return nil
+}
+
+// metricName 返回选择器的指标名，名称写在 {__name__="..."} 中时也能识别
+func metricName(me *MetricExpr) string {
+	if me.Name != "" {
+		return me.Name
+	}
+	if len(me.Filters) == 1 {
+		for _, m := range me.Filters[0] {
+			if m.Label == "__name__" && m.Op == "=" {
+				return m.Value
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,687 @@
+package metricsql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// aggrFuncs 聚合函数 (支持 by/without 与 limit 修饰)
+var aggrFuncs = map[string]bool{
+	"sum": true, "min": true, "max": true, "avg": true, "count": true,
+	"stddev": true, "stdvar": true, "group": true, "count_values": true,
+	"topk": true, "bottomk": true, "quantile": true, "quantiles": true,
+	"limitk": true, "any": true, "median": true, "mode": true, "distinct": true,
+	"geomean": true, "histogram": true, "mad": true, "share": true, "sum2": true,
+	"zscore": true, "outliersk": true, "outliers_iqr": true, "outliers_mad": true,
+	"topk_min": true, "topk_max": true, "topk_avg": true, "topk_median": true, "topk_last": true,
+	"bottomk_min": true, "bottomk_max": true, "bottomk_avg": true, "bottomk_median": true, "bottomk_last": true,
+}
+
+// binaryOpPriority 二元运算符优先级，数值越大结合越紧
+var binaryOpPriority = map[string]int{
+	"default": 1, "if": 1, "ifnot": 1,
+	"or":  2,
+	"and": 3, "unless": 3,
+	"==": 4, "!=": 4, ">": 4, "<": 4, ">=": 4, "<=": 4,
+	"+": 5, "-": 5,
+	"*": 6, "/": 6, "%": 6, "atan2": 6,
+	"^": 7,
+}
+
+// IsAggrFunc 是否为聚合函数
+func IsAggrFunc(name string) bool {
+	return aggrFuncs[strings.ToLower(name)]
+}
+
+// Parse 解析查询表达式，失败时返回 *Error
+func Parse(query string) (Expr, error) {
+	tokens, err := lex(query)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	expr, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, p.errorf(tok, "unexpected %s after expression", tok)
+	}
+	return expr, nil
+}
+
+// parser 递归下降解析器
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// is 判断当前词法单元是否为指定标点/运算符
+func (p *parser) is(text string) bool {
+	tok := p.peek()
+	return (tok.kind == tokenPunct || tok.kind == tokenOp) && tok.text == text
+}
+
+// isKeyword 判断当前词法单元是否为指定关键字 (大小写不敏感)
+func (p *parser) isKeyword(keyword string) bool {
+	tok := p.peek()
+	return tok.kind == tokenIdent && strings.EqualFold(tok.text, keyword)
+}
+
+// expect 消费指定标点/运算符，否则报错
+func (p *parser) expect(text string) (token, error) {
+	if !p.is(text) {
+		tok := p.peek()
+		return tok, p.errorf(tok, "expected %q, got %s", text, tok)
+	}
+	return p.next(), nil
+}
+
+func (p *parser) errorf(tok token, format string, args ...any) error {
+	return &Error{Pos: tok.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// parseExpr 解析完整表达式
+func (p *parser) parseExpr() (Expr, error) {
+	if p.isKeyword("with") && p.tokens[p.pos+1].text == "(" {
+		return p.parseWith()
+	}
+	return p.parseBinary(0)
+}
+
+// binaryOp 返回当前位置的二元运算符 (小写)，不是运算符时返回空
+func (p *parser) binaryOp() string {
+	tok := p.peek()
+	switch tok.kind {
+	case tokenOp:
+		if tok.text == "=" || tok.text == "=~" || tok.text == "!~" {
+			return ""
+		}
+		return tok.text
+	case tokenIdent:
+		op := strings.ToLower(tok.text)
+		if _, ok := binaryOpPriority[op]; ok {
+			return op
+		}
+	}
+	return ""
+}
+
+// parseBinary 按优先级爬升解析二元运算
+func (p *parser) parseBinary(minPriority int) (Expr, error) {
+	lhs, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		op := p.binaryOp()
+		priority := binaryOpPriority[op]
+		if op == "" || priority <= minPriority {
+			return lhs, nil
+		}
+		p.next()
+
+		be := &BinaryExpr{Op: op, LHS: lhs}
+		if err := p.parseBinaryModifiers(be); err != nil {
+			return nil, err
+		}
+
+		// ^ 右结合，其余左结合
+		next := priority
+		if op == "^" {
+			next = priority - 1
+		}
+		rhs, err := p.parseBinary(next)
+		if err != nil {
+			return nil, err
+		}
+		be.RHS = rhs
+		if p.isKeyword("keep_metric_names") {
+			p.next()
+			be.KeepMetricNames = true
+		}
+		lhs = be
+	}
+}
+
+// parseBinaryModifiers 解析 bool、on/ignoring、group_left/group_right 修饰符
+func (p *parser) parseBinaryModifiers(be *BinaryExpr) error {
+	if p.isKeyword("bool") {
+		tok := p.next()
+		if binaryOpPriority[be.Op] != binaryOpPriority["=="] {
+			return p.errorf(tok, "bool modifier is only allowed for comparison operators")
+		}
+		be.Bool = true
+	}
+
+	if p.isKeyword("on") || p.isKeyword("ignoring") {
+		be.GroupModifier = strings.ToLower(p.next().text)
+		labels, err := p.parseLabelList()
+		if err != nil {
+			return err
+		}
+		be.GroupLabels = labels
+	}
+
+	if p.isKeyword("group_left") || p.isKeyword("group_right") {
+		tok := p.next()
+		if be.GroupModifier == "" {
+			return p.errorf(tok, "%s requires on() or ignoring() modifier", tok.text)
+		}
+		if be.Op == "and" || be.Op == "or" || be.Op == "unless" {
+			return p.errorf(tok, "%s is not allowed for set operator %q", tok.text, be.Op)
+		}
+		be.JoinModifier = strings.ToLower(tok.text)
+		if p.is("(") {
+			labels, err := p.parseLabelList()
+			if err != nil {
+				return err
+			}
+			be.JoinLabels = labels
+		}
+	}
+	return nil
+}
+
+// parseUnary 解析一元运算，一元负号的优先级低于 ^ (-a^b 等价于 -(a^b))
+func (p *parser) parseUnary() (Expr, error) {
+	if p.is("-") || p.is("+") {
+		tok := p.next()
+		expr, err := p.parseBinary(binaryOpPriority["*"])
+		if err != nil {
+			return nil, err
+		}
+		// 负数字面量直接合并
+		if n, ok := expr.(*NumberExpr); ok && tok.text == "-" && !strings.HasPrefix(n.Text, "-") {
+			return &NumberExpr{Text: "-" + n.Text, Offset: tok.pos}, nil
+		}
+		return &UnaryExpr{Op: tok.text, Expr: expr, Offset: tok.pos}, nil
+	}
+	return p.parsePostfix()
+}
+
+// parsePostfix 解析基本表达式及其后的 [window]、offset、@ 修饰
+func (p *parser) parsePostfix() (Expr, error) {
+	expr, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	var re *RollupExpr
+	rollup := func() *RollupExpr {
+		if re == nil {
+			re = &RollupExpr{Expr: expr}
+		}
+		return re
+	}
+
+	if p.is("[") {
+		if err := p.parseWindow(rollup()); err != nil {
+			return nil, err
+		}
+	}
+
+	for {
+		switch {
+		case p.isKeyword("offset"):
+			tok := p.next()
+			if re != nil && re.OffsetStr != "" {
+				return nil, p.errorf(tok, "duplicate offset modifier")
+			}
+			d, err := p.parseDuration(true)
+			if err != nil {
+				return nil, err
+			}
+			rollup().OffsetStr = d
+		case p.is("@"):
+			tok := p.next()
+			if re != nil && re.At != nil {
+				return nil, p.errorf(tok, "duplicate @ modifier")
+			}
+			at, err := p.parseAt()
+			if err != nil {
+				return nil, err
+			}
+			rollup().At = at
+		default:
+			if re != nil {
+				return re, nil
+			}
+			return expr, nil
+		}
+	}
+}
+
+// parseAt 解析 @ 修饰符的时间: 数字 (可为负) 或 start()/end()
+func (p *parser) parseAt() (Expr, error) {
+	if p.is("-") {
+		tok := p.next()
+		num := p.peek()
+		if num.kind != tokenNumber {
+			return nil, p.errorf(num, "expected timestamp after @, got %s", num)
+		}
+		p.next()
+		return &NumberExpr{Text: "-" + num.text, Offset: tok.pos}, nil
+	}
+	tok := p.peek()
+	if tok.kind != tokenNumber && tok.kind != tokenIdent {
+		return nil, p.errorf(tok, "expected timestamp after @, got %s", tok)
+	}
+	return p.parsePrimary()
+}
+
+// parseWindow 解析 [window] 或 [window:step]
+func (p *parser) parseWindow(re *RollupExpr) error {
+	open, _ := p.expect("[")
+	if p.is("]") {
+		return p.errorf(p.peek(), "missing range duration")
+	}
+	if !p.is(":") {
+		d, err := p.parseDuration(false)
+		if err != nil {
+			return err
+		}
+		re.Window = d
+	}
+	if p.is(":") {
+		p.next()
+		re.Subquery = true
+		if !p.is("]") {
+			d, err := p.parseDuration(false)
+			if err != nil {
+				return err
+			}
+			re.Step = d
+		}
+	}
+	if !p.is("]") {
+		tok := p.peek()
+		if tok.kind == tokenEOF {
+			return p.errorf(open, "unclosed range selector")
+		}
+		return p.errorf(tok, "expected \"]\", got %s", tok)
+	}
+	p.next()
+	return nil
+}
+
+// parseDuration 解析时长，允许纯数字 (秒) 与 MetricsQL 的 $__interval 等占位标识
+func (p *parser) parseDuration(allowNegative bool) (string, error) {
+	sign := ""
+	if allowNegative && p.is("-") {
+		p.next()
+		sign = "-"
+	}
+	tok := p.peek()
+	switch tok.kind {
+	case tokenDuration, tokenNumber:
+		p.next()
+		return sign + tok.text, nil
+	case tokenIdent:
+		if strings.HasPrefix(tok.text, "$") {
+			p.next()
+			return sign + tok.text, nil
+		}
+	}
+	return "", p.errorf(tok, "expected duration, got %s", tok)
+}
+
+// parsePrimary 解析基本表达式
+func (p *parser) parsePrimary() (Expr, error) {
+	tok := p.peek()
+	switch tok.kind {
+	case tokenNumber, tokenDuration:
+		p.next()
+		return &NumberExpr{Text: tok.text, Offset: tok.pos}, nil
+	case tokenString:
+		p.next()
+		s, err := unquote(tok.text)
+		if err != nil {
+			return nil, p.errorf(tok, "invalid string %s: %v", tok.text, err)
+		}
+		return &StringExpr{Value: s, Offset: tok.pos}, nil
+	case tokenPunct:
+		switch tok.text {
+		case "(":
+			args, err := p.parseArgs()
+			if err != nil {
+				return nil, err
+			}
+			if len(args) == 0 {
+				return nil, p.errorf(tok, "empty parentheses")
+			}
+			return &ParensExpr{Args: args, Offset: tok.pos}, nil
+		case "{":
+			return p.parseMetric("", tok.pos)
+		}
+	case tokenIdent:
+		return p.parseIdent()
+	case tokenEOF:
+		return nil, p.errorf(tok, "unexpected end of input")
+	}
+	return nil, p.errorf(tok, "unexpected %s", tok)
+}
+
+// parseIdent 解析以标识符开头的表达式：函数、聚合、选择器或特殊数字
+func (p *parser) parseIdent() (Expr, error) {
+	tok := p.next()
+	name := tok.text
+	lower := strings.ToLower(name)
+
+	if lower == "inf" || lower == "nan" {
+		return &NumberExpr{Text: name, Offset: tok.pos}, nil
+	}
+	if _, ok := binaryOpPriority[lower]; ok && !p.is("(") {
+		return nil, p.errorf(tok, "unexpected operator %q", name)
+	}
+
+	if aggrFuncs[lower] && (p.is("(") || p.isKeyword("by") || p.isKeyword("without")) {
+		return p.parseAggr(lower, tok.pos)
+	}
+
+	if p.is("(") {
+		args, err := p.parseArgs()
+		if err != nil {
+			return nil, err
+		}
+		fe := &FuncExpr{Name: lower, Args: args, Offset: tok.pos}
+		if p.isKeyword("keep_metric_names") {
+			p.next()
+			fe.KeepMetricNames = true
+		}
+		return fe, nil
+	}
+
+	switch lower {
+	case "by", "without", "on", "ignoring", "group_left", "group_right", "bool", "offset", "keep_metric_names", "limit":
+		return nil, p.errorf(tok, "unexpected keyword %q", name)
+	}
+	return p.parseMetric(name, tok.pos)
+}
+
+// parseAggr 解析聚合: sum by (x) (args) 或 sum(args) by (x)，以及可选的 limit N
+func (p *parser) parseAggr(name string, pos int) (Expr, error) {
+	ae := &AggrExpr{Name: name, Offset: pos}
+	parseModifier := func() error {
+		if p.isKeyword("by") || p.isKeyword("without") {
+			tok := p.next()
+			if ae.Modifier != "" {
+				return p.errorf(tok, "duplicate %s modifier", tok.text)
+			}
+			ae.Modifier = strings.ToLower(tok.text)
+			labels, err := p.parseLabelList()
+			if err != nil {
+				return err
+			}
+			ae.Labels = labels
+		}
+		return nil
+	}
+
+	if err := parseModifier(); err != nil {
+		return nil, err
+	}
+	if !p.is("(") {
+		tok := p.peek()
+		return nil, p.errorf(tok, "expected \"(\" after %s, got %s", name, tok)
+	}
+	args, err := p.parseArgs()
+	if err != nil {
+		return nil, err
+	}
+	ae.Args = args
+	if err := parseModifier(); err != nil {
+		return nil, err
+	}
+	if p.isKeyword("limit") {
+		p.next()
+		tok := p.peek()
+		if tok.kind != tokenNumber {
+			return nil, p.errorf(tok, "expected number after limit, got %s", tok)
+		}
+		ae.Limit = p.next().text
+	}
+	return ae, nil
+}
+
+// parseArgs 解析 ( expr, expr, ... )，允许末尾多余的逗号
+func (p *parser) parseArgs() ([]Expr, error) {
+	open, err := p.expect("(")
+	if err != nil {
+		return nil, err
+	}
+	var args []Expr
+	for !p.is(")") {
+		if p.peek().kind == tokenEOF {
+			return nil, p.errorf(open, "unclosed parenthesis")
+		}
+		arg, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+		if p.is(",") {
+			p.next()
+			continue
+		}
+		if !p.is(")") {
+			tok := p.peek()
+			if tok.kind == tokenEOF {
+				return nil, p.errorf(open, "unclosed parenthesis")
+			}
+			return nil, p.errorf(tok, "expected \",\" or \")\", got %s", tok)
+		}
+	}
+	p.next()
+	return args, nil
+}
+
+// parseLabelList 解析 (label, label, ...)
+func (p *parser) parseLabelList() ([]string, error) {
+	open, err := p.expect("(")
+	if err != nil {
+		return nil, err
+	}
+	labels := []string{}
+	for !p.is(")") {
+		tok := p.peek()
+		switch tok.kind {
+		case tokenIdent:
+			labels = append(labels, tok.text)
+		case tokenString:
+			s, err := unquote(tok.text)
+			if err != nil {
+				return nil, p.errorf(tok, "invalid string %s: %v", tok.text, err)
+			}
+			labels = append(labels, s)
+		case tokenEOF:
+			return nil, p.errorf(open, "unclosed label list")
+		default:
+			return nil, p.errorf(tok, "expected label name, got %s", tok)
+		}
+		p.next()
+		if p.is(",") {
+			p.next()
+		} else if !p.is(")") {
+			tok := p.peek()
+			return nil, p.errorf(tok, "expected \",\" or \")\", got %s", tok)
+		}
+	}
+	p.next()
+	return labels, nil
+}
+
+// parseMetric 解析选择器，name 为空时必须带 {...}
+func (p *parser) parseMetric(name string, pos int) (Expr, error) {
+	me := &MetricExpr{Name: name, Offset: pos}
+	if !p.is("{") {
+		return me, nil
+	}
+	open := p.next()
+
+	var group []LabelMatcher
+	for !p.is("}") {
+		tok := p.peek()
+		switch {
+		case tok.kind == tokenEOF:
+			return nil, p.errorf(open, "unclosed label matchers")
+		case tok.kind == tokenIdent && strings.EqualFold(tok.text, "or") && len(group) > 0:
+			p.next()
+			me.Filters = append(me.Filters, group)
+			group = nil
+			continue
+		}
+
+		m, err := p.parseMatcher()
+		if err != nil {
+			return nil, err
+		}
+		group = append(group, m)
+
+		if p.is(",") {
+			p.next()
+		} else if !p.is("}") && !p.isKeyword("or") {
+			tok := p.peek()
+			if tok.kind == tokenEOF {
+				return nil, p.errorf(open, "unclosed label matchers")
+			}
+			return nil, p.errorf(tok, "expected \",\" or \"}\", got %s", tok)
+		}
+	}
+	p.next()
+	if len(group) > 0 {
+		me.Filters = append(me.Filters, group)
+	}
+
+	if name == "" && len(me.Filters) == 0 {
+		return nil, &Error{Pos: pos, Msg: "vector selector must contain a metric name or at least one label matcher"}
+	}
+	return me, nil
+}
+
+// parseMatcher 解析 label op "value"，Prometheus 3 风格的 {"metric.name"} 视为 __name__ 匹配
+func (p *parser) parseMatcher() (LabelMatcher, error) {
+	tok := p.next()
+	var label string
+	switch tok.kind {
+	case tokenIdent:
+		label = tok.text
+	case tokenString:
+		s, err := unquote(tok.text)
+		if err != nil {
+			return LabelMatcher{}, p.errorf(tok, "invalid string %s: %v", tok.text, err)
+		}
+		if p.is(",") || p.is("}") {
+			return LabelMatcher{Label: "__name__", Op: "=", Value: s}, nil
+		}
+		label = s
+	default:
+		return LabelMatcher{}, p.errorf(tok, "expected label name, got %s", tok)
+	}
+
+	opTok := p.peek()
+	switch {
+	case opTok.kind == tokenOp && (opTok.text == "=" || opTok.text == "!=" || opTok.text == "=~" || opTok.text == "!~"):
+		p.next()
+	case opTok.kind == tokenOp && opTok.text == "==":
+		return LabelMatcher{}, p.errorf(opTok, "unexpected \"==\" in label matcher, use \"=\"")
+	default:
+		return LabelMatcher{}, p.errorf(opTok, "expected label matching operator (=, !=, =~, !~) after %q, got %s", label, opTok)
+	}
+
+	valTok := p.peek()
+	if valTok.kind != tokenString {
+		return LabelMatcher{}, p.errorf(valTok, "expected quoted label value, got %s", valTok)
+	}
+	p.next()
+	value, err := unquote(valTok.text)
+	if err != nil {
+		return LabelMatcher{}, p.errorf(valTok, "invalid string %s: %v", valTok.text, err)
+	}
+	return LabelMatcher{Label: label, Op: opTok.text, Value: value}, nil
+}
+
+// parseWith 解析 WITH (defs) expr
+func (p *parser) parseWith() (Expr, error) {
+	withTok := p.next()
+	open, err := p.expect("(")
+	if err != nil {
+		return nil, err
+	}
+
+	we := &WithExpr{Offset: withTok.pos}
+	for !p.is(")") {
+		tok := p.peek()
+		if tok.kind == tokenEOF {
+			return nil, p.errorf(open, "unclosed WITH definitions")
+		}
+		if tok.kind != tokenIdent {
+			return nil, p.errorf(tok, "expected template name, got %s", tok)
+		}
+		def := WithDef{Name: p.next().text}
+		if p.is("(") {
+			args, err := p.parseLabelList()
+			if err != nil {
+				return nil, err
+			}
+			def.Args = args
+		}
+		if _, err := p.expect("="); err != nil {
+			return nil, err
+		}
+		expr, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		def.Expr = expr
+		we.Defs = append(we.Defs, def)
+
+		if p.is(",") {
+			p.next()
+		} else if !p.is(")") {
+			tok := p.peek()
+			return nil, p.errorf(tok, "expected \",\" or \")\", got %s", tok)
+		}
+	}
+	p.next()
+	if len(we.Defs) == 0 {
+		return nil, p.errorf(open, "WITH requires at least one definition")
+	}
+
+	expr, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	we.Expr = expr
+	return we, nil
+}
+
+// unquote 去掉字符串的引号并处理转义
+func unquote(s string) (string, error) {
+	switch s[0] {
+	case '`':
+		return s[1 : len(s)-1], nil
+	case '\'':
+		// 转换为双引号形式后交给 strconv 处理
+		inner := s[1 : len(s)-1]
+		inner = strings.ReplaceAll(inner, `\'`, `'`)
+		inner = strings.ReplaceAll(inner, `"`, `\"`)
+		return strconv.Unquote(`"` + inner + `"`)
+	default:
+		return strconv.Unquote(s)
+	}
+}
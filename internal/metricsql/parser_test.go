@@ -0,0 +1,172 @@
+package metricsql
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestParseFormat 验证解析后格式化为规范形式，且格式化结果可再次解析为相同文本
+func TestParseFormat(t *testing.T) {
+	tests := map[string]string{
+		`up`:                                      `up`,
+		`up{job="api",instance=~'10\\..*'}`:       `up{job="api", instance=~"10\\..*"}`,
+		`{__name__="up"}`:                         `{__name__="up"}`,
+		`{a="x" or b="y"}`:                        `{a="x" or b="y"}`,
+		`rate(x_total[5m])`:                       `rate(x_total[5m])`,
+		`RATE(x_total[$__rate_interval])`:         `rate(x_total[$__rate_interval])`,
+		`sum(rate(x_total[5m])) by (job)`:         `sum by (job) (rate(x_total[5m]))`,
+		`topk(5, foo) limit 3`:                    `topk(5, foo) limit 3`,
+		`a / on(job) group_left(team) b`:          `a / on (job) group_left (team) b`,
+		`a > bool 1`:                              `a > bool 1`,
+		`-a ^ 2 * 3`:                              `-a ^ 2 * 3`,
+		`max_over_time(rate(x_total[5m])[1h:1m])`: `max_over_time(rate(x_total[5m])[1h:1m])`,
+		`foo offset -5m @ end()`:                  `foo offset -5m @ end()`,
+		`foo[1h30m:] @ 1700000000`:                `foo[1h30m:] @ 1700000000`,
+		`abs(foo) keep_metric_names`:              `abs(foo) keep_metric_names`,
+		`(a, b)`:                                  `(a, b)`,
+		`1Ki + 0x10 + 1e3 - Inf`:                  `1Ki + 0x10 + 1e3 - Inf`,
+		`job:rate5m:sum # comment`:                `job:rate5m:sum`,
+		`WITH (f(x) = rate(x[5m])) f(foo_total)`:  `WITH (f(x) = rate(x[5m])) f(foo_total)`,
+		`foo default 0 if bar`:                    `foo default 0 if bar`,
+		"histogram_quantile(0.9,\n sum(rate(x_bucket[5m])) by (le))": `histogram_quantile(0.9, sum by (le) (rate(x_bucket[5m])))`,
+	}
+	for in, want := range tests {
+		expr, err := Parse(in)
+		if err != nil {
+			t.Errorf("Parse(%q) error: %v", in, err)
+			continue
+		}
+		if got := Format(expr); got != want {
+			t.Errorf("Format(Parse(%q)) = %q, want %q", in, got, want)
+			continue
+		}
+		again, err := Parse(want)
+		if err != nil || Format(again) != want {
+			t.Errorf("re-parse of %q is not stable: %v", want, err)
+		}
+	}
+}
+
+// TestParsePriority 验证运算符优先级与结合性
+func TestParsePriority(t *testing.T) {
+	expr, err := Parse(`a + b * c ^ d ^ e and f or g`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	or, ok := expr.(*BinaryExpr)
+	if !ok || or.Op != "or" {
+		t.Fatalf("top-level op = %#v, want or", expr)
+	}
+	and := or.LHS.(*BinaryExpr)
+	plus := and.LHS.(*BinaryExpr)
+	mul := plus.RHS.(*BinaryExpr)
+	pow := mul.RHS.(*BinaryExpr)
+	if and.Op != "and" || plus.Op != "+" || mul.Op != "*" || pow.Op != "^" {
+		t.Fatalf("unexpected tree: %s", Format(expr))
+	}
+	// ^ 右结合
+	if _, ok := pow.RHS.(*BinaryExpr); !ok {
+		t.Errorf("^ should be right-associative")
+	}
+}
+
+// TestParseError 验证错误信息与位置
+func TestParseError(t *testing.T) {
+	tests := []struct {
+		query string
+		pos   int
+		msg   string
+	}{
+		{`rate(foo_total[5m]))`, 19, `unexpected ")"`},
+		{`rate(foo_total[5m]`, 4, `unclosed parenthesis`},
+		{`foo{job=="a"}`, 7, `"=="`},
+		{`foo{job=a}`, 8, `quoted label value`},
+		{`sum(rate(x[5m]) by (job)`, 16, `expected "," or ")"`},
+		{`a + bool b`, 4, `bool modifier`},
+		{`a * group_left b`, 4, `requires on() or ignoring()`},
+		{`foo[]`, 4, `missing range duration`},
+		{`foo[5x]`, 4, `invalid number`},
+		{`foo[5m:] offset`, 15, `expected duration`},
+		{`"abc`, 0, `unterminated string`},
+		{`{}`, 0, `metric name or at least one label matcher`},
+		{`foo !`, 4, `unexpected character`},
+		{`sum(`, 3, `unclosed parenthesis`},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.query)
+		var perr *Error
+		if !errors.As(err, &perr) {
+			t.Errorf("Parse(%q) error = %v, want *Error", tt.query, err)
+			continue
+		}
+		if perr.Pos != tt.pos || !strings.Contains(perr.Msg, tt.msg) {
+			t.Errorf("Parse(%q) = %d %q, want %d containing %q", tt.query, perr.Pos, perr.Msg, tt.pos, tt.msg)
+		}
+	}
+}
+
+// TestCaret 验证多行查询中的行列计算与 ^ 标记
+func TestCaret(t *testing.T) {
+	q := "sum(\n\trate(x[5m])))"
+	pos := strings.LastIndex(q, ")")
+	if line, col := Location(q, pos); line != 2 || col != 14 {
+		t.Errorf("Location = %d:%d, want 2:14", line, col)
+	}
+	want := "\trate(x[5m])))\n\t            ^"
+	if got := Caret(q, pos); got != want {
+		t.Errorf("Caret = %q, want %q", got, want)
+	}
+}
+
+// TestLint 验证常见错误写法的警告
+func TestLint(t *testing.T) {
+	tests := map[string][]string{
+		`sum(rate(http_requests_total[5m])) by (job)`: nil,
+		`rate(node_memory_bytes[5m])`:                 {`does not look like a counter`},
+		`rate(foo_total)`:                             {`without range selector`},
+		`deriv(foo_total[5m])`:                        {`on counter "foo_total"`},
+		`delta(temperature[1h])`:                      nil,
+		`irate(job:foo:rate5m[5m])`:                   nil,
+		`sum(foo[5m])`:                                {`outside a rollup function`},
+		`rate(sum(x_total)[5m:])`:                     {`aggregate after rate()`},
+		`quantile_over_time(0.9, foo[5m])`:            nil,
+		`WITH (x = foo_total[5m]) rate(x)`:            nil,
+	}
+	for q, want := range tests {
+		expr, err := Parse(q)
+		if err != nil {
+			t.Errorf("Parse(%q) error: %v", q, err)
+			continue
+		}
+		warnings := Lint(expr)
+		if len(warnings) != len(want) {
+			t.Errorf("Lint(%q) = %v, want %d warnings", q, warnings, len(want))
+			continue
+		}
+		for i, w := range warnings {
+			if !strings.Contains(w.Msg, want[i]) {
+				t.Errorf("Lint(%q)[%d] = %q, want containing %q", q, i, w.Msg, want[i])
+			}
+		}
+	}
+}
+
+// TestPrettify 验证超长表达式的多行格式化
+func TestPrettify(t *testing.T) {
+	expr, err := Parse(`sum(rate(http_requests_total{job="api"}[5m])) by (job) / on(job) group_left count(up)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `sum by (job) (
+  rate(http_requests_total{job="api"}[5m])
+)
+/ on (job) group_left
+count(up)`
+	if got := Prettify(expr, 44); got != want {
+		t.Errorf("Prettify =\n%s\nwant\n%s", got, want)
+	}
+	if got := Prettify(expr, 200); got != Format(expr) {
+		t.Errorf("Prettify with large width = %q, want single line", got)
+	}
+}
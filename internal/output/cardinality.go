@@ -0,0 +1,46 @@
+package output
+
+import (
+	"fmt"
+
+	"github.com/lwmacct/251203-vm-metrics/internal/vmapi"
+)
+
+// cardinalitySection TSDB 基数统计中的一类排行
+type cardinalitySection struct {
+	Name    string // 分类名称 (csv 的 section 列)
+	Header  string // 名称列表头 (table)
+	Unit    string // 计数列表头 (table)
+	Total   uint64 // 计算占比的总数
+	Entries []vmapi.TSDBStatusEntry
+}
+
+// cardinalitySections 按展示顺序整理 TSDB 基数统计
+// 序列数排行的占比相对总序列数，标签值数量排行的占比相对总 label=value 对数
+func cardinalitySections(status *vmapi.TSDBStatus) []cardinalitySection {
+	sections := []cardinalitySection{
+		{Name: "metric", Header: "METRIC", Unit: "SERIES", Total: status.TotalSeries, Entries: status.SeriesCountByMetricName},
+		{Name: "label", Header: "LABEL", Unit: "SERIES", Total: status.TotalSeries, Entries: status.SeriesCountByLabelName},
+		{Name: "label_value_pair", Header: "LABEL=VALUE", Unit: "SERIES", Total: status.TotalSeries, Entries: status.SeriesCountByLabelValuePair},
+		{Name: "label_value_count", Header: "LABEL", Unit: "UNIQUE VALUES", Total: status.TotalLabelValuePairs, Entries: status.LabelValueCountByLabelName},
+	}
+	if status.FocusLabel != "" {
+		focus := cardinalitySection{
+			Name:    "focus_label_value",
+			Header:  status.FocusLabel,
+			Unit:    "SERIES",
+			Total:   status.TotalSeries,
+			Entries: status.SeriesCountByFocusLabelValue,
+		}
+		sections = append(sections[:1], append([]cardinalitySection{focus}, sections[1:]...)...)
+	}
+	return sections
+}
+
+// formatShare 计算占比百分比
+func formatShare(count, total uint64) string {
+	if total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f%%", float64(count)*100/float64(total))
+}
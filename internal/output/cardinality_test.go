@@ -0,0 +1,129 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/lwmacct/251203-vm-metrics/internal/vmapi"
+)
+
+// testTSDBStatus 基数统计样例: 200 个序列，50 个 label=value 对
+func testTSDBStatus(focus string) *vmapi.TSDBStatus {
+	status := &vmapi.TSDBStatus{
+		TotalSeries:                 200,
+		TotalLabelValuePairs:        50,
+		SeriesCountByMetricName:     []vmapi.TSDBStatusEntry{{Name: "http_requests_total", Count: 150}, {Name: "up", Count: 50}},
+		SeriesCountByLabelName:      []vmapi.TSDBStatusEntry{{Name: "instance", Count: 200}},
+		SeriesCountByLabelValuePair: []vmapi.TSDBStatusEntry{{Name: "job=api", Count: 120}},
+		LabelValueCountByLabelName:  []vmapi.TSDBStatusEntry{{Name: "instance", Count: 10}},
+	}
+	if focus != "" {
+		status.FocusLabel = focus
+		status.SeriesCountByFocusLabelValue = []vmapi.TSDBStatusEntry{{Name: "api", Count: 120}, {Name: "db", Count: 1}}
+	}
+	return status
+}
+
+// TestTableTSDBStatus 验证表格按分类输出、空分类省略、focus label 紧跟指标排行，以及各分类的占比基准
+func TestTableTSDBStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		status *vmapi.TSDBStatus
+		opts   Options
+		want   string
+	}{
+		{"sections", testTSDBStatus(""), Options{}, `Total series: 200, total label=value pairs: 50
+
+METRIC               SERIES  SHARE
+http_requests_total  150     75.00%
+up                   50      25.00%
+
+LABEL     SERIES  SHARE
+instance  200     100.00%
+
+LABEL=VALUE  SERIES  SHARE
+job=api      120     60.00%
+
+LABEL     UNIQUE VALUES  SHARE
+instance  10             20.00%
+`},
+		{"focus label after metrics", &vmapi.TSDBStatus{
+			TotalSeries:                  200,
+			FocusLabel:                   "job",
+			SeriesCountByMetricName:      []vmapi.TSDBStatusEntry{{Name: "up", Count: 50}},
+			SeriesCountByFocusLabelValue: []vmapi.TSDBStatusEntry{{Name: "api", Count: 120}, {Name: "db", Count: 1}},
+			SeriesCountByLabelName:       []vmapi.TSDBStatusEntry{{Name: "job", Count: 121}},
+		}, Options{NoHeaders: true}, `Total series: 200, total label=value pairs: 0
+
+up  50  25.00%
+
+api  120  60.00%
+db   1    0.50%
+
+job  121  60.50%
+`},
+		{"zero total", &vmapi.TSDBStatus{
+			LabelValueCountByLabelName: []vmapi.TSDBStatusEntry{{Name: "job", Count: 3}},
+		}, Options{}, `Total series: 0, total label=value pairs: 0
+
+LABEL  UNIQUE VALUES  SHARE
+job    3              -
+`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tt.opts.Writer = &buf
+			w, err := New("table", tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := w.WriteTSDBStatus(tt.status); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+// TestJSONTSDBStatus 验证 JSON 输出保留服务端字段名，focusLabel 仅在指定时输出
+func TestJSONTSDBStatus(t *testing.T) {
+	for _, focus := range []string{"", "job"} {
+		var buf bytes.Buffer
+		w, err := New("json", Options{Writer: &buf})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := w.WriteTSDBStatus(testTSDBStatus(focus)); err != nil {
+			t.Fatal(err)
+		}
+
+		var got map[string]json.RawMessage
+		if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+			t.Fatalf("invalid json: %v\n%s", err, buf.String())
+		}
+		if string(got["totalSeries"]) != "200" || string(got["totalLabelValuePairs"]) != "50" {
+			t.Errorf("totals = %s, %s", got["totalSeries"], got["totalLabelValuePairs"])
+		}
+		if want := `[{"name":"http_requests_total","value":150},{"name":"up","value":50}]`; compactJSON(t, got["seriesCountByMetricName"]) != want {
+			t.Errorf("seriesCountByMetricName = %s, want %s", got["seriesCountByMetricName"], want)
+		}
+		_, hasFocus := got["focusLabel"]
+		if hasFocus != (focus != "") {
+			t.Errorf("focus %q: focusLabel present = %v", focus, hasFocus)
+		}
+	}
+}
+
+// compactJSON 去掉缩进以便比较
+func compactJSON(t *testing.T, data []byte) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, data); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
@@ -2,8 +2,8 @@ package output
 
 import (
 	"encoding/csv"
-	"fmt"
-	"time"
+	"slices"
+	"strconv"
 
 	"github.com/lwmacct/251203-vm-metrics/internal/vmapi"
 )
@@ -27,6 +27,9 @@ func (w *csvWriter) WriteQueryResult(result *vmapi.QueryResult) error {
 	case "vector":
 		return w.writeVector(cw, result.Samples)
 	case "matrix":
+		if w.opts.Layout == LayoutWide {
+			return w.writeWide(cw, result.Samples)
+		}
 		return w.writeMatrix(cw, result.Samples)
 	case "scalar":
 		if result.Scalar != nil {
@@ -34,8 +37,8 @@ func (w *csvWriter) WriteQueryResult(result *vmapi.QueryResult) error {
 				_ = cw.Write([]string{"value", "timestamp"})
 			}
 			_ = cw.Write([]string{
-				fmt.Sprintf("%v", result.Scalar.Value),
-				result.Scalar.Timestamp.Format(time.RFC3339),
+				w.opts.formatValue(result.Scalar.Value),
+				w.opts.formatTime(result.Scalar.Timestamp),
 			})
 		}
 	case "string":
@@ -45,7 +48,7 @@ func (w *csvWriter) WriteQueryResult(result *vmapi.QueryResult) error {
 			}
 			_ = cw.Write([]string{
 				result.String.Value,
-				result.String.Timestamp.Format(time.RFC3339),
+				w.opts.formatTime(result.String.Timestamp),
 			})
 		}
 	}
@@ -56,15 +59,14 @@ func (w *csvWriter) WriteQueryResult(result *vmapi.QueryResult) error {
 // writeVector 输出 instant query 结果
 func (w *csvWriter) writeVector(cw *csv.Writer, samples []vmapi.Sample) error {
 	if !w.opts.NoHeaders {
-		_ = cw.Write([]string{"metric", "value", "timestamp"})
+		_ = cw.Write(append(w.opts.labelHeaders(false), "value", "timestamp"))
 	}
 
 	for _, s := range samples {
-		_ = cw.Write([]string{
-			formatMetric(s.Metric),
-			fmt.Sprintf("%v", s.Value.Value),
-			s.Value.Timestamp.Format(time.RFC3339),
-		})
+		_ = cw.Write(append(w.opts.labelCells(s.Metric),
+			w.opts.formatValue(s.Value.Value),
+			w.opts.formatTime(s.Value.Timestamp),
+		))
 	}
 
 	return cw.Error()
@@ -73,23 +75,37 @@ func (w *csvWriter) writeVector(cw *csv.Writer, samples []vmapi.Sample) error {
 // writeMatrix 输出 range query 结果
 func (w *csvWriter) writeMatrix(cw *csv.Writer, samples []vmapi.Sample) error {
 	if !w.opts.NoHeaders {
-		_ = cw.Write([]string{"metric", "value", "timestamp"})
+		_ = cw.Write(append(w.opts.labelHeaders(false), "value", "timestamp"))
 	}
 
 	for _, s := range samples {
-		metric := formatMetric(s.Metric)
+		labels := w.opts.labelCells(s.Metric)
 		for _, v := range s.Values {
-			_ = cw.Write([]string{
-				metric,
-				fmt.Sprintf("%v", v.Value),
-				v.Timestamp.Format(time.RFC3339),
-			})
+			_ = cw.Write(append(slices.Clip(labels),
+				w.opts.formatValue(v.Value),
+				w.opts.formatTime(v.Timestamp),
+			))
 		}
 	}
 
 	return cw.Error()
 }
 
+// writeWide 以宽表输出 range query 结果: 第一列为时间戳，每个序列一列
+func (w *csvWriter) writeWide(cw *csv.Writer, samples []vmapi.Sample) error {
+	t := pivotMatrix(samples, w.opts)
+
+	if !w.opts.NoHeaders {
+		_ = cw.Write(append([]string{"timestamp"}, t.Headers...))
+	}
+
+	for i, ts := range t.Timestamps {
+		_ = cw.Write(append([]string{w.opts.formatTime(ts)}, t.Cells[i]...))
+	}
+
+	return cw.Error()
+}
+
 // WriteStrings 输出字符串列表
 func (w *csvWriter) WriteStrings(items []string) error {
 	cw := csv.NewWriter(w.opts.Writer)
@@ -121,3 +137,26 @@ func (w *csvWriter) WriteSeries(series []vmapi.LabelSet) error {
 
 	return cw.Error()
 }
+
+// WriteTSDBStatus 输出 TSDB 基数统计，每行一个条目
+func (w *csvWriter) WriteTSDBStatus(status *vmapi.TSDBStatus) error {
+	cw := csv.NewWriter(w.opts.Writer)
+	defer cw.Flush()
+
+	if !w.opts.NoHeaders {
+		_ = cw.Write([]string{"section", "name", "count", "share"})
+	}
+
+	for _, section := range cardinalitySections(status) {
+		for _, e := range section.Entries {
+			_ = cw.Write([]string{
+				section.Name,
+				e.Name,
+				strconv.FormatUint(e.Count, 10),
+				formatShare(e.Count, section.Total),
+			})
+		}
+	}
+
+	return cw.Error()
+}
@@ -0,0 +1,196 @@
+package output
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 时间戳格式
+const (
+	TimeRFC3339     = "rfc3339"     // 2024-05-01T10:00:00+08:00 (默认)
+	TimeRFC3339Nano = "rfc3339nano" // 保留亚秒精度
+	TimeUnix        = "unix"        // Unix 秒
+	TimeUnixMs      = "unix_ms"     // Unix 毫秒
+	TimeRelative    = "relative"    // 相对当前时间，如 3m ago
+)
+
+// 数值单位
+const (
+	UnitSI      = "si"      // 十进制前缀: k, M, G ... / m, µ, n
+	UnitIEC     = "iec"     // 二进制前缀: Ki, Mi, Gi ...
+	UnitBytes   = "bytes"   // 字节，二进制前缀: B, KiB, MiB ...
+	UnitSeconds = "seconds" // 时长: 250ms, 1.5s, 3m20s, 2h5m, 3d4h
+	UnitPercent = "percent" // 比例 (0-1) 显示为百分比
+)
+
+// validateFormatOptions 校验时间戳格式与数值单位
+func validateFormatOptions(opts Options) error {
+	switch opts.TimeFormat {
+	case "", TimeRFC3339, TimeRFC3339Nano, TimeUnix, TimeUnixMs, TimeRelative:
+	default:
+		return fmt.Errorf("unsupported time format: %s (use rfc3339, rfc3339nano, unix, unix_ms or relative)", opts.TimeFormat)
+	}
+	switch opts.ValueUnit {
+	case "", UnitSI, UnitIEC, UnitBytes, UnitSeconds, UnitPercent:
+	default:
+		return fmt.Errorf("unsupported value unit: %s (use si, iec, bytes, seconds or percent)", opts.ValueUnit)
+	}
+	return nil
+}
+
+// formatTime 按 TimeFormat 格式化时间戳，UTC 时转换为 UTC，否则保持时间戳自身的时区 (查询结果为本地时区)
+func (o Options) formatTime(t time.Time) string {
+	if o.UTC {
+		t = t.UTC()
+	}
+	switch o.TimeFormat {
+	case TimeRFC3339Nano:
+		return t.Format(time.RFC3339Nano)
+	case TimeUnix:
+		return strconv.FormatInt(t.Unix(), 10)
+	case TimeUnixMs:
+		return strconv.FormatInt(t.UnixMilli(), 10)
+	case TimeRelative:
+		return formatRelative(t, time.Now())
+	}
+	return t.Format(time.RFC3339)
+}
+
+// formatRelative 返回相对 now 的时间，如 45s ago、3m ago、in 2h
+func formatRelative(t, now time.Time) string {
+	d := now.Sub(t)
+	suffix, prefix := " ago", ""
+	if d < 0 {
+		d, suffix, prefix = -d, "", "in "
+	}
+
+	var s string
+	switch {
+	case d < time.Second:
+		return "now"
+	case d < time.Minute:
+		s = fmt.Sprintf("%ds", int(d/time.Second))
+	case d < time.Hour:
+		s = fmt.Sprintf("%dm", int(d/time.Minute))
+	case d < 48*time.Hour:
+		s = fmt.Sprintf("%dh", int(d/time.Hour))
+	default:
+		s = fmt.Sprintf("%dd", int(d/(24*time.Hour)))
+	}
+	return prefix + s + suffix
+}
+
+// formatValue 按 Precision 与 ValueUnit 格式化样本值
+// 未设置时与 %v 一致；NaN 与 ±Inf 不参与单位换算
+func (o Options) formatValue(v float64) string {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return fmt.Sprintf("%v", v)
+	}
+	precision := -1
+	if o.Precision != nil {
+		precision = *o.Precision
+	}
+	switch o.ValueUnit {
+	case UnitSI:
+		return scaleValue(v, 1000, siPrefixes, "", precision)
+	case UnitIEC:
+		return scaleValue(v, 1024, iecPrefixes, "", precision)
+	case UnitBytes:
+		return scaleValue(v, 1024, iecPrefixes, "B", precision)
+	case UnitSeconds:
+		return formatSeconds(v, precision)
+	case UnitPercent:
+		return formatNumber(v*100, precision) + "%"
+	}
+	if precision >= 0 {
+		return strconv.FormatFloat(v, 'f', precision, 64)
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// 单位前缀，下标 0 为无前缀
+var (
+	siPrefixes      = []string{"", "k", "M", "G", "T", "P", "E"}
+	siSmallPrefixes = []string{"", "m", "µ", "n"}
+	iecPrefixes     = []string{"", "Ki", "Mi", "Gi", "Ti", "Pi", "Ei"}
+)
+
+// scaleValue 按 base 逐级换算到合适的前缀，SI 同时支持小于 1 的值
+func scaleValue(v, base float64, prefixes []string, unit string, precision int) string {
+	abs := math.Abs(v)
+	i := 0
+	for abs >= base && i < len(prefixes)-1 {
+		abs /= base
+		v /= base
+		i++
+	}
+	prefix := prefixes[i]
+	if base == 1000 && abs != 0 && abs < 1 {
+		for abs < 1 && i < len(siSmallPrefixes)-1 {
+			abs *= base
+			v *= base
+			i++
+		}
+		prefix = siSmallPrefixes[i]
+	}
+
+	s := formatNumber(v, precision)
+	if prefix+unit == "" {
+		return s
+	}
+	return s + " " + prefix + unit
+}
+
+// formatSeconds 将秒数格式化为时长
+// 小于 1 分钟时保留小数 (小于 1 秒时使用 ms/µs/ns)，否则取最大的两个单位，如 3m20s、2h5m、3d4h
+func formatSeconds(v float64, precision int) string {
+	sign := ""
+	if v < 0 {
+		sign, v = "-", -v
+	}
+	switch {
+	case v == 0:
+		return "0s"
+	case v < 1:
+		return sign + strings.Replace(scaleValue(v, 1000, siPrefixes, "s", precision), " ", "", 1)
+	case v < 60:
+		return sign + formatNumber(v, precision) + "s"
+	}
+
+	units := []struct {
+		name string
+		secs int64
+	}{{"d", 86400}, {"h", 3600}, {"m", 60}, {"s", 1}}
+	rest := int64(math.Round(v))
+	var b strings.Builder
+	b.WriteString(sign)
+	parts := 0
+	for _, u := range units {
+		if n := rest / u.secs; n > 0 || parts > 0 {
+			if n > 0 {
+				fmt.Fprintf(&b, "%d%s", n, u.name)
+			}
+			rest -= n * u.secs
+			parts++
+			if parts == 2 {
+				break
+			}
+		}
+	}
+	return b.String()
+}
+
+// formatNumber 格式化换算后的数值: precision < 0 时保留至多 2 位小数并去掉末尾的 0
+func formatNumber(v float64, precision int) string {
+	if precision >= 0 {
+		return strconv.FormatFloat(v, 'f', precision, 64)
+	}
+	s := strconv.FormatFloat(v, 'f', 2, 64)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	return s
+}
@@ -0,0 +1,66 @@
+package output
+
+import (
+	"testing"
+	"time"
+)
+
+// TestFormatValue 验证精度与各数值单位的换算
+func TestFormatValue(t *testing.T) {
+	two := 2
+	tests := []struct {
+		unit      string
+		precision *int
+		v         float64
+		want      string
+	}{
+		{"", nil, 1.2345678e+09, "1.2345678e+09"},
+		{"", &two, 3.14159, "3.14"},
+		{UnitBytes, nil, 1.2345678e+09, "1.15 GiB"},
+		{UnitBytes, nil, 512, "512 B"},
+		{UnitIEC, nil, 2048, "2 Ki"},
+		{UnitSI, nil, 1.5e6, "1.5 M"},
+		{UnitSI, nil, 0.0025, "2.5 m"},
+		{UnitSeconds, nil, 0.25, "250ms"},
+		{UnitSeconds, nil, 12.5, "12.5s"},
+		{UnitSeconds, nil, 200, "3m20s"},
+		{UnitSeconds, nil, 7500, "2h5m"},
+		{UnitSeconds, nil, 3600, "1h"},
+		{UnitSeconds, nil, 273600, "3d4h"},
+		{UnitPercent, &two, 0.125, "12.50%"},
+	}
+	for _, tt := range tests {
+		opts := Options{ValueUnit: tt.unit, Precision: tt.precision}
+		if got := opts.formatValue(tt.v); got != tt.want {
+			t.Errorf("formatValue(%v, unit=%q) = %q, want %q", tt.v, tt.unit, got, tt.want)
+		}
+	}
+}
+
+// TestFormatTime 验证时间戳格式与相对时间
+func TestFormatTime(t *testing.T) {
+	ts := time.Date(2024, 5, 1, 10, 0, 0, 123000000, time.FixedZone("CST", 8*3600))
+	tests := []struct {
+		opts Options
+		want string
+	}{
+		{Options{}, "2024-05-01T10:00:00+08:00"},
+		{Options{UTC: true}, "2024-05-01T02:00:00Z"},
+		{Options{TimeFormat: TimeRFC3339Nano, UTC: true}, "2024-05-01T02:00:00.123Z"},
+		{Options{TimeFormat: TimeUnix}, "1714528800"},
+		{Options{TimeFormat: TimeUnixMs}, "1714528800123"},
+	}
+	for _, tt := range tests {
+		if got := tt.opts.formatTime(ts); got != tt.want {
+			t.Errorf("formatTime(%+v) = %q, want %q", tt.opts, got, tt.want)
+		}
+	}
+
+	now := ts.Add(3*time.Minute + 20*time.Second)
+	if got := formatRelative(ts, now); got != "3m ago" {
+		t.Errorf("formatRelative = %q, want 3m ago", got)
+	}
+	if got := formatRelative(now, ts); got != "in 3m" {
+		t.Errorf("formatRelative = %q, want in 3m", got)
+	}
+}
@@ -2,11 +2,35 @@ package output
 
 import (
 	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/guptarohit/asciigraph"
 	"github.com/lwmacct/251203-vm-metrics/internal/vmapi"
+	"golang.org/x/term"
 )
 
+// 图表默认尺寸 (非终端输出，或 --graph-separate 时的高度)
+const (
+	graphDefaultWidth  = 60
+	graphDefaultHeight = 10
+	graphMaxHeight     = 30
+	graphMaxSlots      = 100000 // 时间网格的最大点数
+)
+
+// graphColors 叠加绘制时各序列的颜色，超出后循环使用
+// 不含黄色，黄色保留给高亮序列
+var graphColors = []asciigraph.AnsiColor{
+	asciigraph.Blue, asciigraph.Red, asciigraph.Green, asciigraph.Magenta, asciigraph.Cyan,
+	asciigraph.Orange, asciigraph.SlateBlue, asciigraph.HotPink, asciigraph.Olive, asciigraph.Teal,
+}
+
+// ansiPattern 匹配 ANSI 颜色控制序列
+var ansiPattern = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
 // graphWriter ASCII 图表输出
 type graphWriter struct {
 	opts Options
@@ -18,38 +42,295 @@ func NewGraphWriter(opts Options) Writer {
 }
 
 // WriteQueryResult 输出查询结果为 ASCII 图表
-// 仅支持 matrix 类型 (range query)，其他类型回退到 table 格式
+// 仅支持 matrix 类型 (range query)，其他类型或没有有效数据点时回退到 table 格式
+// 默认所有序列叠加在一张图中，GraphSeparate 时每个序列单独一张图
 func (w *graphWriter) WriteQueryResult(result *vmapi.QueryResult) error {
-	if result.ResultType != "matrix" || len(result.Samples) == 0 {
-		// 非 matrix 类型或无数据，回退到 table
+	if result.ResultType != "matrix" || !hasFinitePoint(result.Samples) {
 		return NewTableWriter(w.opts).WriteQueryResult(result)
 	}
 
-	// 为每个时间序列绘制图表
+	if !w.opts.GraphSeparate {
+		_, _ = fmt.Fprintln(w.opts.Writer, w.plot(result.Samples))
+		return nil
+	}
+
 	for _, sample := range result.Samples {
-		if len(sample.Values) == 0 {
+		if !hasFinitePoint([]vmapi.Sample{sample}) {
 			continue
 		}
+		_, _ = fmt.Fprintln(w.opts.Writer, w.plot([]vmapi.Sample{sample}))
+		_, _ = fmt.Fprintln(w.opts.Writer) // 空行分隔
+	}
+	return nil
+}
 
-		// 提取数值
-		data := make([]float64, len(sample.Values))
-		for i, v := range sample.Values {
-			data[i] = v.Value
+// plot 将一组序列绘制为一张图: 图表、带时间戳的 X 轴与图例
+func (w *graphWriter) plot(samples []vmapi.Sample) string {
+	width, fit, height := w.size(len(samples))
+	grid := newTimeGrid(samples)
+
+	colors := make([]asciigraph.AnsiColor, len(samples))
+	for i, s := range samples {
+		switch {
+		case w.opts.NoColor:
+			colors[i] = asciigraph.Default
+		case w.opts.highlighted(s.Metric):
+			colors[i] = asciigraph.Yellow
+		case len(samples) == 1:
+			colors[i] = asciigraph.Default
+		default:
+			colors[i] = graphColors[i%len(graphColors)]
+		}
+	}
+
+	render := func(cols int) (string, int) {
+		data := make([][]float64, len(samples))
+		for i, s := range samples {
+			data[i] = resample(grid.slots(s), cols)
+		}
+		opts := []asciigraph.Option{asciigraph.Height(height)}
+		if !w.opts.NoColor {
+			opts = append(opts, asciigraph.SeriesColors(colors...))
 		}
+		chart := asciigraph.PlotMany(data, opts...)
+		return chart, axisColumn(chart)
+	}
 
-		// 绘制图表
-		metric := formatMetric(sample.Metric)
-		graph := asciigraph.Plot(data,
-			asciigraph.Caption(metric),
-			asciigraph.Height(10),
-			asciigraph.Width(60),
-		)
+	// 按终端宽度绘制时，先按估计宽度绘制一次以测量 Y 轴标签宽度，再调整到恰好占满
+	chart, axis := render(width)
+	if fit && axis+width+1 > w.totalWidth() {
+		width = max(w.totalWidth()-axis-1, 10)
+		chart, axis = render(width)
+	}
 
-		_, _ = fmt.Fprintln(w.opts.Writer, graph)
-		_, _ = fmt.Fprintln(w.opts.Writer) // 空行分隔
+	var b strings.Builder
+	b.WriteString(chart)
+	b.WriteByte('\n')
+	b.WriteString(grid.axis(axis, width))
+	b.WriteByte('\n')
+	for i, s := range samples {
+		b.WriteByte('\n')
+		b.WriteString(strings.Repeat(" ", axis))
+		b.WriteString(w.legendItem(formatMetric(w.opts.displayLabels(s.Metric)), colors[i]))
 	}
+	return b.String()
+}
 
-	return nil
+// legendItem 返回一条图例: 颜色方块 + 序列名
+func (w *graphWriter) legendItem(text string, color asciigraph.AnsiColor) string {
+	if w.opts.NoColor || color == asciigraph.Default {
+		return "■ " + text
+	}
+	return color.String() + "■" + ansiReset + " " + text
+}
+
+// terminalSize 返回输出终端的列数与行数，输出不是终端时 ok 为 false
+// 只探测 Options.Terminal 或本身是终端的 Writer，不使用与输出无关的 os.Stdout
+func (w *graphWriter) terminalSize() (cols, rows int, ok bool) {
+	f := w.opts.Terminal
+	if f == nil {
+		f, _ = w.opts.Writer.(*os.File)
+	}
+	if f == nil || !term.IsTerminal(int(f.Fd())) {
+		return 0, 0, false
+	}
+	cols, rows, err := term.GetSize(int(f.Fd()))
+	return cols, rows, err == nil && cols > 0 && rows > 0
+}
+
+// size 返回绘图区列数、列数是否需要按终端宽度调整，以及高度
+// 未指定 GraphWidth/GraphHeight 时按终端尺寸，非终端输出使用默认尺寸
+func (w *graphWriter) size(series int) (width int, fit bool, height int) {
+	cols, rows, isTerminal := w.terminalSize()
+
+	width = w.opts.GraphWidth
+	if width <= 0 {
+		width = graphDefaultWidth
+		if isTerminal {
+			width, fit = max(cols-12, 10), true
+		}
+	}
+
+	height = w.opts.GraphHeight
+	if height <= 0 {
+		height = graphDefaultHeight
+		if isTerminal && !w.opts.GraphSeparate {
+			// 预留 X 轴、图例与提示符所占的行
+			height = min(max(rows-series-6, graphDefaultHeight), graphMaxHeight)
+		}
+	}
+	return width, fit, height
+}
+
+// totalWidth 返回终端宽度，非终端输出使用默认宽度
+func (w *graphWriter) totalWidth() int {
+	cols, _, ok := w.terminalSize()
+	if !ok {
+		return graphDefaultWidth
+	}
+	return cols
+}
+
+// axisColumn 返回图表中 Y 轴所在的列 (去除颜色控制序列后)
+func axisColumn(chart string) int {
+	first, _, _ := strings.Cut(chart, "\n")
+	first = ansiPattern.ReplaceAllString(first, "")
+	for i, r := range []rune(first) {
+		if r == '┤' || r == '┼' {
+			return i
+		}
+	}
+	return 0
+}
+
+// hasFinitePoint 判断是否至少有一个可绘制的点
+func hasFinitePoint(samples []vmapi.Sample) bool {
+	for _, s := range samples {
+		for _, v := range s.Values {
+			if !math.IsNaN(v.Value) && !math.IsInf(v.Value, 0) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// timeGrid 所有序列共享的时间网格，间隔为序列中最小的相邻点间隔
+// 网格上没有数据的位置 (缺失点) 为 NaN，绘制时形成断点而不是被插值连接
+type timeGrid struct {
+	start, end int64 // 首尾时间戳 (毫秒)
+	step       int64 // 网格间隔 (毫秒)
+	n          int   // 网格点数
+}
+
+// newTimeGrid 根据序列的时间戳构建网格
+func newTimeGrid(samples []vmapi.Sample) *timeGrid {
+	g := &timeGrid{start: math.MaxInt64, end: math.MinInt64}
+	for _, s := range samples {
+		for i, v := range s.Values {
+			ts := v.Timestamp.UnixMilli()
+			g.start, g.end = min(g.start, ts), max(g.end, ts)
+			if i > 0 {
+				if d := ts - s.Values[i-1].Timestamp.UnixMilli(); d > 0 && (g.step == 0 || d < g.step) {
+					g.step = d
+				}
+			}
+		}
+	}
+	if g.step == 0 {
+		g.step = 1
+	}
+	// 时间戳不规则时限制网格点数，避免极小间隔导致网格过大
+	if (g.end-g.start)/g.step >= graphMaxSlots {
+		g.step = (g.end-g.start)/(graphMaxSlots-1) + 1
+	}
+	g.n = int((g.end-g.start)/g.step) + 1
+	return g
+}
+
+// slots 将序列对齐到网格，NaN/±Inf 与缺失点均为 NaN
+func (g *timeGrid) slots(s vmapi.Sample) []float64 {
+	slots := make([]float64, g.n)
+	for i := range slots {
+		slots[i] = math.NaN()
+	}
+	for _, v := range s.Values {
+		if math.IsInf(v.Value, 0) {
+			continue
+		}
+		idx := int(math.Round(float64(v.Timestamp.UnixMilli()-g.start) / float64(g.step)))
+		if idx >= 0 && idx < g.n {
+			slots[idx] = v.Value
+		}
+	}
+	return slots
+}
+
+// axis 返回 X 轴与时间戳标签两行，axis 为 Y 轴所在列，cols 为绘图区列数
+func (g *timeGrid) axis(axis, cols int) string {
+	layout := "15:04"
+	switch {
+	case g.end-g.start >= (24 * time.Hour).Milliseconds():
+		layout = "01-02 15:04"
+	case g.step < time.Minute.Milliseconds():
+		layout = "15:04:05"
+	}
+	labelLen := len(layout)
+	at := func(col int) time.Time {
+		if cols <= 1 {
+			return time.UnixMilli(g.start)
+		}
+		return time.UnixMilli(g.start + (g.end-g.start)*int64(col)/int64(cols-1))
+	}
+
+	line := []rune(strings.Repeat(" ", axis) + "└" + strings.Repeat("─", max(cols-1, 0)))
+	labels := []rune(strings.Repeat(" ", axis+cols+labelLen))
+
+	// 标签居中于刻度，相邻标签至少间隔 2 列
+	count := max(cols/(labelLen+4), 1)
+	prevEnd := -1
+	for i := 0; i <= count; i++ {
+		col := (cols - 1) * i / count
+		pos := min(max(axis+col-labelLen/2, 0), axis+cols-labelLen)
+		if pos < 0 || (prevEnd >= 0 && pos < prevEnd+2) {
+			continue
+		}
+		if col > 0 {
+			line[axis+col] = '┬'
+		}
+		copy(labels[pos:], []rune(at(col).Format(layout)))
+		prevEnd = pos + labelLen
+	}
+	return string(line) + "\n" + strings.TrimRight(string(labels), " ")
+}
+
+// resample 将网格上的点映射到 cols 列
+// 点数多于列数时取每列内有效点的均值；少于列数时只在相邻两个有效点之间线性插值，
+// 与缺失点相邻的区间取最近的网格点，保持断点
+func resample(slots []float64, cols int) []float64 {
+	n := len(slots)
+	out := make([]float64, cols)
+	if n > cols {
+		for c := range out {
+			lo, hi := c*n/cols, (c+1)*n/cols
+			sum, cnt := 0.0, 0
+			for _, v := range slots[lo:hi] {
+				if !math.IsNaN(v) {
+					sum += v
+					cnt++
+				}
+			}
+			out[c] = math.NaN()
+			if cnt > 0 {
+				out[c] = sum / float64(cnt)
+			}
+		}
+		return out
+	}
+
+	for c := range out {
+		if cols == 1 || n == 1 {
+			out[c] = slots[0]
+			continue
+		}
+		pos := float64(c) * float64(n-1) / float64(cols-1)
+		lo := int(math.Floor(pos))
+		hi := min(lo+1, n-1)
+		frac := pos - float64(lo)
+		switch {
+		case frac == 0:
+			out[c] = slots[lo]
+		case math.IsNaN(slots[lo]) || math.IsNaN(slots[hi]):
+			// 缺失点一侧取最近的网格点，孤立的点也至少占据一列
+			out[c] = slots[lo]
+			if frac > 0.5 {
+				out[c] = slots[hi]
+			}
+		default:
+			out[c] = slots[lo] + (slots[hi]-slots[lo])*frac
+		}
+	}
+	return out
 }
 
 // WriteStrings 字符串列表不支持图表，回退到 table
@@ -61,3 +342,8 @@ func (w *graphWriter) WriteStrings(items []string) error {
 func (w *graphWriter) WriteSeries(series []vmapi.LabelSet) error {
 	return NewTableWriter(w.opts).WriteSeries(series)
 }
+
+// WriteTSDBStatus 基数统计不支持图表，回退到 table
+func (w *graphWriter) WriteTSDBStatus(status *vmapi.TSDBStatus) error {
+	return NewTableWriter(w.opts).WriteTSDBStatus(status)
+}
@@ -0,0 +1,68 @@
+package output
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lwmacct/251203-vm-metrics/internal/vmapi"
+)
+
+// TestGraphGrid 验证缺失点与 NaN 对齐到网格后形成断点，重采样不跨断点插值
+func TestGraphGrid(t *testing.T) {
+	t0 := time.Unix(1700000000, 0)
+	point := func(i int, v float64) vmapi.SampleValue {
+		return vmapi.SampleValue{Timestamp: t0.Add(time.Duration(i) * time.Minute), Value: v}
+	}
+	// 第 2 个点缺失，第 3 个点为 NaN
+	s := vmapi.Sample{Values: []vmapi.SampleValue{point(0, 1), point(1, 2), point(3, math.NaN()), point(4, 4), point(5, 5)}}
+
+	g := newTimeGrid([]vmapi.Sample{s})
+	if g.n != 6 || g.step != time.Minute.Milliseconds() {
+		t.Fatalf("grid n=%d step=%d, want 6 points of 1m", g.n, g.step)
+	}
+	nan := math.NaN()
+	assertFloats(t, "slots", g.slots(s), []float64{1, 2, nan, nan, 4, 5})
+
+	// 11 列: 有效点之间插值，断点内的列保持 NaN
+	assertFloats(t, "resample", resample(g.slots(s), 11), []float64{1, 1.5, 2, 2, nan, nan, nan, nan, 4, 4.5, 5})
+
+	// 3 列: 每列取有效点均值
+	assertFloats(t, "downsample", resample(g.slots(s), 3), []float64{1.5, nan, 4.5})
+}
+
+// assertFloats 比较浮点切片，NaN 视为相等
+func assertFloats(t *testing.T, name string, got, want []float64) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s: len = %d, want %d", name, len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] && !(math.IsNaN(got[i]) && math.IsNaN(want[i])) {
+			t.Errorf("%s[%d] = %v, want %v (got %v)", name, i, got[i], want[i], got)
+		}
+	}
+}
+
+// TestGraphSizeRedirected 验证输出到缓冲区或文件时使用默认尺寸，而不是进程 stdout 所在终端的尺寸
+func TestGraphSizeRedirected(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "graph.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+
+	for _, opts := range []Options{{Writer: &bytes.Buffer{}}, {Writer: f}, {Writer: &bytes.Buffer{}, Terminal: f}} {
+		w := &graphWriter{opts: opts}
+		width, fit, height := w.size(1)
+		if width != graphDefaultWidth || fit || height != graphDefaultHeight {
+			t.Errorf("size = %d, %v, %d, want default size", width, fit, height)
+		}
+		if got := w.totalWidth(); got != graphDefaultWidth {
+			t.Errorf("totalWidth = %d, want %d", got, graphDefaultWidth)
+		}
+	}
+}
@@ -0,0 +1,272 @@
+package output
+
+import (
+	"fmt"
+	"html/template"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lwmacct/251203-vm-metrics/internal/vmapi"
+)
+
+// htmlWriter 自包含的 HTML 报告输出
+// 样式与脚本均内联，无外部依赖；表格点击表头排序，matrix 结果附带 SVG 折线图
+type htmlWriter struct {
+	opts Options
+}
+
+// NewHTMLWriter 创建 HTML 输出 Writer
+func NewHTMLWriter(opts Options) Writer {
+	return &htmlWriter{opts: opts}
+}
+
+// htmlSection 页面中的一节: 可选的标题、图表与表格
+type htmlSection struct {
+	Title   string
+	Chart   template.HTML
+	Headers []string
+	Rows    [][]string
+}
+
+// htmlPage 页面模板数据
+type htmlPage struct {
+	Generated string
+	Summary   string
+	Sections  []htmlSection
+}
+
+// WriteQueryResult 输出查询结果，matrix 结果在表格前绘制折线图
+func (w *htmlWriter) WriteQueryResult(result *vmapi.QueryResult) error {
+	headers, rows := queryTable(result, w.opts)
+	section := htmlSection{Headers: headers, Rows: rows}
+	if result.ResultType == "matrix" {
+		section.Chart = svgChart(result.Samples)
+	}
+	summary := fmt.Sprintf("%s result, %d series", result.ResultType, len(result.Samples))
+	return w.render(summary, []htmlSection{section})
+}
+
+// WriteStrings 输出字符串列表
+func (w *htmlWriter) WriteStrings(items []string) error {
+	rows := make([][]string, len(items))
+	for i, item := range items {
+		rows[i] = []string{item}
+	}
+	return w.render(fmt.Sprintf("%d items", len(items)), []htmlSection{{Headers: []string{"NAME"}, Rows: rows}})
+}
+
+// WriteSeries 输出时间序列
+func (w *htmlWriter) WriteSeries(series []vmapi.LabelSet) error {
+	rows := make([][]string, len(series))
+	for i, s := range series {
+		rows[i] = []string{formatMetric(s)}
+	}
+	return w.render(fmt.Sprintf("%d series", len(series)), []htmlSection{{Headers: []string{"SERIES"}, Rows: rows}})
+}
+
+// WriteTSDBStatus 输出 TSDB 基数统计，每类排行一个表格
+func (w *htmlWriter) WriteTSDBStatus(status *vmapi.TSDBStatus) error {
+	var sections []htmlSection
+	for _, section := range cardinalitySections(status) {
+		if len(section.Entries) == 0 {
+			continue
+		}
+		rows := make([][]string, len(section.Entries))
+		for i, e := range section.Entries {
+			rows[i] = []string{e.Name, strconv.FormatUint(e.Count, 10), formatShare(e.Count, section.Total)}
+		}
+		sections = append(sections, htmlSection{
+			Title:   section.Name,
+			Headers: []string{section.Header, section.Unit, "SHARE"},
+			Rows:    rows,
+		})
+	}
+	summary := fmt.Sprintf("Total series: %d, total label=value pairs: %d", status.TotalSeries, status.TotalLabelValuePairs)
+	return w.render(summary, sections)
+}
+
+// render 渲染完整页面
+func (w *htmlWriter) render(summary string, sections []htmlSection) error {
+	return htmlTemplate.Execute(w.opts.Writer, htmlPage{
+		Generated: time.Now().Format(time.RFC3339),
+		Summary:   summary,
+		Sections:  sections,
+	})
+}
+
+// 折线图尺寸与边距 (SVG 坐标)
+const (
+	chartWidth   = 960
+	chartHeight  = 320
+	chartLeft    = 70
+	chartRight   = 20
+	chartTop     = 10
+	chartBottom  = 30
+	chartXTicks  = 6
+	chartYTicks  = 5
+	chartMaxLine = 30 // 超过该数量的序列不再显示图例
+)
+
+// chartPalette 序列颜色，超出后循环使用
+var chartPalette = []string{
+	"#1f77b4", "#ff7f0e", "#2ca02c", "#d62728", "#9467bd",
+	"#8c564b", "#e377c2", "#7f7f7f", "#bcbd22", "#17becf",
+}
+
+// svgChart 将 matrix 结果绘制为内联 SVG 折线图
+// NaN 与 ±Inf 处断开折线，数据为空时返回空字符串
+func svgChart(samples []vmapi.Sample) template.HTML {
+	var tMin, tMax int64 = math.MaxInt64, math.MinInt64
+	vMin, vMax := math.Inf(1), math.Inf(-1)
+	for _, s := range samples {
+		for _, v := range s.Values {
+			if math.IsNaN(v.Value) || math.IsInf(v.Value, 0) {
+				continue
+			}
+			ts := v.Timestamp.UnixMilli()
+			tMin, tMax = min(tMin, ts), max(tMax, ts)
+			vMin, vMax = math.Min(vMin, v.Value), math.Max(vMax, v.Value)
+		}
+	}
+	if tMin > tMax {
+		return ""
+	}
+	if tMin == tMax {
+		tMin, tMax = tMin-1, tMax+1
+	}
+	if vMin == vMax {
+		vMin, vMax = vMin-1, vMax+1
+	}
+
+	plotW := float64(chartWidth - chartLeft - chartRight)
+	plotH := float64(chartHeight - chartTop - chartBottom)
+	x := func(ts int64) float64 {
+		return chartLeft + float64(ts-tMin)/float64(tMax-tMin)*plotW
+	}
+	y := func(v float64) float64 {
+		return chartTop + (vMax-v)/(vMax-vMin)*plotH
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg class="chart" viewBox="0 0 %d %d" xmlns="http://www.w3.org/2000/svg">`, chartWidth, chartHeight)
+
+	// 网格与坐标轴标签
+	for i := 0; i <= chartYTicks; i++ {
+		v := vMin + (vMax-vMin)*float64(i)/chartYTicks
+		py := y(v)
+		fmt.Fprintf(&b, `<line class="grid" x1="%d" y1="%.1f" x2="%d" y2="%.1f"/>`, chartLeft, py, chartWidth-chartRight, py)
+		fmt.Fprintf(&b, `<text class="axis" x="%d" y="%.1f" text-anchor="end">%s</text>`, chartLeft-6, py+4,
+			template.HTMLEscapeString(strconv.FormatFloat(v, 'g', 4, 64)))
+	}
+	layout := "15:04:05"
+	if time.Duration(tMax-tMin)*time.Millisecond > 24*time.Hour {
+		layout = "01-02 15:04"
+	}
+	for i := 0; i <= chartXTicks; i++ {
+		ts := tMin + (tMax-tMin)*int64(i)/chartXTicks
+		px := x(ts)
+		fmt.Fprintf(&b, `<line class="grid" x1="%.1f" y1="%d" x2="%.1f" y2="%d"/>`, px, chartTop, px, chartHeight-chartBottom)
+		fmt.Fprintf(&b, `<text class="axis" x="%.1f" y="%d" text-anchor="middle">%s</text>`, px, chartHeight-chartBottom+18,
+			time.UnixMilli(ts).Format(layout))
+	}
+
+	// 每个序列一条 path，遇到无效值时以 M 重新起笔
+	for i, s := range samples {
+		var d strings.Builder
+		pen := false
+		for _, v := range s.Values {
+			if math.IsNaN(v.Value) || math.IsInf(v.Value, 0) {
+				pen = false
+				continue
+			}
+			cmd := "L"
+			if !pen {
+				cmd = "M"
+				pen = true
+			}
+			fmt.Fprintf(&d, "%s%.1f,%.1f ", cmd, x(v.Timestamp.UnixMilli()), y(v.Value))
+		}
+		if d.Len() == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, `<path fill="none" stroke="%s" stroke-width="1.5" d="%s"><title>%s</title></path>`,
+			chartPalette[i%len(chartPalette)], strings.TrimSpace(d.String()), template.HTMLEscapeString(formatMetric(s.Metric)))
+	}
+	b.WriteString(`</svg>`)
+
+	// 图例
+	if len(samples) <= chartMaxLine {
+		b.WriteString(`<ul class="legend">`)
+		for i, s := range samples {
+			fmt.Fprintf(&b, `<li><span class="swatch" style="background:%s"></span>%s</li>`,
+				chartPalette[i%len(chartPalette)], template.HTMLEscapeString(formatMetric(s.Metric)))
+		}
+		b.WriteString(`</ul>`)
+	}
+
+	return template.HTML(b.String()) //nolint:gosec // 内容均已转义
+}
+
+// htmlTemplate 页面模板，排序脚本按数值 (可解析时) 或字符串比较，再次点击切换升降序
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>vm-metrics report</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 24px; color: #24292f; }
+header { color: #57606a; margin-bottom: 16px; }
+h2 { font-size: 16px; margin: 24px 0 8px; }
+table { border-collapse: collapse; font-size: 13px; }
+th, td { border: 1px solid #d0d7de; padding: 4px 10px; text-align: left; font-family: ui-monospace, Menlo, Consolas, monospace; }
+th { background: #f6f8fa; cursor: pointer; user-select: none; white-space: nowrap; }
+th.asc::after { content: " \25B2"; }
+th.desc::after { content: " \25BC"; }
+tr:nth-child(even) td { background: #fbfcfd; }
+.chart { width: 100%; max-width: 960px; height: auto; }
+.chart .grid { stroke: #eaeef2; }
+.chart .axis { font-size: 11px; fill: #57606a; }
+.legend { list-style: none; padding: 0; font-size: 12px; font-family: ui-monospace, Menlo, Consolas, monospace; }
+.legend li { margin: 2px 0; }
+.swatch { display: inline-block; width: 12px; height: 12px; margin-right: 6px; vertical-align: middle; }
+</style>
+</head>
+<body>
+<header>vm-metrics report &middot; {{.Generated}} &middot; {{.Summary}}</header>
+{{range .Sections}}
+<section>
+{{if .Title}}<h2>{{.Title}}</h2>{{end}}
+{{.Chart}}
+<table class="sortable">
+<thead><tr>{{range .Headers}}<th>{{.}}</th>{{end}}</tr></thead>
+<tbody>
+{{range .Rows}}<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{end}}</tbody>
+</table>
+</section>
+{{end}}
+<script>
+var num = /^\s*[-+]?(\d+\.?\d*|\.\d+)([eE][-+]?\d+)?%?\s*$/;
+document.querySelectorAll("table.sortable").forEach(function (table) {
+  table.querySelectorAll("th").forEach(function (th, col) {
+    th.addEventListener("click", function () {
+      var asc = !th.classList.contains("asc");
+      table.querySelectorAll("th").forEach(function (h) { h.classList.remove("asc", "desc"); });
+      th.classList.add(asc ? "asc" : "desc");
+      var body = table.tBodies[0];
+      var rows = Array.prototype.slice.call(body.rows);
+      rows.sort(function (a, b) {
+        var x = a.cells[col].textContent, y = b.cells[col].textContent;
+        var r = (num.test(x) && num.test(y)) ? parseFloat(x) - parseFloat(y) : x.localeCompare(y, undefined, {numeric: true});
+        return asc ? r : -r;
+      });
+      rows.forEach(function (r) { body.appendChild(r); });
+    });
+  });
+});
+</script>
+</body>
+</html>
+`))
@@ -31,6 +31,11 @@ func (w *jsonWriter) WriteSeries(series []vmapi.LabelSet) error {
 	return w.writeJSON(series)
 }
 
+// WriteTSDBStatus 输出 TSDB 基数统计
+func (w *jsonWriter) WriteTSDBStatus(status *vmapi.TSDBStatus) error {
+	return w.writeJSON(status)
+}
+
 // writeJSON 通用 JSON 输出
 func (w *jsonWriter) writeJSON(v any) error {
 	enc := json.NewEncoder(w.opts.Writer)
@@ -0,0 +1,68 @@
+package output
+
+import (
+	"slices"
+	"strings"
+)
+
+// displayLabels 返回用于展示的标签 (METRIC 列与宽表列名)
+// 指定 Columns 时只保留这些标签，否则去掉 Without 中的标签；NoMetricName 时去掉指标名
+// 未设置任何选项时直接返回原标签
+func (o Options) displayLabels(labels map[string]string) map[string]string {
+	if len(o.Columns) == 0 && len(o.Without) == 0 && !o.NoMetricName {
+		return labels
+	}
+	filtered := make(map[string]string, len(labels))
+	for k, v := range labels {
+		switch {
+		case k == "__name__":
+			if o.NoMetricName {
+				continue
+			}
+		case len(o.Columns) > 0:
+			if !slices.Contains(o.Columns, k) {
+				continue
+			}
+		case slices.Contains(o.Without, k):
+			continue
+		}
+		filtered[k] = v
+	}
+	return filtered
+}
+
+// labelHeaders 返回序列标识列的表头
+// 指定 Columns 时指标名与每个标签各占一列 (NoMetricName 时不输出指标名列)，否则只有一个 METRIC 列
+// upper 为 true 时使用大写表头 (table/markdown)
+func (o Options) labelHeaders(upper bool) []string {
+	metric := "metric"
+	columns := slices.Clone(o.Columns)
+	if upper {
+		metric = "METRIC"
+		for i, c := range columns {
+			columns[i] = strings.ToUpper(c)
+		}
+	}
+	if len(o.Columns) == 0 {
+		return []string{metric}
+	}
+	if o.NoMetricName {
+		return columns
+	}
+	return append([]string{metric}, columns...)
+}
+
+// labelCells 返回序列标识列的值，与 labelHeaders 对应
+func (o Options) labelCells(labels map[string]string) []string {
+	if len(o.Columns) == 0 {
+		return []string{formatMetric(o.displayLabels(labels))}
+	}
+	cells := make([]string, 0, len(o.Columns)+1)
+	if !o.NoMetricName {
+		cells = append(cells, labels["__name__"])
+	}
+	for _, c := range o.Columns {
+		cells = append(cells, labels[c])
+	}
+	return cells
+}
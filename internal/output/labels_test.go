@@ -0,0 +1,56 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/lwmacct/251203-vm-metrics/internal/vmapi"
+)
+
+// TestLabelColumns 验证 Columns、Without 与 NoMetricName 对 CSV 标识列的影响
+func TestLabelColumns(t *testing.T) {
+	ts := time.Unix(1700000000, 0).UTC()
+	result := &vmapi.QueryResult{
+		ResultType: "vector",
+		Samples: []vmapi.Sample{
+			{Metric: map[string]string{"__name__": "up", "pod": "a", "namespace": "x", "job": "kube"}, Value: vmapi.SampleValue{Timestamp: ts, Value: 1}},
+			{Metric: map[string]string{"__name__": "up", "pod": "b"}, Value: vmapi.SampleValue{Timestamp: ts, Value: 0}},
+		},
+	}
+
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{"columns", Options{Columns: []string{"pod", "namespace"}}, `metric,pod,namespace,value,timestamp
+up,a,x,1,2023-11-14T22:13:20Z
+up,b,,0,2023-11-14T22:13:20Z
+`},
+		{"columns without name", Options{Columns: []string{"pod"}, NoMetricName: true}, `pod,value,timestamp
+a,1,2023-11-14T22:13:20Z
+b,0,2023-11-14T22:13:20Z
+`},
+		{"without", Options{Without: []string{"job", "namespace"}, NoMetricName: true}, `metric,value,timestamp
+"{pod=""a""}",1,2023-11-14T22:13:20Z
+"{pod=""b""}",0,2023-11-14T22:13:20Z
+`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tt.opts.Writer = &buf
+			w, err := New("csv", tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := w.WriteQueryResult(result); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("output =\n%s\nwant\n%s", buf.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package output
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/lwmacct/251203-vm-metrics/internal/vmapi"
+)
+
+// markdownWriter GitHub Flavored Markdown 表格输出
+// GFM 表格必须有表头，NoHeaders 对该格式无效
+type markdownWriter struct {
+	opts Options
+}
+
+// NewMarkdownWriter 创建 Markdown 输出 Writer
+func NewMarkdownWriter(opts Options) Writer {
+	return &markdownWriter{opts: opts}
+}
+
+// WriteQueryResult 输出查询结果
+func (w *markdownWriter) WriteQueryResult(result *vmapi.QueryResult) error {
+	headers, rows := queryTable(result, w.opts)
+	bw := bufio.NewWriter(w.opts.Writer)
+	writeMarkdownTable(bw, headers, rows)
+	return bw.Flush()
+}
+
+// WriteStrings 输出字符串列表
+func (w *markdownWriter) WriteStrings(items []string) error {
+	rows := make([][]string, len(items))
+	for i, item := range items {
+		rows[i] = []string{item}
+	}
+	bw := bufio.NewWriter(w.opts.Writer)
+	writeMarkdownTable(bw, []string{"NAME"}, rows)
+	return bw.Flush()
+}
+
+// WriteSeries 输出时间序列
+func (w *markdownWriter) WriteSeries(series []vmapi.LabelSet) error {
+	rows := make([][]string, len(series))
+	for i, s := range series {
+		rows[i] = []string{formatMetric(s)}
+	}
+	bw := bufio.NewWriter(w.opts.Writer)
+	writeMarkdownTable(bw, []string{"SERIES"}, rows)
+	return bw.Flush()
+}
+
+// WriteTSDBStatus 输出 TSDB 基数统计，每类排行一个小节
+func (w *markdownWriter) WriteTSDBStatus(status *vmapi.TSDBStatus) error {
+	bw := bufio.NewWriter(w.opts.Writer)
+	_, _ = fmt.Fprintf(bw, "Total series: %d, total label=value pairs: %d\n",
+		status.TotalSeries, status.TotalLabelValuePairs)
+
+	for _, section := range cardinalitySections(status) {
+		if len(section.Entries) == 0 {
+			continue
+		}
+		rows := make([][]string, len(section.Entries))
+		for i, e := range section.Entries {
+			rows[i] = []string{e.Name, strconv.FormatUint(e.Count, 10), formatShare(e.Count, section.Total)}
+		}
+		_, _ = fmt.Fprintf(bw, "\n### %s\n\n", escapeMarkdown(section.Name))
+		writeMarkdownTable(bw, []string{section.Header, section.Unit, "SHARE"}, rows)
+	}
+	return bw.Flush()
+}
+
+// writeMarkdownTable 输出 GFM 表格
+func writeMarkdownTable(w io.Writer, headers []string, rows [][]string) {
+	writeRow := func(cells []string) {
+		_, _ = fmt.Fprint(w, "|")
+		for _, c := range cells {
+			_, _ = fmt.Fprintf(w, " %s |", escapeMarkdown(c))
+		}
+		_, _ = fmt.Fprintln(w)
+	}
+
+	writeRow(headers)
+	_, _ = fmt.Fprintln(w, "|"+strings.Repeat(" --- |", len(headers)))
+	for _, row := range rows {
+		writeRow(row)
+	}
+}
+
+// markdownEscaper 转义会破坏表格结构或被解释为格式的字符
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"|", `\|`,
+	"*", `\*`,
+	"`", "\\`",
+	"<", "&lt;",
+	"\r\n", "<br>",
+	"\n", "<br>",
+)
+
+// escapeMarkdown 转义单元格文本
+func escapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+// queryTable 将查询结果整理为表头与行 (markdown/html 共用)
+// matrix 按 Layout 输出长表或宽表
+func queryTable(result *vmapi.QueryResult, opts Options) ([]string, [][]string) {
+	switch result.ResultType {
+	case "vector":
+		rows := make([][]string, 0, len(result.Samples))
+		for _, s := range result.Samples {
+			rows = append(rows, append(opts.labelCells(s.Metric), opts.formatValue(s.Value.Value), opts.formatTime(s.Value.Timestamp)))
+		}
+		return append(opts.labelHeaders(true), "VALUE", "TIMESTAMP"), rows
+	case "matrix":
+		if opts.Layout == LayoutWide {
+			t := pivotMatrix(result.Samples, opts)
+			rows := make([][]string, len(t.Timestamps))
+			for i, ts := range t.Timestamps {
+				rows[i] = append([]string{opts.formatTime(ts)}, t.Cells[i]...)
+			}
+			return append([]string{"TIMESTAMP"}, t.Headers...), rows
+		}
+		var rows [][]string
+		for _, s := range result.Samples {
+			labels := opts.labelCells(s.Metric)
+			for _, v := range s.Values {
+				rows = append(rows, append(slices.Clip(labels), opts.formatValue(v.Value), opts.formatTime(v.Timestamp)))
+			}
+		}
+		return append(opts.labelHeaders(true), "VALUE", "TIMESTAMP"), rows
+	case "scalar":
+		if result.Scalar != nil {
+			return []string{"VALUE", "TIMESTAMP"}, [][]string{{opts.formatValue(result.Scalar.Value), opts.formatTime(result.Scalar.Timestamp)}}
+		}
+	case "string":
+		if result.String != nil {
+			return []string{"VALUE", "TIMESTAMP"}, [][]string{{result.String.Value, opts.formatTime(result.String.Timestamp)}}
+		}
+	}
+	return []string{"VALUE", "TIMESTAMP"}, nil
+}
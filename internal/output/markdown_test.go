@@ -0,0 +1,69 @@
+package output
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lwmacct/251203-vm-metrics/internal/vmapi"
+)
+
+// TestMarkdownWriter 验证 GFM 表格结构与单元格中竖线与反斜杠的转义
+func TestMarkdownWriter(t *testing.T) {
+	ts := time.Unix(1700000000, 0).UTC()
+	result := &vmapi.QueryResult{
+		ResultType: "vector",
+		Samples: []vmapi.Sample{
+			{Metric: map[string]string{"__name__": "up", "path": "a|b\nc"}, Value: vmapi.SampleValue{Timestamp: ts, Value: 1}},
+		},
+	}
+
+	var buf bytes.Buffer
+	w, err := New("markdown", Options{Writer: &buf})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteQueryResult(result); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `| METRIC | VALUE | TIMESTAMP |
+| --- | --- | --- |
+| up{path="a\|b\\nc"} | 1 | 2023-11-14T22:13:20Z |
+`
+	if buf.String() != want {
+		t.Errorf("output =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+// TestSVGChartGaps 验证折线在 NaN 处断开，且序列名被转义
+func TestSVGChartGaps(t *testing.T) {
+	t0 := time.Unix(1700000000, 0)
+	values := []vmapi.SampleValue{
+		{Timestamp: t0, Value: 1},
+		{Timestamp: t0.Add(time.Minute), Value: 2},
+		{Timestamp: t0.Add(2 * time.Minute), Value: math.NaN()},
+		{Timestamp: t0.Add(3 * time.Minute), Value: 3},
+	}
+	chart := string(svgChart([]vmapi.Sample{{Metric: map[string]string{"job": "<x>"}, Values: values}}))
+
+	var path string
+	for _, part := range strings.Split(chart, "<path ") {
+		if strings.Contains(part, ` d="`) {
+			path = part[strings.Index(part, ` d="`)+4:]
+			path = path[:strings.IndexByte(path, '"')]
+		}
+	}
+	if got := strings.Count(path, "M"); got != 2 {
+		t.Errorf("path %q has %d segments, want 2", path, got)
+	}
+	if strings.Contains(chart, "<x>") || !strings.Contains(chart, "&lt;x&gt;") {
+		t.Error("series name is not escaped")
+	}
+
+	if svgChart([]vmapi.Sample{{Values: values[2:3]}}) != "" {
+		t.Error("expected empty chart when all values are NaN")
+	}
+}
@@ -0,0 +1,205 @@
+package output
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lwmacct/251203-vm-metrics/internal/vmapi"
+)
+
+// promDefaultName 结果中没有 __name__ 时 (如 rate() 的结果) 使用的指标名
+// 文本格式要求每行都有指标名
+const promDefaultName = "query_result"
+
+// promWriter Prometheus 文本格式 (exposition) 输出
+// 每个样本一行: metric{labels} value timestamp_ms，可直接由 import prometheus 导入
+// openmetrics 为同一格式的别名，时间戳同样为毫秒
+type promWriter struct {
+	opts Options
+}
+
+// NewPrometheusWriter 创建 Prometheus 文本格式输出 Writer
+func NewPrometheusWriter(opts Options) Writer {
+	return &promWriter{opts: opts}
+}
+
+// WriteQueryResult 输出查询结果，vector 每个序列一行，matrix 每个点一行
+func (w *promWriter) WriteQueryResult(result *vmapi.QueryResult) error {
+	bw := bufio.NewWriter(w.opts.Writer)
+
+	switch result.ResultType {
+	case "vector", "matrix":
+		samples := w.sortByName(result.Samples)
+		lastName := ""
+		for i, s := range samples {
+			name := promMetricName(s.Metric)
+			if i == 0 || name != lastName {
+				w.writeType(bw, name)
+				lastName = name
+			}
+			series := formatPromSeries(name, s.Metric)
+			if result.ResultType == "vector" {
+				writePromSample(bw, series, s.Value)
+				continue
+			}
+			for _, v := range s.Values {
+				writePromSample(bw, series, v)
+			}
+		}
+	case "scalar":
+		if result.Scalar != nil {
+			w.writeType(bw, "scalar")
+			writePromSample(bw, "scalar", *result.Scalar)
+		}
+	case "string":
+		return fmt.Errorf("string results cannot be written in Prometheus text format")
+	}
+
+	return bw.Flush()
+}
+
+// sortByName 需要 # TYPE 时按指标名稳定排序，保证同名序列连续 (文本格式要求同一指标族不被打断)
+func (w *promWriter) sortByName(samples []vmapi.Sample) []vmapi.Sample {
+	if w.opts.TypeHint == "" {
+		return samples
+	}
+	sorted := make([]vmapi.Sample, len(samples))
+	copy(sorted, samples)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return promMetricName(sorted[i].Metric) < promMetricName(sorted[j].Metric)
+	})
+	return sorted
+}
+
+// writeType 按 TypeHint 输出 # TYPE 行
+func (w *promWriter) writeType(bw io.Writer, name string) {
+	if w.opts.TypeHint == "" {
+		return
+	}
+	_, _ = fmt.Fprintf(bw, "# TYPE %s %s\n", name, promType(name, w.opts.TypeHint))
+}
+
+// WriteStrings 输出字符串列表，每行一个
+func (w *promWriter) WriteStrings(items []string) error {
+	bw := bufio.NewWriter(w.opts.Writer)
+	for _, item := range items {
+		_, _ = fmt.Fprintln(bw, item)
+	}
+	return bw.Flush()
+}
+
+// WriteSeries 输出时间序列选择器，每行一个 (可作为 match[] 使用)
+func (w *promWriter) WriteSeries(series []vmapi.LabelSet) error {
+	bw := bufio.NewWriter(w.opts.Writer)
+	for _, s := range series {
+		_, _ = fmt.Fprintln(bw, formatPromSeries(s["__name__"], s))
+	}
+	return bw.Flush()
+}
+
+// WriteTSDBStatus 以指标形式输出 TSDB 基数统计
+func (w *promWriter) WriteTSDBStatus(status *vmapi.TSDBStatus) error {
+	bw := bufio.NewWriter(w.opts.Writer)
+	gauge := func(name string) {
+		if w.opts.TypeHint != "" {
+			_, _ = fmt.Fprintf(bw, "# TYPE %s gauge\n", name)
+		}
+	}
+
+	gauge("tsdb_status_total_series")
+	_, _ = fmt.Fprintf(bw, "tsdb_status_total_series %d\n", status.TotalSeries)
+	gauge("tsdb_status_total_label_value_pairs")
+	_, _ = fmt.Fprintf(bw, "tsdb_status_total_label_value_pairs %d\n", status.TotalLabelValuePairs)
+
+	gauge("tsdb_status_entries")
+	for _, section := range cardinalitySections(status) {
+		for _, e := range section.Entries {
+			series := formatPromSeries("tsdb_status_entries", map[string]string{"section": section.Name, "name": e.Name})
+			_, _ = fmt.Fprintf(bw, "%s %d\n", series, e.Count)
+		}
+	}
+	return bw.Flush()
+}
+
+// promMetricName 返回序列的指标名，没有时使用默认名称
+func promMetricName(labels map[string]string) string {
+	if name := labels["__name__"]; name != "" {
+		return name
+	}
+	return promDefaultName
+}
+
+// promType 返回 # TYPE 的类型，hint 为 auto 时按指标名后缀推断
+func promType(name, hint string) string {
+	if hint != "auto" {
+		return hint
+	}
+	switch {
+	case strings.HasSuffix(name, "_total"):
+		return "counter"
+	case strings.HasSuffix(name, "_bucket"), strings.HasSuffix(name, "_count"), strings.HasSuffix(name, "_sum"):
+		return "untyped"
+	default:
+		return "gauge"
+	}
+}
+
+// formatPromSeries 格式化为 name{k="v", ...}，标签按名称排序，值按文本格式规则转义
+func formatPromSeries(name string, labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		if k != "__name__" {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return name
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(name)
+	b.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(k)
+		b.WriteString(`="`)
+		b.WriteString(escapeLabelValue(labels[k]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// promLabelEscaper 文本格式的标签值只转义反斜杠、双引号与换行
+var promLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escapeLabelValue 转义标签值
+func escapeLabelValue(v string) string {
+	return promLabelEscaper.Replace(v)
+}
+
+// writePromSample 输出一行样本
+func writePromSample(w io.Writer, series string, v vmapi.SampleValue) {
+	_, _ = fmt.Fprintf(w, "%s %s %d\n", series, formatPromValue(v.Value), v.Timestamp.UnixMilli())
+}
+
+// formatPromValue 格式化样本值，特殊值使用 NaN、+Inf、-Inf
+func formatPromValue(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
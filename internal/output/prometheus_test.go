@@ -0,0 +1,57 @@
+package output
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/lwmacct/251203-vm-metrics/internal/vmapi"
+)
+
+// TestPrometheusWriter 验证文本格式输出的标签转义、特殊值、毫秒时间戳与 # TYPE 提示
+func TestPrometheusWriter(t *testing.T) {
+	ts := time.UnixMilli(1700000000123)
+	result := &vmapi.QueryResult{
+		ResultType: "matrix",
+		Samples: []vmapi.Sample{
+			{
+				Metric: map[string]string{"__name__": "up", "job": "a"},
+				Values: []vmapi.SampleValue{{Timestamp: ts, Value: 1}},
+			},
+			{
+				Metric: map[string]string{"path": `C:\tmp "x"` + "\n", "code": "200"},
+				Values: []vmapi.SampleValue{{Timestamp: ts, Value: 0.5}, {Timestamp: ts.Add(time.Minute), Value: math.Inf(1)}},
+			},
+			{
+				Metric: map[string]string{"__name__": "http_requests_total"},
+				Values: []vmapi.SampleValue{{Timestamp: ts, Value: math.NaN()}},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	w, err := New("prom", Options{Writer: &buf, TypeHint: "auto"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteQueryResult(result); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `# TYPE http_requests_total counter
+http_requests_total NaN 1700000000123
+# TYPE query_result gauge
+query_result{code="200",path="C:\\tmp \"x\"\n"} 0.5 1700000000123
+query_result{code="200",path="C:\\tmp \"x\"\n"} +Inf 1700000060123
+# TYPE up gauge
+up{job="a"} 1 1700000000123
+`
+	if buf.String() != want {
+		t.Errorf("output =\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	if _, err := New("prom", Options{TypeHint: "bogus"}); err == nil {
+		t.Error("expected error for unsupported type hint")
+	}
+}
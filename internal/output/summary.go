@@ -0,0 +1,220 @@
+package output
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/lwmacct/251203-vm-metrics/internal/vmapi"
+)
+
+// sparkWidth sparkline 的最大字符数，点数更多时按列取均值
+const sparkWidth = 24
+
+// sparkBlocks sparkline 使用的字符，从低到高
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// summaryColumns summary 格式可用于排序的列
+var summaryColumns = []string{"name", "min", "max", "avg", "last", "p95", "stddev", "points"}
+
+// seriesSummary 单个序列的统计值，忽略 NaN 与 ±Inf
+type seriesSummary struct {
+	Name   string
+	Spark  string
+	Min    float64 // 没有有效点时以下统计值均为 NaN
+	Max    float64
+	Avg    float64
+	Last   float64
+	P95    float64
+	StdDev float64
+	Points int // 有效点数
+}
+
+// value 返回排序列的数值
+func (s *seriesSummary) value(column string) float64 {
+	switch column {
+	case "min":
+		return s.Min
+	case "max":
+		return s.Max
+	case "avg":
+		return s.Avg
+	case "last":
+		return s.Last
+	case "p95":
+		return s.P95
+	case "stddev":
+		return s.StdDev
+	case "points":
+		return float64(s.Points)
+	}
+	return math.NaN()
+}
+
+// summaryWriter 每个序列一行的统计摘要输出 (sparkline + min/max/avg/last/p95/stddev/点数)
+// 仅支持 matrix 类型 (range query)，其他类型回退到 table 格式
+type summaryWriter struct {
+	opts Options
+}
+
+// NewSummaryWriter 创建统计摘要输出 Writer
+func NewSummaryWriter(opts Options) Writer {
+	return &summaryWriter{opts: opts}
+}
+
+// WriteQueryResult 输出查询结果的统计摘要，按 SortBy 排序并截取前 Top 个序列
+func (w *summaryWriter) WriteQueryResult(result *vmapi.QueryResult) error {
+	if result.ResultType != "matrix" {
+		return NewTableWriter(w.opts).WriteQueryResult(result)
+	}
+
+	rows := summarize(result.Samples, w.opts)
+	sortSummaries(rows, w.opts.SortBy, w.opts.Desc)
+	if w.opts.Top > 0 && len(rows) > w.opts.Top {
+		rows = rows[:w.opts.Top]
+	}
+
+	tw := tabwriter.NewWriter(w.opts.Writer, 0, 0, 2, ' ', 0)
+	if !w.opts.NoHeaders {
+		_, _ = fmt.Fprintln(tw, "METRIC\tSPARKLINE\tMIN\tMAX\tAVG\tLAST\tP95\tSTDDEV\tPOINTS")
+	}
+	for _, r := range rows {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%d\n",
+			r.Name, r.Spark,
+			formatStat(r.Min), formatStat(r.Max), formatStat(r.Avg), formatStat(r.Last),
+			formatStat(r.P95), formatStat(r.StdDev), r.Points,
+		)
+	}
+	return tw.Flush()
+}
+
+// WriteStrings 字符串列表不支持摘要，回退到 table
+func (w *summaryWriter) WriteStrings(items []string) error {
+	return NewTableWriter(w.opts).WriteStrings(items)
+}
+
+// WriteSeries 时间序列列表不支持摘要，回退到 table
+func (w *summaryWriter) WriteSeries(series []vmapi.LabelSet) error {
+	return NewTableWriter(w.opts).WriteSeries(series)
+}
+
+// WriteTSDBStatus 基数统计不支持摘要，回退到 table
+func (w *summaryWriter) WriteTSDBStatus(status *vmapi.TSDBStatus) error {
+	return NewTableWriter(w.opts).WriteTSDBStatus(status)
+}
+
+// summarize 计算每个序列的统计值
+// sparkline 使用所有序列共享的时间网格，缺失点显示为空格，不同序列的同一列对应同一时间
+func summarize(samples []vmapi.Sample, opts Options) []*seriesSummary {
+	var grid *timeGrid
+	if len(samples) > 0 {
+		grid = newTimeGrid(samples)
+	}
+
+	rows := make([]*seriesSummary, 0, len(samples))
+	for _, s := range samples {
+		var values []float64
+		for _, v := range s.Values {
+			if !math.IsNaN(v.Value) && !math.IsInf(v.Value, 0) {
+				values = append(values, v.Value)
+			}
+		}
+
+		r := &seriesSummary{Name: formatMetric(opts.displayLabels(s.Metric)), Points: len(values)}
+		if len(values) == 0 {
+			nan := math.NaN()
+			r.Min, r.Max, r.Avg, r.Last, r.P95, r.StdDev = nan, nan, nan, nan, nan, nan
+			rows = append(rows, r)
+			continue
+		}
+
+		r.Min, r.Max = values[0], values[0]
+		sum := 0.0
+		for _, v := range values {
+			r.Min, r.Max = math.Min(r.Min, v), math.Max(r.Max, v)
+			sum += v
+		}
+		r.Avg = sum / float64(len(values))
+		r.Last = values[len(values)-1]
+
+		variance := 0.0
+		for _, v := range values {
+			variance += (v - r.Avg) * (v - r.Avg)
+		}
+		r.StdDev = math.Sqrt(variance / float64(len(values)))
+
+		sorted := append([]float64(nil), values...)
+		sort.Float64s(sorted)
+		r.P95 = quantile(sorted, 0.95)
+
+		r.Spark = sparkline(resample(grid.slots(s), min(grid.n, sparkWidth)), r.Min, r.Max)
+		rows = append(rows, r)
+	}
+	return rows
+}
+
+// quantile 返回已排序数据的 q 分位数，相邻两点间线性插值
+func quantile(sorted []float64, q float64) float64 {
+	if len(sorted) == 1 {
+		return sorted[0]
+	}
+	pos := q * float64(len(sorted)-1)
+	lo := int(math.Floor(pos))
+	hi := min(lo+1, len(sorted)-1)
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(pos-float64(lo))
+}
+
+// sparkline 按 [lo, hi] 将数值映射到方块字符，NaN 显示为空格
+func sparkline(values []float64, lo, hi float64) string {
+	var b strings.Builder
+	for _, v := range values {
+		switch {
+		case math.IsNaN(v):
+			b.WriteRune(' ')
+		case hi == lo:
+			b.WriteRune(sparkBlocks[0])
+		default:
+			idx := int(math.Round((v - lo) / (hi - lo) * float64(len(sparkBlocks)-1)))
+			b.WriteRune(sparkBlocks[min(max(idx, 0), len(sparkBlocks)-1)])
+		}
+	}
+	return b.String()
+}
+
+// sortSummaries 按列稳定排序，数值列中没有有效点的序列始终排在最后
+func sortSummaries(rows []*seriesSummary, column string, desc bool) {
+	if column == "" {
+		return
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		if column == "name" {
+			if desc {
+				return a.Name > b.Name
+			}
+			return a.Name < b.Name
+		}
+		va, vb := a.value(column), b.value(column)
+		switch {
+		case math.IsNaN(va):
+			return false
+		case math.IsNaN(vb):
+			return true
+		case desc:
+			return va > vb
+		default:
+			return va < vb
+		}
+	})
+}
+
+// formatStat 格式化统计值，保留 6 位有效数字
+func formatStat(v float64) string {
+	if math.IsNaN(v) {
+		return "-"
+	}
+	return strconv.FormatFloat(v, 'g', 6, 64)
+}
@@ -0,0 +1,51 @@
+package output
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/lwmacct/251203-vm-metrics/internal/vmapi"
+)
+
+// TestSummaryWriter 验证统计值、sparkline、按列降序排序与 Top 截取
+func TestSummaryWriter(t *testing.T) {
+	t0 := time.Unix(1700000000, 0)
+	series := func(pod string, values ...float64) vmapi.Sample {
+		s := vmapi.Sample{Metric: map[string]string{"__name__": "up", "pod": pod}}
+		for i, v := range values {
+			s.Values = append(s.Values, vmapi.SampleValue{Timestamp: t0.Add(time.Duration(i) * time.Minute), Value: v})
+		}
+		return s
+	}
+	result := &vmapi.QueryResult{
+		ResultType: "matrix",
+		Samples: []vmapi.Sample{
+			series("a", 1, 2, 3, 4),
+			series("b", 0, math.NaN(), 8, 4),
+			series("c", math.NaN()),
+		},
+	}
+
+	var buf bytes.Buffer
+	w, err := New("summary", Options{Writer: &buf, SortBy: "max", Desc: true, Top: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteQueryResult(result); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `METRIC       SPARKLINE  MIN  MAX  AVG  LAST  P95   STDDEV   POINTS
+up{pod="b"}  ▁ █▅       0    8    4    4     7.6   3.26599  3
+up{pod="a"}  ▁▃▆█       1    4    2.5  4     3.85  1.11803  4
+`
+	if buf.String() != want {
+		t.Errorf("output =\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	if _, err := New("summary", Options{SortBy: "median"}); err == nil {
+		t.Error("expected error for unsupported sort column")
+	}
+}
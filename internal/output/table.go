@@ -5,7 +5,6 @@ import (
 	"sort"
 	"strings"
 	"text/tabwriter"
-	"time"
 
 	"github.com/lwmacct/251203-vm-metrics/internal/vmapi"
 )
@@ -29,14 +28,17 @@ func (w *tableWriter) WriteQueryResult(result *vmapi.QueryResult) error {
 	case "vector":
 		return w.writeVector(tw, result.Samples)
 	case "matrix":
+		if w.opts.Layout == LayoutWide {
+			return w.writeWide(tw, result.Samples)
+		}
 		return w.writeMatrix(tw, result.Samples)
 	case "scalar":
 		if result.Scalar != nil {
-			_, _ = fmt.Fprintf(tw, "%v\t@%s\n", result.Scalar.Value, result.Scalar.Timestamp.Format(time.RFC3339))
+			_, _ = fmt.Fprintf(tw, "%s\t@%s\n", w.opts.formatValue(result.Scalar.Value), w.opts.formatTime(result.Scalar.Timestamp))
 		}
 	case "string":
 		if result.String != nil {
-			_, _ = fmt.Fprintf(tw, "%s\t@%s\n", result.String.Value, result.String.Timestamp.Format(time.RFC3339))
+			_, _ = fmt.Fprintf(tw, "%s\t@%s\n", result.String.Value, w.opts.formatTime(result.String.Timestamp))
 		}
 	}
 
@@ -46,15 +48,14 @@ func (w *tableWriter) WriteQueryResult(result *vmapi.QueryResult) error {
 // writeVector 输出 instant query 结果
 func (w *tableWriter) writeVector(tw *tabwriter.Writer, samples []vmapi.Sample) error {
 	if !w.opts.NoHeaders {
-		_, _ = fmt.Fprintln(tw, "METRIC\tVALUE\tTIMESTAMP")
+		_, _ = fmt.Fprintf(tw, "%s\t%s\tTIMESTAMP\n", w.labelColumns(nil, w.opts.labelHeaders(true)), w.colorize(nil, "VALUE"))
 	}
 
 	for _, s := range samples {
-		metric := formatMetric(s.Metric)
-		_, _ = fmt.Fprintf(tw, "%s\t%v\t%s\n",
-			metric,
-			s.Value.Value,
-			s.Value.Timestamp.Format(time.RFC3339),
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\n",
+			w.labelColumns(s.Metric, w.opts.labelCells(s.Metric)),
+			w.colorize(s.Metric, w.opts.formatValue(s.Value.Value)),
+			w.opts.formatTime(s.Value.Timestamp),
 		)
 	}
 
@@ -64,16 +65,16 @@ func (w *tableWriter) writeVector(tw *tabwriter.Writer, samples []vmapi.Sample)
 // writeMatrix 输出 range query 结果
 func (w *tableWriter) writeMatrix(tw *tabwriter.Writer, samples []vmapi.Sample) error {
 	if !w.opts.NoHeaders {
-		_, _ = fmt.Fprintln(tw, "METRIC\tVALUE\tTIMESTAMP")
+		_, _ = fmt.Fprintf(tw, "%s\t%s\tTIMESTAMP\n", w.labelColumns(nil, w.opts.labelHeaders(true)), w.colorize(nil, "VALUE"))
 	}
 
 	for _, s := range samples {
-		metric := formatMetric(s.Metric)
+		labels := w.labelColumns(s.Metric, w.opts.labelCells(s.Metric))
 		for _, v := range s.Values {
-			_, _ = fmt.Fprintf(tw, "%s\t%v\t%s\n",
-				metric,
-				v.Value,
-				v.Timestamp.Format(time.RFC3339),
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\n",
+				labels,
+				w.colorize(s.Metric, w.opts.formatValue(v.Value)),
+				w.opts.formatTime(v.Timestamp),
 			)
 		}
 	}
@@ -81,6 +82,29 @@ func (w *tableWriter) writeMatrix(tw *tabwriter.Writer, samples []vmapi.Sample)
 	return nil
 }
 
+// writeWide 以宽表输出 range query 结果: 第一列为时间戳，每个序列一列
+func (w *tableWriter) writeWide(tw *tabwriter.Writer, samples []vmapi.Sample) error {
+	t := pivotMatrix(samples, w.opts)
+
+	if !w.opts.NoHeaders {
+		_, _ = fmt.Fprint(tw, "TIMESTAMP")
+		for j, h := range t.Headers {
+			_, _ = fmt.Fprintf(tw, "\t%s", w.colorize(t.Series[j].Metric, h))
+		}
+		_, _ = fmt.Fprintln(tw)
+	}
+
+	for i, ts := range t.Timestamps {
+		_, _ = fmt.Fprint(tw, w.opts.formatTime(ts))
+		for j, cell := range t.Cells[i] {
+			_, _ = fmt.Fprintf(tw, "\t%s", w.colorize(t.Series[j].Metric, cell))
+		}
+		_, _ = fmt.Fprintln(tw)
+	}
+
+	return nil
+}
+
 // WriteStrings 输出字符串列表
 func (w *tableWriter) WriteStrings(items []string) error {
 	for _, item := range items {
@@ -101,6 +125,52 @@ func (w *tableWriter) WriteSeries(series []vmapi.LabelSet) error {
 	return nil
 }
 
+// WriteTSDBStatus 输出 TSDB 基数统计
+// 每类排行一个表格，SHARE 列为条目占总数的比例
+func (w *tableWriter) WriteTSDBStatus(status *vmapi.TSDBStatus) error {
+	_, _ = fmt.Fprintf(w.opts.Writer, "Total series: %d, total label=value pairs: %d\n",
+		status.TotalSeries, status.TotalLabelValuePairs)
+
+	for _, section := range cardinalitySections(status) {
+		if len(section.Entries) == 0 {
+			continue
+		}
+		_, _ = fmt.Fprintln(w.opts.Writer)
+
+		tw := tabwriter.NewWriter(w.opts.Writer, 0, 0, 2, ' ', 0)
+		if !w.opts.NoHeaders {
+			_, _ = fmt.Fprintf(tw, "%s\t%s\tSHARE\n", section.Header, section.Unit)
+		}
+		for _, e := range section.Entries {
+			_, _ = fmt.Fprintf(tw, "%s\t%d\t%s\n", e.Name, e.Count, formatShare(e.Count, section.Total))
+		}
+		_ = tw.Flush()
+	}
+
+	return nil
+}
+
+// colorize 为高亮序列的单元格添加颜色
+// 启用高亮时其他单元格 (包括表头，metric 为 nil) 使用等长的默认色控制序列，保证 tabwriter 列宽对齐
+func (w *tableWriter) colorize(metric map[string]string, s string) string {
+	if w.opts.Highlight == nil || w.opts.NoColor {
+		return s
+	}
+	if metric != nil && w.opts.highlighted(metric) {
+		return ansiHighlight + s + ansiReset
+	}
+	return ansiDefault + s + ansiReset
+}
+
+// labelColumns 着色并以制表符连接序列标识列
+func (w *tableWriter) labelColumns(metric map[string]string, cells []string) string {
+	colored := make([]string, len(cells))
+	for i, c := range cells {
+		colored[i] = w.colorize(metric, c)
+	}
+	return strings.Join(colored, "\t")
+}
+
 // formatMetric 格式化 metric 标签为 Prometheus 格式
 // 例如: metric_name{label1="value1", label2="value2"}
 func formatMetric(labels map[string]string) string {
@@ -0,0 +1,77 @@
+package output
+
+import (
+	"sort"
+	"time"
+
+	"github.com/lwmacct/251203-vm-metrics/internal/vmapi"
+)
+
+// 矩阵布局
+const (
+	LayoutLong = "long" // 每个 (序列, 点) 一行
+	LayoutWide = "wide" // 每个时间戳一行，每个序列一列
+)
+
+// wideTable 按时间戳对齐后的矩阵
+type wideTable struct {
+	Series     []vmapi.Sample // 列对应的序列 (顺序与 Headers 一致)
+	Headers    []string       // 序列列的表头
+	Timestamps []time.Time    // 所有序列时间戳的并集，升序
+	Cells      [][]string     // Cells[i][j] 为 Timestamps[i] 时序列 j 的值，缺失时为填充值
+}
+
+// pivotMatrix 将 range query 结果转为宽表，缺失点使用 opts.FillValue 填充
+// 表头优先使用 opts.HeaderLabel 的值，标签为空或与其他序列重复时使用按标签选项过滤后的 formatMetric
+func pivotMatrix(samples []vmapi.Sample, opts Options) *wideTable {
+	t := &wideTable{Series: samples, Headers: wideHeaders(samples, opts)}
+	fill := opts.FillValue
+
+	index := make(map[int64]int) // UnixNano -> 行号
+	for _, s := range samples {
+		for _, v := range s.Values {
+			key := v.Timestamp.UnixNano()
+			if _, ok := index[key]; !ok {
+				index[key] = len(t.Timestamps)
+				t.Timestamps = append(t.Timestamps, v.Timestamp)
+			}
+		}
+	}
+	sort.Slice(t.Timestamps, func(i, j int) bool { return t.Timestamps[i].Before(t.Timestamps[j]) })
+	for i, ts := range t.Timestamps {
+		index[ts.UnixNano()] = i
+	}
+
+	t.Cells = make([][]string, len(t.Timestamps))
+	for i := range t.Cells {
+		row := make([]string, len(samples))
+		for j := range row {
+			row[j] = fill
+		}
+		t.Cells[i] = row
+	}
+	for j, s := range samples {
+		for _, v := range s.Values {
+			t.Cells[index[v.Timestamp.UnixNano()]][j] = opts.formatValue(v.Value)
+		}
+	}
+	return t
+}
+
+// wideHeaders 生成序列列的表头
+func wideHeaders(samples []vmapi.Sample, opts Options) []string {
+	headers := make([]string, len(samples))
+	count := make(map[string]int)
+	for i, s := range samples {
+		if opts.HeaderLabel != "" {
+			headers[i] = s.Metric[opts.HeaderLabel]
+		}
+		count[headers[i]]++
+	}
+	for i, s := range samples {
+		if headers[i] == "" || count[headers[i]] > 1 {
+			headers[i] = formatMetric(opts.displayLabels(s.Metric))
+		}
+	}
+	return headers
+}
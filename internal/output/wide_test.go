@@ -0,0 +1,43 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/lwmacct/251203-vm-metrics/internal/vmapi"
+)
+
+// TestCSVWide 验证宽表按时间戳对齐、列名取自标签 (重复时退回完整序列名) 以及缺失点填充
+func TestCSVWide(t *testing.T) {
+	t0 := time.Unix(1700000000, 0).UTC()
+	point := func(offset int, v float64) vmapi.SampleValue {
+		return vmapi.SampleValue{Timestamp: t0.Add(time.Duration(offset) * time.Minute), Value: v}
+	}
+	result := &vmapi.QueryResult{
+		ResultType: "matrix",
+		Samples: []vmapi.Sample{
+			{Metric: map[string]string{"__name__": "up", "pod": "a", "ns": "x"}, Values: []vmapi.SampleValue{point(1, 1), point(2, 0)}},
+			{Metric: map[string]string{"__name__": "up", "pod": "a", "ns": "y"}, Values: []vmapi.SampleValue{point(0, 2)}},
+			{Metric: map[string]string{"__name__": "up", "pod": "b"}, Values: []vmapi.SampleValue{point(2, 3)}},
+		},
+	}
+
+	var buf bytes.Buffer
+	w, err := New("csv", Options{Writer: &buf, Layout: LayoutWide, HeaderLabel: "pod", FillValue: "0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteQueryResult(result); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `timestamp,"up{ns=""x"", pod=""a""}","up{ns=""y"", pod=""a""}",b
+2023-11-14T22:13:20Z,0,2,0
+2023-11-14T22:14:20Z,1,0,0
+2023-11-14T22:15:20Z,0,0,3
+`
+	if buf.String() != want {
+		t.Errorf("output =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"slices"
+	"strings"
 
 	"github.com/lwmacct/251203-vm-metrics/internal/vmapi"
 )
@@ -19,6 +21,9 @@ type Writer interface {
 
 	// WriteSeries 输出时间序列列表
 	WriteSeries(series []vmapi.LabelSet) error
+
+	// WriteTSDBStatus 输出 TSDB 基数统计 (cardinality)
+	WriteTSDBStatus(status *vmapi.TSDBStatus) error
 }
 
 // Options 输出选项
@@ -26,6 +31,52 @@ type Options struct {
 	Writer    io.Writer // 输出目标，默认 os.Stdout
 	NoHeaders bool      // 禁用表头 (table/csv)
 	NoColor   bool      // 禁用颜色 (table)
+	TypeHint  string    // prom 格式的 # TYPE 提示: 空表示不输出，auto 按指标名推断，或 counter/gauge 等固定类型
+
+	// 时间戳与数值格式 (table/csv/markdown/html)
+	TimeFormat string // rfc3339 (默认), rfc3339nano, unix, unix_ms, relative
+	UTC        bool   // 时间戳使用 UTC，默认为本地时区
+	Precision  *int   // 数值保留的小数位数，nil 表示不限制
+	ValueUnit  string // 数值单位: si, iec, bytes, seconds, percent，为空时原样输出
+
+	// 标签列 (table/csv/markdown/html，summary/graph 的序列名同样按此过滤)
+	Columns      []string // 只展示这些标签，每个标签单独一列
+	Without      []string // 从 METRIC 列中去掉这些标签 (未指定 Columns 时生效)
+	NoMetricName bool     // 不展示指标名
+
+	// 矩阵布局 (table/csv/markdown/html)
+	Layout      string // long (默认) 或 wide: 共享时间戳列，每个序列一列
+	HeaderLabel string // wide 布局中用作列名的标签，为空或重复时使用完整序列名
+	FillValue   string // wide 布局中缺失点的填充值
+
+	// 统计摘要 (summary)
+	SortBy string // 排序列: name, min, max, avg, last, p95, stddev, points，为空时保持查询结果顺序
+	Desc   bool   // 降序排序
+	Top    int    // 只输出排序后的前 N 个序列，0 表示不限制
+
+	// 图表尺寸 (graph)，0 表示按终端尺寸
+	GraphWidth    int  // 绘图区列数
+	GraphHeight   int  // 绘图区行数
+	GraphSeparate bool // 每个序列单独绘制一张图，默认叠加在同一张图中
+
+	// Terminal 输出最终显示到的终端，用于 Writer 为缓冲区时按终端尺寸绘图 (graph)
+	// 为 nil 时仅在 Writer 本身是终端时按终端尺寸，重定向到文件或管道时使用默认尺寸
+	Terminal *os.File
+
+	// Highlight 返回 true 的序列高亮显示 (table/graph)，为 nil 时不高亮
+	Highlight func(metric map[string]string) bool
+}
+
+// ANSI 颜色控制序列
+const (
+	ansiHighlight = "\x1b[33m" // 黄色前景
+	ansiDefault   = "\x1b[39m" // 默认前景，与 ansiHighlight 等长
+	ansiReset     = "\x1b[0m"
+)
+
+// highlighted 判断序列是否需要高亮
+func (o Options) highlighted(metric map[string]string) bool {
+	return o.Highlight != nil && !o.NoColor && o.Highlight(metric)
 }
 
 // DefaultOptions 返回默认输出选项
@@ -43,6 +94,16 @@ func New(format string, opts Options) (Writer, error) {
 		opts.Writer = os.Stdout
 	}
 
+	switch opts.Layout {
+	case "", LayoutLong, LayoutWide:
+	default:
+		return nil, fmt.Errorf("unsupported layout: %s (use long or wide)", opts.Layout)
+	}
+
+	if err := validateFormatOptions(opts); err != nil {
+		return nil, err
+	}
+
 	switch format {
 	case "json":
 		return NewJSONWriter(opts), nil
@@ -50,6 +111,22 @@ func New(format string, opts Options) (Writer, error) {
 		return NewCSVWriter(opts), nil
 	case "graph":
 		return NewGraphWriter(opts), nil
+	case "prom", "prometheus", "openmetrics":
+		switch opts.TypeHint {
+		case "", "auto", "counter", "gauge", "untyped", "histogram", "summary":
+		default:
+			return nil, fmt.Errorf("unsupported type hint: %s", opts.TypeHint)
+		}
+		return NewPrometheusWriter(opts), nil
+	case "summary":
+		if opts.SortBy != "" && !slices.Contains(summaryColumns, opts.SortBy) {
+			return nil, fmt.Errorf("unsupported sort column: %s (use %s)", opts.SortBy, strings.Join(summaryColumns, ", "))
+		}
+		return NewSummaryWriter(opts), nil
+	case "markdown", "md":
+		return NewMarkdownWriter(opts), nil
+	case "html":
+		return NewHTMLWriter(opts), nil
 	case "table", "":
 		return NewTableWriter(opts), nil
 	default:
@@ -0,0 +1,217 @@
+// Package relabel 实现 Prometheus 风格的 relabel_configs，用于在导入与导出的 JSON Line 流中改写或过滤序列
+package relabel
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// 支持的 action
+const (
+	ActionReplace   = "replace"
+	ActionKeep      = "keep"
+	ActionDrop      = "drop"
+	ActionLabelDrop = "labeldrop"
+	ActionLabelKeep = "labelkeep"
+	ActionLabelMap  = "labelmap"
+	ActionHashMod   = "hashmod"
+)
+
+// Config 单条 relabel 规则，字段与 Prometheus relabel_config 一致
+type Config struct {
+	SourceLabels []string `yaml:"source_labels"`
+	Separator    *string  `yaml:"separator"` // 默认 ;
+	TargetLabel  string   `yaml:"target_label"`
+	Regex        *string  `yaml:"regex"` // 默认 (.*)，自动加上 ^ 与 $
+	Modulus      uint64   `yaml:"modulus"`
+	Replacement  *string  `yaml:"replacement"` // 默认 $1
+	Action       string   `yaml:"action"`      // 默认 replace
+}
+
+// rule 编译后的规则
+type rule struct {
+	sourceLabels []string
+	separator    string
+	targetLabel  string
+	regex        *regexp.Regexp
+	modulus      uint64
+	replacement  string
+	action       string
+}
+
+// Rules 按顺序执行的一组规则
+type Rules struct {
+	rules []rule
+}
+
+// LoadFile 从 YAML 文件加载规则，文件内容为规则列表，或带有 relabel_configs 字段的对象
+func LoadFile(path string) (*Rules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read relabel config: %w", err)
+	}
+	rules, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid relabel config %s: %w", path, err)
+	}
+	return rules, nil
+}
+
+// Parse 解析 YAML 格式的规则
+func Parse(data []byte) (*Rules, error) {
+	var configs []Config
+	if err := yaml.Unmarshal(data, &configs); err != nil {
+		var doc struct {
+			RelabelConfigs []Config `yaml:"relabel_configs"`
+		}
+		if err2 := yaml.Unmarshal(data, &doc); err2 != nil {
+			return nil, err
+		}
+		configs = doc.RelabelConfigs
+	}
+	return Compile(configs)
+}
+
+// Compile 校验并编译规则
+func Compile(configs []Config) (*Rules, error) {
+	rs := &Rules{rules: make([]rule, 0, len(configs))}
+	for i, c := range configs {
+		r, err := compile(c)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i+1, err)
+		}
+		rs.rules = append(rs.rules, r)
+	}
+	return rs, nil
+}
+
+// compile 填充默认值并编译单条规则
+func compile(c Config) (rule, error) {
+	r := rule{
+		sourceLabels: c.SourceLabels,
+		separator:    ";",
+		targetLabel:  c.TargetLabel,
+		modulus:      c.Modulus,
+		replacement:  "$1",
+		action:       strings.ToLower(c.Action),
+	}
+	if c.Separator != nil {
+		r.separator = *c.Separator
+	}
+	if c.Replacement != nil {
+		r.replacement = *c.Replacement
+	}
+	if r.action == "" {
+		r.action = ActionReplace
+	}
+	expr := "(.*)"
+	if c.Regex != nil {
+		expr = *c.Regex
+	}
+	re, err := regexp.Compile("^(?:" + expr + ")$")
+	if err != nil {
+		return r, fmt.Errorf("invalid regex %q: %w", expr, err)
+	}
+	r.regex = re
+
+	switch r.action {
+	case ActionReplace:
+		if r.targetLabel == "" {
+			return r, fmt.Errorf("replace requires target_label")
+		}
+	case ActionHashMod:
+		if r.targetLabel == "" || r.modulus == 0 {
+			return r, fmt.Errorf("hashmod requires target_label and a positive modulus")
+		}
+	case ActionKeep, ActionDrop:
+		if len(r.sourceLabels) == 0 {
+			return r, fmt.Errorf("%s requires source_labels", r.action)
+		}
+	case ActionLabelDrop, ActionLabelKeep, ActionLabelMap:
+	default:
+		return r, fmt.Errorf("unsupported action %q (use replace, keep, drop, labeldrop, labelkeep, labelmap or hashmod)", c.Action)
+	}
+	return r, nil
+}
+
+// Len 规则数量
+func (rs *Rules) Len() int {
+	return len(rs.rules)
+}
+
+// Apply 依次执行规则，返回改写后的标签；序列被丢弃时返回 false
+// 不修改传入的 labels
+func (rs *Rules) Apply(labels map[string]string) (map[string]string, bool) {
+	out := make(map[string]string, len(labels))
+	for k, v := range labels {
+		out[k] = v
+	}
+	for i := range rs.rules {
+		if !rs.rules[i].apply(out) {
+			return nil, false
+		}
+	}
+	return out, true
+}
+
+// apply 执行单条规则，序列被丢弃时返回 false
+func (r *rule) apply(labels map[string]string) bool {
+	switch r.action {
+	case ActionKeep:
+		return r.regex.MatchString(r.sourceValue(labels))
+	case ActionDrop:
+		return !r.regex.MatchString(r.sourceValue(labels))
+	case ActionReplace:
+		value := r.sourceValue(labels)
+		match := r.regex.FindStringSubmatchIndex(value)
+		if match == nil {
+			return true
+		}
+		target := string(r.regex.ExpandString(nil, r.targetLabel, value, match))
+		if target == "" {
+			return true
+		}
+		if res := string(r.regex.ExpandString(nil, r.replacement, value, match)); res != "" {
+			labels[target] = res
+		} else {
+			delete(labels, target)
+		}
+	case ActionHashMod:
+		sum := md5.Sum([]byte(r.sourceValue(labels)))
+		labels[r.targetLabel] = fmt.Sprint(binary.BigEndian.Uint64(sum[8:]) % r.modulus)
+	case ActionLabelMap:
+		// 先收集再写入，避免新增的标签在同一次遍历中再次被匹配
+		mapped := map[string]string{}
+		for k, v := range labels {
+			if r.regex.MatchString(k) {
+				mapped[r.regex.ReplaceAllString(k, r.replacement)] = v
+			}
+		}
+		for k, v := range mapped {
+			labels[k] = v
+		}
+	case ActionLabelDrop, ActionLabelKeep:
+		keep := r.action == ActionLabelKeep
+		for k := range labels {
+			if r.regex.MatchString(k) != keep {
+				delete(labels, k)
+			}
+		}
+	}
+	return true
+}
+
+// sourceValue 以 separator 连接 source_labels 的值
+func (r *rule) sourceValue(labels map[string]string) string {
+	values := make([]string, len(r.sourceLabels))
+	for i, name := range r.sourceLabels {
+		values[i] = labels[name]
+	}
+	return strings.Join(values, r.separator)
+}
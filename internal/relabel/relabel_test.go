@@ -0,0 +1,144 @@
+package relabel
+
+import (
+	"maps"
+	"strings"
+	"testing"
+)
+
+// TestApply 验证各 action 的行为
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		in     map[string]string
+		want   map[string]string // nil 表示被丢弃
+	}{
+		{
+			name:   "replace with default regex",
+			config: `[{target_label: env, replacement: staging}]`,
+			in:     map[string]string{"__name__": "up"},
+			want:   map[string]string{"__name__": "up", "env": "staging"},
+		},
+		{
+			name:   "replace renames metric",
+			config: `[{source_labels: [__name__], regex: "node_(.+)", target_label: __name__, replacement: "host_$1"}]`,
+			in:     map[string]string{"__name__": "node_load1"},
+			want:   map[string]string{"__name__": "host_load1"},
+		},
+		{
+			name:   "replace without match and empty result",
+			config: `[{source_labels: [job], regex: "x", target_label: a}, {source_labels: [missing], target_label: job}]`,
+			in:     map[string]string{"__name__": "up", "job": "node"},
+			want:   map[string]string{"__name__": "up"},
+		},
+		{
+			name:   "keep",
+			config: `[{source_labels: [__name__, job], separator: "@", regex: "up@node", action: keep}]`,
+			in:     map[string]string{"__name__": "up", "job": "api"},
+		},
+		{
+			name:   "drop",
+			config: `relabel_configs: [{source_labels: [__name__], regex: "go_.*", action: drop}]`,
+			in:     map[string]string{"__name__": "go_goroutines"},
+		},
+		{
+			name:   "labeldrop and labelkeep",
+			config: `[{regex: "pod_.*", action: labeldrop}, {regex: "__name__|pod|node", action: labelkeep}]`,
+			in:     map[string]string{"__name__": "up", "pod": "a", "pod_uid": "1", "zone": "eu", "node": "n1"},
+			want:   map[string]string{"__name__": "up", "pod": "a", "node": "n1"},
+		},
+		{
+			name:   "labelmap",
+			config: `[{regex: "__meta_(.+)", action: labelmap}]`,
+			in:     map[string]string{"__name__": "up", "__meta_zone": "eu"},
+			want:   map[string]string{"__name__": "up", "__meta_zone": "eu", "zone": "eu"},
+		},
+		{
+			name:   "hashmod",
+			config: `[{source_labels: [instance], modulus: 8, target_label: shard, action: HashMod}]`,
+			in:     map[string]string{"__name__": "up", "instance": "localhost:9090"},
+			want:   map[string]string{"__name__": "up", "instance": "localhost:9090", "shard": "2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := Parse([]byte(tt.config))
+			if err != nil {
+				t.Fatal(err)
+			}
+			in := maps.Clone(tt.in)
+			got, keep := rules.Apply(in)
+			if keep != (tt.want != nil) || !maps.Equal(got, tt.want) {
+				t.Errorf("Apply = %v, %v, want %v", got, keep, tt.want)
+			}
+			if !maps.Equal(in, tt.in) {
+				t.Errorf("Apply 修改了输入: %v", in)
+			}
+		})
+	}
+
+	for _, config := range []string{
+		`[{action: replace}]`,
+		`[{action: hashmod, target_label: x}]`,
+		`[{action: keep}]`,
+		`[{action: rename, target_label: x}]`,
+		`[{regex: "(", target_label: x}]`,
+	} {
+		if _, err := Parse([]byte(config)); err == nil {
+			t.Errorf("Parse(%s) 应返回错误", config)
+		}
+	}
+}
+
+// TestWriter 验证按行处理与统计
+func TestWriter(t *testing.T) {
+	rules, err := Parse([]byte(`[{source_labels: [__name__], regex: "drop_me", action: drop}, {target_label: env, replacement: prod, source_labels: [__name__], regex: "up"}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := NewProcessor(rules)
+
+	input := `{"metric":{"__name__":"up"},"values":[1],"timestamps":[1]}
+{"metric":{"__name__":"drop_me"},"values":[1],"timestamps":[1]}
+{"metric":{"__name__":"other"},"values":[2],"timestamps":[2]}`
+	var out strings.Builder
+	w := p.NewWriter(&out)
+	// 分多次写入，行被拆分在不同的 Write 中
+	for _, chunk := range []string{input[:30], input[30:100], input[100:]} {
+		if _, err := w.Write([]byte(chunk)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"metric":{"__name__":"up","env":"prod"},"values":[1],"timestamps":[1]}
+{"metric":{"__name__":"other"},"values":[2],"timestamps":[2]}`
+	if out.String() != want {
+		t.Errorf("output =\n%s\nwant\n%s", out.String(), want)
+	}
+	if got := p.String(); got != "series: 3, dropped: 1, modified: 1" {
+		t.Errorf("stats = %s", got)
+	}
+}
+
+// TestProcessLineNonFinite 验证 export 中的 null 与 "Infinity" 值原样保留
+func TestProcessLineNonFinite(t *testing.T) {
+	rules, err := Parse([]byte(`[{target_label: env, replacement: prod}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := NewProcessor(rules)
+
+	line := `{"metric":{"__name__":"up"},"values":[1,null,"Infinity","-Infinity"],"timestamps":[1,2,3,4]}` + "\n"
+	out, err := p.ProcessLine([]byte(line))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"metric":{"__name__":"up","env":"prod"},"values":[1,null,"Infinity","-Infinity"],"timestamps":[1,2,3,4]}` + "\n"
+	if string(out) != want {
+		t.Errorf("output = %s, want %s", out, want)
+	}
+}
@@ -0,0 +1,131 @@
+package relabel
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"sync/atomic"
+)
+
+// Processor 对 JSON Line 流逐行执行规则并统计，可在多个 goroutine 中共享
+type Processor struct {
+	rules    *Rules
+	series   atomic.Int64
+	dropped  atomic.Int64
+	modified atomic.Int64
+}
+
+// NewProcessor 创建 JSON Line 处理器
+func NewProcessor(rules *Rules) *Processor {
+	return &Processor{rules: rules}
+}
+
+// ProcessLine 处理一行 JSON Line: 丢弃时返回 nil，未修改时原样返回
+// 空行返回 nil，可直接用作导入的逐行转换
+func (p *Processor) ProcessLine(line []byte) ([]byte, error) {
+	if len(bytes.TrimSpace(line)) == 0 {
+		return nil, nil
+	}
+	var row rawRow
+	if err := json.Unmarshal(line, &row); err != nil {
+		return nil, fmt.Errorf("invalid JSON line: %w", err)
+	}
+
+	p.series.Add(1)
+	labels, keep := p.rules.Apply(row.Metric)
+	switch {
+	case !keep:
+		p.dropped.Add(1)
+		return nil, nil
+	case maps.Equal(labels, row.Metric):
+		return line, nil
+	}
+	p.modified.Add(1)
+	row.Metric = labels
+	return row.appendJSON(nil), nil
+}
+
+// rawRow 只解析 metric 的 JSON Line 行
+// values 与 timestamps 原样保留: export 将 NaN (含 staleness 标记) 输出为 null，±Inf 输出为 "Infinity"/"-Infinity"
+type rawRow struct {
+	Metric     map[string]string `json:"metric"`
+	Values     json.RawMessage   `json:"values"`
+	Timestamps json.RawMessage   `json:"timestamps"`
+}
+
+// appendJSON 将行序列化为一行 JSON (含换行符) 追加到 dst
+func (r *rawRow) appendJSON(dst []byte) []byte {
+	metric, _ := json.Marshal(r.Metric) // map[string]string 不会序列化失败
+	dst = append(dst, `{"metric":`...)
+	dst = append(dst, metric...)
+	dst = append(dst, `,"values":`...)
+	dst = appendRaw(dst, r.Values)
+	dst = append(dst, `,"timestamps":`...)
+	dst = appendRaw(dst, r.Timestamps)
+	return append(dst, "}\n"...)
+}
+
+// appendRaw 追加原始 JSON，缺失时写入空数组
+func appendRaw(dst []byte, raw json.RawMessage) []byte {
+	if len(raw) == 0 {
+		return append(dst, "[]"...)
+	}
+	return append(dst, raw...)
+}
+
+// String 返回统计摘要
+func (p *Processor) String() string {
+	return fmt.Sprintf("series: %d, dropped: %d, modified: %d", p.series.Load(), p.dropped.Load(), p.modified.Load())
+}
+
+// Writer 将写入的 JSON Line 流按行处理后写入下游，Close 时处理最后不完整的一行
+type Writer struct {
+	p   *Processor
+	w   io.Writer
+	buf []byte
+}
+
+// NewWriter 创建按行处理的 Writer
+func (p *Processor) NewWriter(w io.Writer) *Writer {
+	return &Writer{p: p, w: w}
+}
+
+// Write 实现 io.Writer
+func (w *Writer) Write(data []byte) (int, error) {
+	w.buf = append(w.buf, data...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		if err := w.writeLine(w.buf[:i+1]); err != nil {
+			return 0, err
+		}
+		w.buf = w.buf[i+1:]
+	}
+	// 避免底层数组无限增长
+	w.buf = append(w.buf[:0:0], w.buf...)
+	return len(data), nil
+}
+
+// Close 处理剩余数据，不关闭下游
+func (w *Writer) Close() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	err := w.writeLine(w.buf)
+	w.buf = nil
+	return err
+}
+
+// writeLine 处理一行并写入下游
+func (w *Writer) writeLine(line []byte) error {
+	out, err := w.p.ProcessLine(line)
+	if err != nil || len(out) == 0 {
+		return err
+	}
+	_, err = w.w.Write(out)
+	return err
+}
@@ -0,0 +1,56 @@
+// Package util 提供各命令与 API 客户端共用的小工具
+package util
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// FirstNonEmpty 返回第一个非空字符串
+func FirstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// SeriesKey 生成序列的唯一标识，与标签顺序无关
+func SeriesKey(metric map[string]string) string {
+	pairs := make([]string, 0, len(metric))
+	for k, v := range metric {
+		pairs = append(pairs, k+"\x00"+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "\x01")
+}
+
+// FormatBytes 以 IEC 单位格式化字节数
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// CountingWriter 统计写入字节数
+type CountingWriter struct {
+	W io.Writer
+	N int64
+}
+
+// Write 实现 io.Writer
+func (c *CountingWriter) Write(p []byte) (int, error) {
+	n, err := c.W.Write(p)
+	c.N += int64(n)
+	return n, err
+}
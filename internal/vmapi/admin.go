@@ -0,0 +1,211 @@
+package vmapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// TSDBStatusOptions TSDB 状态查询选项
+type TSDBStatusOptions struct {
+	TopN       int       // 每类统计返回的条目数 (默认 10)
+	Date       time.Time // 统计日期，按其所在时区的日期发送 (零值表示服务端的当天)
+	FocusLabel string    // 额外统计该标签各个值的序列数
+	Match      []string  // 仅统计匹配的序列
+
+	ExtraLabels  []string // extra_label 参数，格式 name=value
+	ExtraFilters []string // extra_filters[] 参数，序列选择器
+}
+
+// TSDBStatusEntry 统计条目
+type TSDBStatusEntry struct {
+	Name  string `json:"name"`
+	Count uint64 `json:"value"`
+}
+
+// TSDBStatus TSDB 基数统计
+type TSDBStatus struct {
+	TotalSeries                  uint64            `json:"totalSeries"`
+	TotalLabelValuePairs         uint64            `json:"totalLabelValuePairs"`
+	SeriesCountByMetricName      []TSDBStatusEntry `json:"seriesCountByMetricName"`
+	SeriesCountByLabelName       []TSDBStatusEntry `json:"seriesCountByLabelName"`
+	SeriesCountByFocusLabelValue []TSDBStatusEntry `json:"seriesCountByFocusLabelValue"`
+	SeriesCountByLabelValuePair  []TSDBStatusEntry `json:"seriesCountByLabelValuePair"`
+	LabelValueCountByLabelName   []TSDBStatusEntry `json:"labelValueCountByLabelName"`
+
+	FocusLabel string `json:"focusLabel,omitempty"` // 请求中的 focusLabel，便于展示
+}
+
+// Admin 管理接口
+// 快照接口作用于单个存储节点：单机版直接调用，集群版需将 --server-url 指向 vmstorage
+type Admin interface {
+	// DeleteSeries 删除匹配的时间序列 (全部时间范围)
+	// endpoint: POST /api/v1/admin/tsdb/delete_series
+	DeleteSeries(ctx context.Context, match []string) error
+
+	// TSDBStatus 获取 TSDB 基数统计
+	// endpoint: GET /api/v1/status/tsdb
+	TSDBStatus(ctx context.Context, opts *TSDBStatusOptions) (*TSDBStatus, error)
+
+	// SnapshotCreate 创建快照，返回快照名称
+	// endpoint: /snapshot/create
+	SnapshotCreate(ctx context.Context) (string, error)
+
+	// SnapshotList 列出所有快照
+	// endpoint: /snapshot/list
+	SnapshotList(ctx context.Context) ([]string, error)
+
+	// SnapshotDelete 删除指定快照
+	// endpoint: /snapshot/delete
+	SnapshotDelete(ctx context.Context, name string) error
+
+	// SnapshotDeleteAll 删除所有快照
+	// endpoint: /snapshot/delete_all
+	SnapshotDeleteAll(ctx context.Context) error
+
+	// ForceFlush 将内存中缓冲的新数据刷到存储，使其立即可查询
+	// endpoint: /internal/force_flush
+	ForceFlush(ctx context.Context) error
+}
+
+// snapshotResponse 快照接口响应
+type snapshotResponse struct {
+	Status    string   `json:"status"` // "ok" | "error"
+	Snapshot  string   `json:"snapshot,omitempty"`
+	Snapshots []string `json:"snapshots,omitempty"`
+	Msg       string   `json:"msg,omitempty"`
+}
+
+// DeleteSeries 删除匹配的时间序列
+func (c *restyClient) DeleteSeries(ctx context.Context, match []string) error {
+	if len(match) == 0 {
+		return fmt.Errorf("at least one match selector is required")
+	}
+
+	req := c.client.R().SetContext(ctx)
+	for _, m := range match {
+		req.FormData.Add("match[]", m)
+	}
+
+	resp, err := req.Post(c.deleteEndpoint("/api/v1/admin/tsdb/delete_series"))
+	if err != nil {
+		return fmt.Errorf("delete_series request failed: %w", err)
+	}
+	if resp.StatusCode() != 204 && resp.StatusCode() != 200 {
+		return c.statusError("delete series", resp)
+	}
+	return nil
+}
+
+// TSDBStatus 获取 TSDB 基数统计
+func (c *restyClient) TSDBStatus(ctx context.Context, opts *TSDBStatusOptions) (*TSDBStatus, error) {
+	req := c.client.R().SetContext(ctx)
+
+	if opts != nil {
+		if opts.TopN > 0 {
+			req.SetQueryParam("topN", strconv.Itoa(opts.TopN))
+		}
+		if !opts.Date.IsZero() {
+			req.SetQueryParam("date", opts.Date.Format(time.DateOnly))
+		}
+		if opts.FocusLabel != "" {
+			req.SetQueryParam("focusLabel", opts.FocusLabel)
+		}
+		for _, m := range opts.Match {
+			req.QueryParam.Add("match[]", m)
+		}
+		setExtraParams(req, opts.ExtraLabels, opts.ExtraFilters)
+	}
+
+	resp, err := req.Get(c.selectEndpoint("/api/v1/status/tsdb"))
+	if err != nil {
+		return nil, fmt.Errorf("tsdb status request failed: %w", err)
+	}
+
+	var apiResp APIResponse
+	if err := json.Unmarshal(resp.Body(), &apiResp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if !apiResp.IsSuccess() {
+		return nil, fmt.Errorf("API error [%s]: %s", apiResp.ErrorType, apiResp.Error)
+	}
+
+	var status TSDBStatus
+	if err := json.Unmarshal(apiResp.Data, &status); err != nil {
+		return nil, fmt.Errorf("failed to parse tsdb status data: %w", err)
+	}
+	if opts != nil {
+		status.FocusLabel = opts.FocusLabel
+	}
+	return &status, nil
+}
+
+// SnapshotCreate 创建快照
+func (c *restyClient) SnapshotCreate(ctx context.Context) (string, error) {
+	// 创建不是幂等的，超时后重试可能产生重复的快照
+	result, err := c.snapshotRequest(WithoutRetry(ctx), "/snapshot/create", nil)
+	if err != nil {
+		return "", err
+	}
+	return result.Snapshot, nil
+}
+
+// SnapshotList 列出所有快照
+func (c *restyClient) SnapshotList(ctx context.Context) ([]string, error) {
+	result, err := c.snapshotRequest(ctx, "/snapshot/list", nil)
+	if err != nil {
+		return nil, err
+	}
+	return result.Snapshots, nil
+}
+
+// SnapshotDelete 删除指定快照
+func (c *restyClient) SnapshotDelete(ctx context.Context, name string) error {
+	_, err := c.snapshotRequest(ctx, "/snapshot/delete", map[string]string{"snapshot": name})
+	return err
+}
+
+// SnapshotDeleteAll 删除所有快照
+func (c *restyClient) SnapshotDeleteAll(ctx context.Context) error {
+	_, err := c.snapshotRequest(ctx, "/snapshot/delete_all", nil)
+	return err
+}
+
+// ForceFlush 刷新缓冲的新数据
+func (c *restyClient) ForceFlush(ctx context.Context) error {
+	resp, err := c.client.R().
+		SetContext(ctx).
+		Get(c.baseURL + "/internal/force_flush")
+	if err != nil {
+		return fmt.Errorf("force flush request failed: %w", err)
+	}
+	if resp.StatusCode() != 200 {
+		return c.statusError("force flush", resp)
+	}
+	return nil
+}
+
+// snapshotRequest 调用快照接口并检查 status 字段
+func (c *restyClient) snapshotRequest(ctx context.Context, path string, params map[string]string) (*snapshotResponse, error) {
+	resp, err := c.client.R().
+		SetContext(ctx).
+		SetQueryParams(params).
+		Get(c.baseURL + path)
+	if err != nil {
+		return nil, fmt.Errorf("snapshot request failed: %w", err)
+	}
+	if resp.StatusCode() != 200 {
+		return nil, c.statusError("snapshot "+path, resp)
+	}
+
+	var result snapshotResponse
+	if err := json.Unmarshal(resp.Body(), &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if result.Status != "ok" {
+		return nil, fmt.Errorf("snapshot error: %s", result.Msg)
+	}
+	return &result, nil
+}
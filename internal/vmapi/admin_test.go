@@ -0,0 +1,176 @@
+package vmapi
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordedRequest 测试服务器收到的请求
+type recordedRequest struct {
+	method string
+	path   string
+	query  url.Values
+	form   url.Values
+}
+
+// newAdminServer 记录每个请求并按路径返回 body
+func newAdminServer(t *testing.T, bodies map[string]string) (*httptest.Server, *[]recordedRequest) {
+	t.Helper()
+	var reqs []recordedRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("parse form: %v", err)
+		}
+		reqs = append(reqs, recordedRequest{method: r.Method, path: r.URL.Path, query: query, form: r.PostForm})
+		for suffix, body := range bodies {
+			if strings.HasSuffix(r.URL.Path, suffix) {
+				_, _ = io.WriteString(w, body)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &reqs
+}
+
+func newAdminClient(t *testing.T, cfg *ClientConfig) Admin {
+	t.Helper()
+	cfg.Timeout = 5 * time.Second
+	client, err := NewClient(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client.(Admin)
+}
+
+// TestDeleteSeries 验证 match[] 以表单 POST 发送，集群模式下发往 vmselect 的 /delete/<tenant>/prometheus
+func TestDeleteSeries(t *testing.T) {
+	match := []string{`{job="a",instance=~"x|y"}`, "up"}
+	tests := []struct {
+		name     string
+		cluster  bool
+		wantPath string
+	}{
+		{"single node", false, "/api/v1/admin/tsdb/delete_series"},
+		{"cluster", true, "/delete/1:2/prometheus/api/v1/admin/tsdb/delete_series"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, reqs := newAdminServer(t, nil)
+			cfg := &ClientConfig{URL: srv.URL}
+			if tt.cluster {
+				// 写请求地址不可达，删除请求必须走 vmselect
+				cfg = &ClientConfig{URL: "http://127.0.0.1:1", SelectURL: srv.URL, InsertURL: "http://127.0.0.1:1", TenantID: "1:2"}
+			}
+			admin := newAdminClient(t, cfg)
+
+			if err := admin.DeleteSeries(context.Background(), match); err != nil {
+				t.Fatal(err)
+			}
+			if len(*reqs) != 1 {
+				t.Fatalf("got %d requests, want 1", len(*reqs))
+			}
+			got := (*reqs)[0]
+			if got.method != http.MethodPost || got.path != tt.wantPath {
+				t.Errorf("request = %s %s, want POST %s", got.method, got.path, tt.wantPath)
+			}
+			if !slices.Equal(got.form["match[]"], match) {
+				t.Errorf("form match[] = %q, want %q", got.form["match[]"], match)
+			}
+			if len(got.query) != 0 {
+				t.Errorf("match[] 不应放在 URL 中: %v", got.query)
+			}
+		})
+	}
+
+	admin := newAdminClient(t, &ClientConfig{URL: "http://127.0.0.1:1"})
+	if err := admin.DeleteSeries(context.Background(), nil); err == nil {
+		t.Error("empty match should fail")
+	}
+}
+
+// TestTSDBStatusParams 验证 TSDB 状态的查询参数与响应解析
+func TestTSDBStatusParams(t *testing.T) {
+	srv, reqs := newAdminServer(t, map[string]string{
+		"/api/v1/status/tsdb": `{"status":"success","data":{"totalSeries":3,"seriesCountByMetricName":[{"name":"up","value":3}]}}`,
+	})
+	admin := newAdminClient(t, &ClientConfig{URL: srv.URL, PathPrefix: "/vm/"})
+
+	date := time.Date(2024, 5, 1, 23, 30, 0, 0, time.FixedZone("UTC+8", 8*3600))
+	status, err := admin.TSDBStatus(context.Background(), &TSDBStatusOptions{
+		TopN:       5,
+		Date:       date,
+		FocusLabel: "job",
+		Match:      []string{"up", `{job="a"}`},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.TotalSeries != 3 || len(status.SeriesCountByMetricName) != 1 || status.FocusLabel != "job" {
+		t.Errorf("status = %+v", status)
+	}
+
+	got := (*reqs)[0]
+	if got.method != http.MethodGet || got.path != "/vm/api/v1/status/tsdb" {
+		t.Errorf("request = %s %s", got.method, got.path)
+	}
+	want := url.Values{
+		"topN":       {"5"},
+		"date":       {"2024-05-01"}, // 按 Date 所在时区的日期
+		"focusLabel": {"job"},
+		"match[]":    {"up", `{job="a"}`},
+	}
+	if got.query.Encode() != want.Encode() {
+		t.Errorf("query = %s, want %s", got.query.Encode(), want.Encode())
+	}
+}
+
+// TestSnapshot 验证快照接口的地址、参数与 status 字段处理
+func TestSnapshot(t *testing.T) {
+	srv, reqs := newAdminServer(t, map[string]string{
+		"/snapshot/create":     `{"status":"ok","snapshot":"20240501-s1"}`,
+		"/snapshot/list":       `{"status":"ok","snapshots":["20240501-s1","20240502-s2"]}`,
+		"/snapshot/delete":     `{"status":"error","msg":"cannot find snapshot"}`,
+		"/snapshot/delete_all": `{"status":"ok"}`,
+	})
+	// 快照接口直接作用于存储节点，集群配置下也不加 select/insert 前缀
+	admin := newAdminClient(t, &ClientConfig{URL: srv.URL, TenantID: "1"})
+	ctx := context.Background()
+
+	name, err := admin.SnapshotCreate(ctx)
+	if err != nil || name != "20240501-s1" {
+		t.Errorf("create = %q, %v", name, err)
+	}
+	names, err := admin.SnapshotList(ctx)
+	if err != nil || !slices.Equal(names, []string{"20240501-s1", "20240502-s2"}) {
+		t.Errorf("list = %q, %v", names, err)
+	}
+	err = admin.SnapshotDelete(ctx, "20240501-s1")
+	if err == nil || !strings.Contains(err.Error(), "cannot find snapshot") {
+		t.Errorf("delete err = %v, want status error", err)
+	}
+	if err := admin.SnapshotDeleteAll(ctx); err != nil {
+		t.Errorf("delete all: %v", err)
+	}
+
+	var paths []string
+	for _, r := range *reqs {
+		paths = append(paths, r.path)
+	}
+	wantPaths := []string{"/snapshot/create", "/snapshot/list", "/snapshot/delete", "/snapshot/delete_all"}
+	if !slices.Equal(paths, wantPaths) {
+		t.Errorf("paths = %q, want %q", paths, wantPaths)
+	}
+	if got := (*reqs)[2].query.Get("snapshot"); got != "20240501-s1" {
+		t.Errorf("delete snapshot param = %q", got)
+	}
+}
@@ -8,25 +8,32 @@ import (
 
 // Client VictoriaMetrics API 客户端接口
 type Client interface {
-	// Query 执行即时查询
+	// Query 执行即时查询，opts 可为 nil
 	// endpoint: GET /api/v1/query
-	Query(ctx context.Context, query string, ts time.Time) (*QueryResult, error)
+	Query(ctx context.Context, query string, ts time.Time, opts *QueryOptions) (*QueryResult, error)
 
-	// QueryRange 执行范围查询
+	// QueryRange 执行范围查询，opts 可为 nil
 	// endpoint: GET /api/v1/query_range
-	QueryRange(ctx context.Context, query string, start, end time.Time, step time.Duration) (*QueryResult, error)
+	QueryRange(ctx context.Context, query string, start, end time.Time, step time.Duration, opts *QueryOptions) (*QueryResult, error)
 
-	// Series 获取时间序列
+	// Series 获取时间序列，opts 可为 nil
 	// endpoint: GET /api/v1/series
-	Series(ctx context.Context, match []string, start, end time.Time) (*SeriesResult, error)
+	Series(ctx context.Context, match []string, start, end time.Time, opts *QueryOptions) (*SeriesResult, error)
 
-	// Labels 获取所有标签名称
+	// Labels 获取所有标签名称，opts 可为 nil
 	// endpoint: GET /api/v1/labels
-	Labels(ctx context.Context, start, end time.Time) (*LabelsResult, error)
+	Labels(ctx context.Context, start, end time.Time, opts *QueryOptions) (*LabelsResult, error)
 
-	// LabelValues 获取指定标签的所有值
+	// LabelValues 获取指定标签的所有值，opts 可为 nil
 	// endpoint: GET /api/v1/label/<name>/values
-	LabelValues(ctx context.Context, label string, start, end time.Time) (*LabelValuesResult, error)
+	LabelValues(ctx context.Context, label string, start, end time.Time, opts *QueryOptions) (*LabelValuesResult, error)
+}
+
+// QueryOptions 查询附加参数
+type QueryOptions struct {
+	ExtraLabels  []string // extra_label 参数，格式 name=value，作为强制的标签过滤条件
+	ExtraFilters []string // extra_filters[] 参数，序列选择器，如 {env="prod"}
+	NoCache      bool     // nocache=1，不使用服务端的查询结果缓存
 }
 
 // ClientConfig 客户端配置
@@ -36,6 +43,22 @@ type ClientConfig struct {
 	PathPrefix string // API 路径前缀 (如 /victoria)
 	Timeout    time.Duration
 
+	// 集群模式配置 (任一字段非空即启用)
+	SelectURL string // vmselect 地址，读请求使用 (默认: URL)
+	InsertURL string // vminsert 地址，写请求使用 (默认: URL)
+	TenantID  string // 租户 ID: accountID 或 accountID:projectID (默认: 0)
+
+	// 重试配置
+	RetryMaxAttempts   int           // 最大尝试次数 (含首次)，<= 1 表示不重试
+	RetryWait          time.Duration // 首次重试等待时间，之后指数增长并加入随机抖动
+	RetryMaxWait       time.Duration // 单次等待上限 (同时限制 Retry-After)
+	RetryStatusCodes   []int         // 需要重试的 HTTP 状态码
+	RetryNetworkErrors bool          // 是否重试网络错误 (连接失败、连接被重置)
+
+	// 熔断配置
+	BreakerThreshold int           // 连续失败多少次后熔断，0 表示禁用
+	BreakerCooldown  time.Duration // 熔断持续时间
+
 	// 认证配置
 	AuthType string // "basic" | "bearer"
 	User     string
@@ -48,3 +71,8 @@ type ClientConfig struct {
 	KeyPath    string
 	SkipVerify bool
 }
+
+// IsCluster 是否启用集群模式 (vmselect/vminsert 路由)
+func (c *ClientConfig) IsCluster() bool {
+	return c.SelectURL != "" || c.InsertURL != "" || c.TenantID != ""
+}
@@ -18,12 +18,14 @@ const (
 
 // ExportOptions 导出选项
 type ExportOptions struct {
-	Match           []string      // 时间序列选择器
-	Start           time.Time     // 开始时间
-	End             time.Time     // 结束时间
-	MaxRowsPerLine  int           // JSON Line 每行最大样本数
-	CSVFormat       string        // CSV 列定义
-	ReduceMemUsage  bool          // 跳过去重
+	Match          []string  // 时间序列选择器
+	Start          time.Time // 开始时间
+	End            time.Time // 结束时间
+	MaxRowsPerLine int       // JSON Line 每行最大样本数
+	CSVFormat      string    // CSV 列定义
+	ReduceMemUsage bool      // 跳过去重
+	ExtraLabels    []string  // extra_label 参数，格式 name=value
+	ExtraFilters   []string  // extra_filters[] 参数，序列选择器
 }
 
 // Exporter 导出接口
@@ -48,6 +50,7 @@ func (c *restyClient) ExportJSON(ctx context.Context, w io.Writer, opts *ExportO
 	for _, m := range opts.Match {
 		req.QueryParam.Add("match[]", m)
 	}
+	setExtraParams(req, opts.ExtraLabels, opts.ExtraFilters)
 
 	if !opts.Start.IsZero() {
 		req.SetQueryParam("start", formatTime(opts.Start))
@@ -59,11 +62,12 @@ func (c *restyClient) ExportJSON(ctx context.Context, w io.Writer, opts *ExportO
 		req.SetQueryParam("max_rows_per_line", fmt.Sprintf("%d", opts.MaxRowsPerLine))
 	}
 
-	resp, err := req.Get("/api/v1/export")
+	resp, err := req.Get(c.selectEndpoint("/api/v1/export"))
 	if err != nil {
 		return fmt.Errorf("export request failed: %w", err)
 	}
 	defer func() { _ = resp.RawBody().Close() }()
+	c.recordStream(resp)
 
 	if resp.StatusCode() != 200 {
 		body, _ := io.ReadAll(resp.RawBody())
@@ -90,6 +94,7 @@ func (c *restyClient) ExportCSV(ctx context.Context, w io.Writer, opts *ExportOp
 	for _, m := range opts.Match {
 		req.QueryParam.Add("match[]", m)
 	}
+	setExtraParams(req, opts.ExtraLabels, opts.ExtraFilters)
 
 	if !opts.Start.IsZero() {
 		req.SetQueryParam("start", formatTime(opts.Start))
@@ -101,11 +106,12 @@ func (c *restyClient) ExportCSV(ctx context.Context, w io.Writer, opts *ExportOp
 		req.SetQueryParam("reduce_mem_usage", "1")
 	}
 
-	resp, err := req.Get("/api/v1/export/csv")
+	resp, err := req.Get(c.selectEndpoint("/api/v1/export/csv"))
 	if err != nil {
 		return fmt.Errorf("export csv request failed: %w", err)
 	}
 	defer func() { _ = resp.RawBody().Close() }()
+	c.recordStream(resp)
 
 	if resp.StatusCode() != 200 {
 		body, _ := io.ReadAll(resp.RawBody())
@@ -126,6 +132,7 @@ func (c *restyClient) ExportNative(ctx context.Context, w io.Writer, opts *Expor
 	for _, m := range opts.Match {
 		req.QueryParam.Add("match[]", m)
 	}
+	setExtraParams(req, opts.ExtraLabels, opts.ExtraFilters)
 
 	if !opts.Start.IsZero() {
 		req.SetQueryParam("start", formatTime(opts.Start))
@@ -134,11 +141,12 @@ func (c *restyClient) ExportNative(ctx context.Context, w io.Writer, opts *Expor
 		req.SetQueryParam("end", formatTime(opts.End))
 	}
 
-	resp, err := req.Get("/api/v1/export/native")
+	resp, err := req.Get(c.selectEndpoint("/api/v1/export/native"))
 	if err != nil {
 		return fmt.Errorf("export native request failed: %w", err)
 	}
 	defer func() { _ = resp.RawBody().Close() }()
+	c.recordStream(resp)
 
 	if resp.StatusCode() != 200 {
 		body, _ := io.ReadAll(resp.RawBody())
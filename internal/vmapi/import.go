@@ -1,9 +1,15 @@
 package vmapi
 
 import (
+	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/lwmacct/251203-vm-metrics/internal/lineproto"
 )
 
 // ImportFormat 导入格式
@@ -14,77 +20,90 @@ const (
 	ImportFormatCSV        ImportFormat = "csv"
 	ImportFormatNative     ImportFormat = "native"
 	ImportFormatPrometheus ImportFormat = "prometheus"
+	ImportFormatInflux     ImportFormat = "influx"
+	ImportFormatGraphite   ImportFormat = "graphite"
+	ImportFormatOpenTSDB   ImportFormat = "opentsdb"
 )
 
-// ImportOptions 导入选项
+// ImportOptions 导入选项 (可为 nil)
 type ImportOptions struct {
+	ExtraLabels []string // extra_label 参数，格式 name=value，追加到每个导入的序列
+
 	// Prometheus 格式专用
 	Job      string // Pushgateway job 标签
 	Instance string // Pushgateway instance 标签
+
+	// Influx 格式专用
+	DB              string // db 参数，写入为 db 标签 (数据中已有 db 标签时不覆盖)
+	Precision       string // 时间戳精度: ns, u, ms, s, m, h
+	RetentionPolicy string // rp 参数
+
+	// Graphite 格式专用
+	GraphiteTemplates []*lineproto.GraphiteTemplate // 已解析的路径到标签模板，按顺序取第一个匹配的模板
 }
 
 // Importer 导入接口
 type Importer interface {
 	// ImportJSON 导入 JSON Line 格式
-	ImportJSON(ctx context.Context, r io.Reader) error
+	ImportJSON(ctx context.Context, r io.Reader, opts *ImportOptions) error
 
 	// ImportCSV 导入 CSV 格式
-	ImportCSV(ctx context.Context, r io.Reader) error
+	ImportCSV(ctx context.Context, r io.Reader, opts *ImportOptions) error
 
 	// ImportNative 导入 Native 二进制格式
-	ImportNative(ctx context.Context, r io.Reader) error
+	ImportNative(ctx context.Context, r io.Reader, opts *ImportOptions) error
 
 	// ImportPrometheus 导入 Prometheus exposition 格式
 	ImportPrometheus(ctx context.Context, r io.Reader, opts *ImportOptions) error
+
+	// ImportInflux 导入 Influx line protocol 格式 (/write)
+	ImportInflux(ctx context.Context, r io.Reader, opts *ImportOptions) error
+
+	// ImportGraphite 导入 Graphite plaintext 格式 (客户端转换为 JSON Line)
+	ImportGraphite(ctx context.Context, r io.Reader, opts *ImportOptions) error
+
+	// ImportOpenTSDB 导入 OpenTSDB JSON put 格式 (客户端转换为 JSON Line)
+	ImportOpenTSDB(ctx context.Context, r io.Reader, opts *ImportOptions) error
 }
 
 // ImportJSON 导入 JSON Line 格式
-func (c *restyClient) ImportJSON(ctx context.Context, r io.Reader) error {
-	resp, err := c.client.R().
-		SetContext(ctx).
-		SetBody(r).
-		SetHeader("Content-Type", "application/json").
-		Post("/api/v1/import")
+func (c *restyClient) ImportJSON(ctx context.Context, r io.Reader, opts *ImportOptions) error {
+	resp, err := c.importRequest(ctx, r, "application/json", opts).
+		Post(c.insertEndpoint("/api/v1/import"))
 	if err != nil {
 		return fmt.Errorf("import json request failed: %w", err)
 	}
 
 	if resp.StatusCode() != 204 && resp.StatusCode() != 200 {
-		return fmt.Errorf("import json failed [%d]: %s", resp.StatusCode(), resp.String())
+		return c.statusError("import json", resp)
 	}
 	return nil
 }
 
 // ImportCSV 导入 CSV 格式
-func (c *restyClient) ImportCSV(ctx context.Context, r io.Reader) error {
-	resp, err := c.client.R().
-		SetContext(ctx).
-		SetBody(r).
-		SetHeader("Content-Type", "text/csv").
-		Post("/api/v1/import/csv")
+func (c *restyClient) ImportCSV(ctx context.Context, r io.Reader, opts *ImportOptions) error {
+	resp, err := c.importRequest(ctx, r, "text/csv", opts).
+		Post(c.insertEndpoint("/api/v1/import/csv"))
 	if err != nil {
 		return fmt.Errorf("import csv request failed: %w", err)
 	}
 
 	if resp.StatusCode() != 204 && resp.StatusCode() != 200 {
-		return fmt.Errorf("import csv failed [%d]: %s", resp.StatusCode(), resp.String())
+		return c.statusError("import csv", resp)
 	}
 	return nil
 }
 
 // ImportNative 导入 Native 二进制格式
-func (c *restyClient) ImportNative(ctx context.Context, r io.Reader) error {
-	resp, err := c.client.R().
-		SetContext(ctx).
-		SetBody(r).
-		SetHeader("Content-Type", "application/octet-stream").
-		Post("/api/v1/import/native")
+func (c *restyClient) ImportNative(ctx context.Context, r io.Reader, opts *ImportOptions) error {
+	resp, err := c.importRequest(ctx, r, "application/octet-stream", opts).
+		Post(c.insertEndpoint("/api/v1/import/native"))
 	if err != nil {
 		return fmt.Errorf("import native request failed: %w", err)
 	}
 
 	if resp.StatusCode() != 204 && resp.StatusCode() != 200 {
-		return fmt.Errorf("import native failed [%d]: %s", resp.StatusCode(), resp.String())
+		return c.statusError("import native", resp)
 	}
 	return nil
 }
@@ -102,17 +121,127 @@ func (c *restyClient) ImportPrometheus(ctx context.Context, r io.Reader, opts *I
 		endpoint = "/api/v1/import/prometheus"
 	}
 
-	resp, err := c.client.R().
-		SetContext(ctx).
-		SetBody(r).
-		SetHeader("Content-Type", "text/plain").
-		Post(endpoint)
+	resp, err := c.importRequest(ctx, r, "text/plain", opts).
+		Post(c.insertEndpoint(endpoint))
 	if err != nil {
 		return fmt.Errorf("import prometheus request failed: %w", err)
 	}
 
 	if resp.StatusCode() != 204 && resp.StatusCode() != 200 {
-		return fmt.Errorf("import prometheus failed [%d]: %s", resp.StatusCode(), resp.String())
+		return c.statusError("import prometheus", resp)
+	}
+	return nil
+}
+
+// ImportInflux 导入 Influx line protocol 格式
+func (c *restyClient) ImportInflux(ctx context.Context, r io.Reader, opts *ImportOptions) error {
+	req := c.importRequest(ctx, r, "text/plain", opts)
+	if opts != nil {
+		for k, v := range map[string]string{"db": opts.DB, "precision": opts.Precision, "rp": opts.RetentionPolicy} {
+			if v != "" {
+				req.SetQueryParam(k, v)
+			}
+		}
+	}
+
+	resp, err := req.Post(c.influxURL)
+	if err != nil {
+		return fmt.Errorf("import influx request failed: %w", err)
+	}
+
+	if resp.StatusCode() != 204 && resp.StatusCode() != 200 {
+		return c.statusError("import influx", resp)
 	}
 	return nil
 }
+
+// ImportGraphite 导入 Graphite plaintext 格式
+// 在客户端转换为 JSON Line 后经由 /api/v1/import 写入，不依赖服务端的 Graphite 监听端口
+func (c *restyClient) ImportGraphite(ctx context.Context, r io.Reader, opts *ImportOptions) error {
+	var templates []*lineproto.GraphiteTemplate
+	if opts != nil {
+		templates = opts.GraphiteTemplates
+	}
+
+	return c.importConverted(ctx, "import graphite", opts, func(w *bufio.Writer) error {
+		sc := bufio.NewScanner(r)
+		sc.Buffer(make([]byte, 0, 64<<10), 64<<20)
+		var buf []byte
+		for n := 1; sc.Scan(); n++ {
+			p, err := lineproto.ParseGraphiteLine(sc.Text())
+			if err != nil {
+				return fmt.Errorf("line %d: %w", n, err)
+			}
+			if p == nil {
+				continue
+			}
+			row := p.Row(templates, time.Now().UnixMilli())
+			buf = row.AppendJSON(buf[:0])
+			if _, err := w.Write(buf); err != nil {
+				return err
+			}
+		}
+		return sc.Err()
+	})
+}
+
+// ImportOpenTSDB 导入 OpenTSDB JSON put 格式
+// 在客户端转换为 JSON Line 后经由 /api/v1/import 写入，不依赖服务端的 OpenTSDB 监听端口
+func (c *restyClient) ImportOpenTSDB(ctx context.Context, r io.Reader, opts *ImportOptions) error {
+	return c.importConverted(ctx, "import opentsdb", opts, func(w *bufio.Writer) error {
+		var buf []byte
+		return lineproto.DecodeOpenTSDB(r, func(p *lineproto.OpenTSDBPoint) error {
+			row, err := p.Row(time.Now().UnixMilli())
+			if err != nil {
+				return err
+			}
+			buf = row.AppendJSON(buf[:0])
+			_, err = w.Write(buf)
+			return err
+		})
+	})
+}
+
+// importConverted 将 convert 写出的 JSON Line 流式发送到 /api/v1/import
+// 转换错误优先于请求错误返回
+func (c *restyClient) importConverted(ctx context.Context, op string, opts *ImportOptions, convert func(w *bufio.Writer) error) error {
+	pr, pw := io.Pipe()
+	convErr := make(chan error, 1)
+	go func() {
+		w := bufio.NewWriter(pw)
+		err := convert(w)
+		if err == nil {
+			err = w.Flush()
+		}
+		convErr <- err
+		_ = pw.CloseWithError(err)
+	}()
+
+	resp, err := c.importRequest(ctx, pr, "application/json", opts).
+		Post(c.insertEndpoint("/api/v1/import"))
+	// 请求提前结束时解除转换协程的阻塞
+	_ = pr.Close()
+	if cerr := <-convErr; cerr != nil && !errors.Is(cerr, io.ErrClosedPipe) {
+		return fmt.Errorf("%s: %w", op, cerr)
+	}
+	if err != nil {
+		return fmt.Errorf("%s request failed: %w", op, err)
+	}
+
+	if resp.StatusCode() != 204 && resp.StatusCode() != 200 {
+		return c.statusError(op, resp)
+	}
+	return nil
+}
+
+// importRequest 创建导入请求，添加 extra_label 参数
+func (c *restyClient) importRequest(ctx context.Context, body io.Reader, contentType string, opts *ImportOptions) *resty.Request {
+	req := c.client.R().
+		SetContext(ctx).
+		SetBody(body).
+		SetHeader("Content-Type", contentType)
+	if opts != nil {
+		setExtraParams(req, opts.ExtraLabels, nil)
+	}
+	return req
+}
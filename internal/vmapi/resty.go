@@ -6,33 +6,57 @@ import (
 	"crypto/x509"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"os"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/go-resty/resty/v2"
+
+	"github.com/lwmacct/251203-vm-metrics/internal/util"
 )
 
 // restyClient go-resty 实现的 VictoriaMetrics 客户端
 type restyClient struct {
-	client  *resty.Client
-	baseURL string
+	client    *resty.Client
+	baseURL   string
+	selectURL string // 读请求基础地址，单节点模式下等于 baseURL
+	insertURL string // 写请求基础地址，单节点模式下等于 baseURL
+	deleteURL string // 删除请求基础地址，单节点模式下等于 baseURL
+	influxURL string // Influx 写入地址，单节点模式下为 baseURL + /write
+
+	breaker          *circuitBreaker
+	retryStatusCodes []int
 }
 
 // NewClient 创建新的 VictoriaMetrics 客户端
 func NewClient(cfg *ClientConfig) (Client, error) {
 	// 构建 baseURL，支持路径前缀
-	baseURL := strings.TrimSuffix(cfg.URL, "/")
-	if cfg.PathPrefix != "" {
-		baseURL = baseURL + "/" + strings.Trim(cfg.PathPrefix, "/")
+	baseURL := joinBaseURL(cfg.URL, cfg.PathPrefix)
+	selectURL, insertURL, deleteURL := baseURL, baseURL, baseURL
+	influxURL := baseURL + "/write"
+
+	// 集群模式: 读请求走 vmselect，写请求走 vminsert
+	if cfg.IsCluster() {
+		tenant, err := parseTenantID(cfg.TenantID)
+		if err != nil {
+			return nil, err
+		}
+		selectURL = joinBaseURL(util.FirstNonEmpty(cfg.SelectURL, cfg.URL), cfg.PathPrefix) + "/select/" + tenant + "/prometheus"
+		insertURL = joinBaseURL(util.FirstNonEmpty(cfg.InsertURL, cfg.URL), cfg.PathPrefix) + "/insert/" + tenant + "/prometheus"
+		deleteURL = joinBaseURL(util.FirstNonEmpty(cfg.SelectURL, cfg.URL), cfg.PathPrefix) + "/delete/" + tenant + "/prometheus"
+		influxURL = joinBaseURL(util.FirstNonEmpty(cfg.InsertURL, cfg.URL), cfg.PathPrefix) + "/insert/" + tenant + "/influx/write"
 	}
 
 	client := resty.New().
 		SetBaseURL(baseURL).
 		SetTimeout(cfg.Timeout).
 		SetHeader("Accept", "application/json").
-		SetDisableWarn(true)
+		SetDisableWarn(true).
+		SetLogger(slogLogger{})
+
+	breaker := setupRetry(client, cfg)
 
 	// 配置认证
 	switch cfg.AuthType {
@@ -52,11 +76,88 @@ func NewClient(cfg *ClientConfig) (Client, error) {
 	}
 
 	return &restyClient{
-		client:  client,
-		baseURL: cfg.URL,
+		client:    client,
+		baseURL:   baseURL,
+		selectURL: selectURL,
+		insertURL: insertURL,
+		deleteURL: deleteURL,
+		influxURL: influxURL,
+
+		breaker:          breaker,
+		retryStatusCodes: cfg.RetryStatusCodes,
 	}, nil
 }
 
+// slogLogger 将 resty 日志 (重试告警等) 转为 slog Debug 输出，避免干扰命令输出
+type slogLogger struct{}
+
+func (slogLogger) Errorf(format string, v ...any) { slog.Debug(fmt.Sprintf(format, v...)) }
+func (slogLogger) Warnf(format string, v ...any)  { slog.Debug(fmt.Sprintf(format, v...)) }
+func (slogLogger) Debugf(format string, v ...any) { slog.Debug(fmt.Sprintf(format, v...)) }
+
+// joinBaseURL 拼接服务器地址与路径前缀
+func joinBaseURL(url, pathPrefix string) string {
+	baseURL := strings.TrimSuffix(url, "/")
+	if pathPrefix != "" {
+		baseURL = baseURL + "/" + strings.Trim(pathPrefix, "/")
+	}
+	return baseURL
+}
+
+// parseTenantID 校验并规范化租户 ID
+// 支持 "accountID" 或 "accountID:projectID"，空字符串视为 "0"
+func parseTenantID(s string) (string, error) {
+	if s == "" {
+		return "0", nil
+	}
+	parts := strings.Split(s, ":")
+	if len(parts) > 2 {
+		return "", fmt.Errorf("invalid tenant ID: %s (use accountID or accountID:projectID)", s)
+	}
+	for _, p := range parts {
+		if _, err := strconv.ParseUint(p, 10, 32); err != nil {
+			return "", fmt.Errorf("invalid tenant ID: %s (use accountID or accountID:projectID)", s)
+		}
+	}
+	return s, nil
+}
+
+// selectEndpoint 返回读请求 (query/export/series 等) 的完整地址
+func (c *restyClient) selectEndpoint(path string) string {
+	return c.selectURL + path
+}
+
+// insertEndpoint 返回写请求 (import) 的完整地址
+func (c *restyClient) insertEndpoint(path string) string {
+	return c.insertURL + path
+}
+
+// deleteEndpoint 返回删除请求 (delete_series) 的完整地址，集群模式下由 vmselect 处理
+func (c *restyClient) deleteEndpoint(path string) string {
+	return c.deleteURL + path
+}
+
+// setExtraParams 添加 extra_label 与 extra_filters[] 参数 (均可重复)
+func setExtraParams(req *resty.Request, extraLabels, extraFilters []string) {
+	for _, l := range extraLabels {
+		req.QueryParam.Add("extra_label", l)
+	}
+	for _, f := range extraFilters {
+		req.QueryParam.Add("extra_filters[]", f)
+	}
+}
+
+// setQueryOptions 添加查询附加参数，opts 可为 nil
+func setQueryOptions(req *resty.Request, opts *QueryOptions) {
+	if opts == nil {
+		return
+	}
+	setExtraParams(req, opts.ExtraLabels, opts.ExtraFilters)
+	if opts.NoCache {
+		req.SetQueryParam("nocache", "1")
+	}
+}
+
 // buildTLSConfig 构建 TLS 配置
 func buildTLSConfig(cfg *ClientConfig) (*tls.Config, error) {
 	tlsConfig := &tls.Config{
@@ -89,7 +190,7 @@ func buildTLSConfig(cfg *ClientConfig) (*tls.Config, error) {
 }
 
 // Query 执行即时查询
-func (c *restyClient) Query(ctx context.Context, query string, ts time.Time) (*QueryResult, error) {
+func (c *restyClient) Query(ctx context.Context, query string, ts time.Time, opts *QueryOptions) (*QueryResult, error) {
 	params := map[string]string{
 		"query": query,
 	}
@@ -97,10 +198,12 @@ func (c *restyClient) Query(ctx context.Context, query string, ts time.Time) (*Q
 		params["time"] = formatTime(ts)
 	}
 
-	resp, err := c.client.R().
+	req := c.client.R().
 		SetContext(ctx).
-		SetQueryParams(params).
-		Get("/api/v1/query")
+		SetQueryParams(params)
+	setQueryOptions(req, opts)
+
+	resp, err := req.Get(c.selectEndpoint("/api/v1/query"))
 	if err != nil {
 		return nil, fmt.Errorf("query request failed: %w", err)
 	}
@@ -109,7 +212,7 @@ func (c *restyClient) Query(ctx context.Context, query string, ts time.Time) (*Q
 }
 
 // QueryRange 执行范围查询
-func (c *restyClient) QueryRange(ctx context.Context, query string, start, end time.Time, step time.Duration) (*QueryResult, error) {
+func (c *restyClient) QueryRange(ctx context.Context, query string, start, end time.Time, step time.Duration, opts *QueryOptions) (*QueryResult, error) {
 	params := map[string]string{
 		"query": query,
 		"start": formatTime(start),
@@ -117,10 +220,12 @@ func (c *restyClient) QueryRange(ctx context.Context, query string, start, end t
 		"step":  formatDuration(step),
 	}
 
-	resp, err := c.client.R().
+	req := c.client.R().
 		SetContext(ctx).
-		SetQueryParams(params).
-		Get("/api/v1/query_range")
+		SetQueryParams(params)
+	setQueryOptions(req, opts)
+
+	resp, err := req.Get(c.selectEndpoint("/api/v1/query_range"))
 	if err != nil {
 		return nil, fmt.Errorf("query_range request failed: %w", err)
 	}
@@ -129,13 +234,14 @@ func (c *restyClient) QueryRange(ctx context.Context, query string, start, end t
 }
 
 // Series 获取时间序列
-func (c *restyClient) Series(ctx context.Context, match []string, start, end time.Time) (*SeriesResult, error) {
+func (c *restyClient) Series(ctx context.Context, match []string, start, end time.Time, opts *QueryOptions) (*SeriesResult, error) {
 	req := c.client.R().SetContext(ctx)
 
 	// match[] 参数可以有多个
 	for _, m := range match {
 		req.QueryParam.Add("match[]", m)
 	}
+	setQueryOptions(req, opts)
 
 	if !start.IsZero() {
 		req.SetQueryParam("start", formatTime(start))
@@ -144,7 +250,7 @@ func (c *restyClient) Series(ctx context.Context, match []string, start, end tim
 		req.SetQueryParam("end", formatTime(end))
 	}
 
-	resp, err := req.Get("/api/v1/series")
+	resp, err := req.Get(c.selectEndpoint("/api/v1/series"))
 	if err != nil {
 		return nil, fmt.Errorf("series request failed: %w", err)
 	}
@@ -166,8 +272,9 @@ func (c *restyClient) Series(ctx context.Context, match []string, start, end tim
 }
 
 // Labels 获取所有标签名称
-func (c *restyClient) Labels(ctx context.Context, start, end time.Time) (*LabelsResult, error) {
+func (c *restyClient) Labels(ctx context.Context, start, end time.Time, opts *QueryOptions) (*LabelsResult, error) {
 	req := c.client.R().SetContext(ctx)
+	setQueryOptions(req, opts)
 
 	if !start.IsZero() {
 		req.SetQueryParam("start", formatTime(start))
@@ -176,7 +283,7 @@ func (c *restyClient) Labels(ctx context.Context, start, end time.Time) (*Labels
 		req.SetQueryParam("end", formatTime(end))
 	}
 
-	resp, err := req.Get("/api/v1/labels")
+	resp, err := req.Get(c.selectEndpoint("/api/v1/labels"))
 	if err != nil {
 		return nil, fmt.Errorf("labels request failed: %w", err)
 	}
@@ -198,8 +305,9 @@ func (c *restyClient) Labels(ctx context.Context, start, end time.Time) (*Labels
 }
 
 // LabelValues 获取指定标签的所有值
-func (c *restyClient) LabelValues(ctx context.Context, label string, start, end time.Time) (*LabelValuesResult, error) {
+func (c *restyClient) LabelValues(ctx context.Context, label string, start, end time.Time, opts *QueryOptions) (*LabelValuesResult, error) {
 	req := c.client.R().SetContext(ctx)
+	setQueryOptions(req, opts)
 
 	if !start.IsZero() {
 		req.SetQueryParam("start", formatTime(start))
@@ -208,7 +316,7 @@ func (c *restyClient) LabelValues(ctx context.Context, label string, start, end
 		req.SetQueryParam("end", formatTime(end))
 	}
 
-	resp, err := req.Get(fmt.Sprintf("/api/v1/label/%s/values", label))
+	resp, err := req.Get(c.selectEndpoint(fmt.Sprintf("/api/v1/label/%s/values", label)))
 	if err != nil {
 		return nil, fmt.Errorf("label_values request failed: %w", err)
 	}
@@ -0,0 +1,222 @@
+package vmapi
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lwmacct/251203-vm-metrics/internal/lineproto"
+)
+
+// TestExtraParams 验证读写请求都带上重复的 extra_label 与 extra_filters[] 参数
+func TestExtraParams(t *testing.T) {
+	var got url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.URL.Query()
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/api/v1/import") || r.URL.Path == "/write":
+			w.WriteHeader(http.StatusNoContent)
+		case strings.HasPrefix(r.URL.Path, "/api/v1/export"):
+			_, _ = io.WriteString(w, "")
+		case strings.HasPrefix(r.URL.Path, "/api/v1/query"):
+			_, _ = io.WriteString(w, `{"status":"success","data":{"resultType":"vector","result":[]}}`)
+		case r.URL.Path == "/api/v1/status/tsdb":
+			_, _ = io.WriteString(w, `{"status":"success","data":{}}`)
+		default:
+			_, _ = io.WriteString(w, `{"status":"success","data":[]}`)
+		}
+	}))
+	defer srv.Close()
+
+	client, err := NewClient(&ClientConfig{URL: srv.URL, Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exporter := client.(Exporter)
+	importer := client.(Importer)
+	admin := client.(Admin)
+
+	labels := []string{"team=a", "env=prod"}
+	filters := []string{`{job=~"a|b",x="1"}`, `{y="2"}`}
+	qo := &QueryOptions{ExtraLabels: labels, ExtraFilters: filters}
+	eo := &ExportOptions{Match: []string{"up"}, ExtraLabels: labels, ExtraFilters: filters}
+	imp := &ImportOptions{ExtraLabels: labels}
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+	body := func() *strings.Reader { return strings.NewReader("x 1\n") }
+
+	tests := []struct {
+		name        string
+		call        func() error
+		wantFilters bool
+	}{
+		{"query", func() error { _, err := client.Query(ctx, "up", now, qo); return err }, true},
+		{"query_range", func() error {
+			_, err := client.QueryRange(ctx, "up", now.Add(-time.Hour), now, time.Minute, qo)
+			return err
+		}, true},
+		{"series", func() error { _, err := client.Series(ctx, []string{"up"}, now, now, qo); return err }, true},
+		{"labels", func() error { _, err := client.Labels(ctx, now, now, qo); return err }, true},
+		{"label values", func() error { _, err := client.LabelValues(ctx, "job", now, now, qo); return err }, true},
+		{"tsdb status", func() error {
+			_, err := admin.TSDBStatus(ctx, &TSDBStatusOptions{ExtraLabels: labels, ExtraFilters: filters})
+			return err
+		}, true},
+		{"export json", func() error { return exporter.ExportJSON(ctx, io.Discard, eo) }, true},
+		{"export csv", func() error { return exporter.ExportCSV(ctx, io.Discard, eo) }, true},
+		{"export native", func() error { return exporter.ExportNative(ctx, io.Discard, eo) }, true},
+		{"import json", func() error { return importer.ImportJSON(ctx, body(), imp) }, false},
+		{"import csv", func() error { return importer.ImportCSV(ctx, body(), imp) }, false},
+		{"import native", func() error { return importer.ImportNative(ctx, body(), imp) }, false},
+		{"import prometheus", func() error { return importer.ImportPrometheus(ctx, body(), imp) }, false},
+		{"import influx", func() error { return importer.ImportInflux(ctx, body(), imp) }, false},
+		{"import graphite", func() error { return importer.ImportGraphite(ctx, body(), imp) }, false},
+		{"import opentsdb", func() error {
+			return importer.ImportOpenTSDB(ctx, strings.NewReader(`{"metric":"x","timestamp":1,"value":1,"tags":{"a":"b"}}`), imp)
+		}, false},
+	}
+	for _, tt := range tests {
+		got = nil
+		if err := tt.call(); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !slices.Equal(got["extra_label"], labels) {
+			t.Errorf("%s: extra_label = %q, want %q", tt.name, got["extra_label"], labels)
+		}
+		wantFilters := filters
+		if !tt.wantFilters {
+			wantFilters = nil
+		}
+		if !slices.Equal(got["extra_filters[]"], wantFilters) {
+			t.Errorf("%s: extra_filters[] = %q, want %q", tt.name, got["extra_filters[]"], wantFilters)
+		}
+	}
+}
+
+// TestNewClientURLs 验证路径前缀、集群租户与尾部斜杠组合出的读写删除地址
+func TestNewClientURLs(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     ClientConfig
+		want    [4]string // select, insert, delete, influx
+		wantErr bool
+	}{
+		{
+			name: "single node",
+			cfg:  ClientConfig{URL: "http://vm:8428/"},
+			want: [4]string{"http://vm:8428", "http://vm:8428", "http://vm:8428", "http://vm:8428/write"},
+		},
+		{
+			name: "single node with prefix",
+			cfg:  ClientConfig{URL: "http://vm:8428/", PathPrefix: "/victoria/"},
+			want: [4]string{"http://vm:8428/victoria", "http://vm:8428/victoria", "http://vm:8428/victoria", "http://vm:8428/victoria/write"},
+		},
+		{
+			name: "cluster default tenant",
+			cfg:  ClientConfig{URL: "http://lb", SelectURL: "http://vmselect:8481/", InsertURL: "http://vminsert:8480"},
+			want: [4]string{
+				"http://vmselect:8481/select/0/prometheus",
+				"http://vminsert:8480/insert/0/prometheus",
+				"http://vmselect:8481/delete/0/prometheus",
+				"http://vminsert:8480/insert/0/influx/write",
+			},
+		},
+		{
+			name: "cluster tenant with project and prefix",
+			cfg:  ClientConfig{URL: "http://lb/", PathPrefix: "vm", TenantID: "12:34"},
+			want: [4]string{
+				"http://lb/vm/select/12:34/prometheus",
+				"http://lb/vm/insert/12:34/prometheus",
+				"http://lb/vm/delete/12:34/prometheus",
+				"http://lb/vm/insert/12:34/influx/write",
+			},
+		},
+		{
+			name: "cluster select only",
+			cfg:  ClientConfig{URL: "http://lb", SelectURL: "http://vmselect", TenantID: "5"},
+			want: [4]string{
+				"http://vmselect/select/5/prometheus",
+				"http://lb/insert/5/prometheus",
+				"http://vmselect/delete/5/prometheus",
+				"http://lb/insert/5/influx/write",
+			},
+		},
+		{name: "invalid tenant", cfg: ClientConfig{URL: "http://lb", TenantID: "a:b"}, wantErr: true},
+		{name: "too many tenant parts", cfg: ClientConfig{URL: "http://lb", TenantID: "1:2:3"}, wantErr: true},
+		{name: "negative tenant", cfg: ClientConfig{URL: "http://lb", TenantID: "-1"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewClient(&tt.cfg)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("want error for invalid tenant")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			c := client.(*restyClient)
+			got := [4]string{c.selectURL, c.insertURL, c.deleteURL, c.influxURL}
+			if got != tt.want {
+				t.Errorf("urls = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestImportConverted 验证 Graphite 与 OpenTSDB 在客户端转换为 JSON Line 后写入 /api/v1/import
+func TestImportConverted(t *testing.T) {
+	var path, body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		path, body = r.URL.Path, string(data)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	client, err := NewClient(&ClientConfig{URL: srv.URL, Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	importer := client.(Importer)
+	tmpl, err := lineproto.ParseGraphiteTemplate("servers.*.cpu.* host=$1 metric=$2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		call func() error
+		want string
+	}{
+		{"graphite", func() error {
+			return importer.ImportGraphite(ctx, strings.NewReader("servers.a.cpu.idle 5 1700000000\n"), &ImportOptions{GraphiteTemplates: []*lineproto.GraphiteTemplate{tmpl}})
+		}, `{"metric":{"__name__":"idle","host":"a"},"values":[5],"timestamps":[1700000000000]}` + "\n"},
+		{"opentsdb", func() error {
+			return importer.ImportOpenTSDB(ctx, strings.NewReader(`[{"metric":"sys.cpu","timestamp":1700000000,"value":1,"tags":{"host":"a"}}]`), nil)
+		}, `{"metric":{"__name__":"sys.cpu","host":"a"},"values":[1],"timestamps":[1700000000000]}` + "\n"},
+	}
+	for _, tt := range tests {
+		if err := tt.call(); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if path != "/api/v1/import" || body != tt.want {
+			t.Errorf("%s: %s %q, want /api/v1/import %q", tt.name, path, body, tt.want)
+		}
+	}
+
+	if err := importer.ImportGraphite(ctx, strings.NewReader("ok 1 1\nbad\n"), nil); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("err = %v, want line 2 error", err)
+	}
+}
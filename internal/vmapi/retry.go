@@ -0,0 +1,211 @@
+package vmapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// ErrCircuitOpen 熔断器打开时请求被直接拒绝
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// noRetryKey 标记禁用自动重试的请求
+type noRetryKey struct{}
+
+// WithoutRetry 返回禁用客户端自动重试的 context
+// 用于调用方自行重试的请求 (如分批导入的批次)，避免两层重试叠加
+func WithoutRetry(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noRetryKey{}, true)
+}
+
+// retryDisabled 请求是否禁用了自动重试
+func retryDisabled(ctx context.Context) bool {
+	v, _ := ctx.Value(noRetryKey{}).(bool)
+	return v
+}
+
+// setupRetry 配置重试策略与熔断器，返回熔断器供流式响应记录结果
+// - GET 等无请求体的请求自动重试，WithoutRetry 标记的请求除外
+// - 带请求体的请求 (导入) 仅在请求体可 Seek 时重试，重试前回到起点
+// - 优先使用响应中的 Retry-After，否则按指数退避加随机抖动等待
+func setupRetry(client *resty.Client, cfg *ClientConfig) *circuitBreaker {
+	breaker := newCircuitBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown)
+
+	client.OnBeforeRequest(func(_ *resty.Client, r *resty.Request) error {
+		if err := breaker.allow(); err != nil {
+			return err
+		}
+		if r.Attempt > 1 {
+			if s, ok := r.Body.(io.Seeker); ok {
+				if _, err := s.Seek(0, io.SeekStart); err != nil {
+					return fmt.Errorf("failed to rewind request body: %w", err)
+				}
+			}
+		}
+		return nil
+	})
+	// 流式响应 (SetDoNotParseResponse) 不经过 OnAfterResponse，由 recordStream 记录
+	client.OnAfterResponse(func(_ *resty.Client, resp *resty.Response) error {
+		breaker.record(!slices.Contains(cfg.RetryStatusCodes, resp.StatusCode()))
+		return nil
+	})
+	client.OnError(func(_ *resty.Request, err error) {
+		if !errors.Is(err, ErrCircuitOpen) && IsNetworkError(err) {
+			breaker.record(false)
+		}
+	})
+
+	if cfg.RetryMaxAttempts <= 1 {
+		return breaker
+	}
+
+	client.
+		SetRetryCount(cfg.RetryMaxAttempts - 1).
+		SetRetryWaitTime(cfg.RetryWait).
+		SetRetryMaxWaitTime(cfg.RetryMaxWait).
+		SetRetryAfter(retryAfter).
+		AddRetryCondition(func(resp *resty.Response, err error) bool {
+			if errors.Is(err, ErrCircuitOpen) {
+				return false
+			}
+			if resp != nil && (retryDisabled(resp.Request.Context()) || !replayable(resp.Request.Body)) {
+				return false
+			}
+			if err != nil {
+				return cfg.RetryNetworkErrors && IsNetworkError(err)
+			}
+			return resp != nil && slices.Contains(cfg.RetryStatusCodes, resp.StatusCode())
+		}).
+		AddRetryHook(func(resp *resty.Response, _ error) {
+			// 流式响应 (SetDoNotParseResponse) 需要手动关闭被丢弃的响应体
+			// 最后一次尝试的响应体留给调用方读取错误信息
+			if resp != nil && resp.RawResponse != nil && resp.Request.Attempt < cfg.RetryMaxAttempts {
+				_ = resp.RawResponse.Body.Close()
+			}
+		})
+	return breaker
+}
+
+// retryableStatus 状态码是否属于配置的可重试列表
+// 自动重试、分批导入的重试与熔断器使用同一列表
+func (c *restyClient) retryableStatus(code int) bool {
+	return slices.Contains(c.retryStatusCodes, code)
+}
+
+// statusError 创建 StatusError，按配置的可重试列表标记是否为临时错误
+func (c *restyClient) statusError(op string, resp *resty.Response) *StatusError {
+	return &StatusError{Op: op, StatusCode: resp.StatusCode(), Body: resp.String(), Retryable: c.retryableStatus(resp.StatusCode())}
+}
+
+// recordStream 记录流式响应的结果 (resty 对其不执行 OnAfterResponse)
+// 网络错误已由 OnError 记录，这里只处理收到的响应
+func (c *restyClient) recordStream(resp *resty.Response) {
+	c.breaker.record(!c.retryableStatus(resp.StatusCode()))
+}
+
+// retryAfter 解析 Retry-After 响应头 (秒数或 HTTP 日期)
+// 返回 0 表示使用默认的指数退避
+func retryAfter(_ *resty.Client, resp *resty.Response) (time.Duration, error) {
+	v := resp.Header().Get("Retry-After")
+	if v == "" {
+		return 0, nil
+	}
+	if sec, err := strconv.Atoi(v); err == nil && sec > 0 {
+		return time.Duration(sec) * time.Second, nil
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d, nil
+		}
+	}
+	return 0, nil
+}
+
+// replayable 请求体能否在重试时重新发送
+func replayable(body any) bool {
+	switch b := body.(type) {
+	case nil, []byte, string:
+		return true
+	case io.Seeker:
+		// stdin 等管道实现了 Seek 但无法定位
+		_, err := b.Seek(0, io.SeekCurrent)
+		return err == nil
+	case io.Reader:
+		return false
+	default:
+		// 结构体等由 resty 序列化的请求体
+		return true
+	}
+}
+
+// IsNetworkError 是否为值得重试的网络错误 (与客户端自动重试使用同一判断)
+// 包括连接失败、连接被重置、服务端提前关闭连接；不包括客户端超时
+func IsNetworkError(err error) bool {
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsTemporary
+}
+
+// circuitBreaker 连续失败达到阈值后熔断，冷却期内直接拒绝请求
+// 冷却期结束后放行请求，成功则恢复，失败则再次熔断
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int // 0 表示禁用
+	cooldown  time.Duration
+	failures  int
+	openUntil time.Time
+}
+
+// newCircuitBreaker 创建熔断器
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown}
+}
+
+// allow 检查是否允许发出请求
+func (b *circuitBreaker) allow() error {
+	if b.threshold <= 0 {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if wait := time.Until(b.openUntil); wait > 0 {
+		return fmt.Errorf("%w: %d consecutive failures, retry in %s", ErrCircuitOpen, b.failures, wait.Round(time.Second))
+	}
+	return nil
+}
+
+// record 记录一次请求结果
+func (b *circuitBreaker) record(ok bool) {
+	if b.threshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if ok {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openUntil = time.Now().Add(b.cooldown)
+	}
+}
@@ -0,0 +1,136 @@
+package vmapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lwmacct/251203-vm-metrics/internal/config"
+)
+
+// TestRetry 验证状态码重试、Retry-After 与请求体重放
+func TestRetry(t *testing.T) {
+	var calls atomic.Int32
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		data, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(data))
+		if n == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	client, err := NewClient(&ClientConfig{
+		URL:              srv.URL,
+		Timeout:          5 * time.Second,
+		RetryMaxAttempts: 3,
+		RetryWait:        10 * time.Millisecond,
+		RetryMaxWait:     2 * time.Second,
+		RetryStatusCodes: config.DefaultConfig().Server.RetryStatusCodes,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	importer := client.(Importer)
+
+	// 可 Seek 的请求体重试时完整重放
+	started := time.Now()
+	if err := importer.ImportJSON(context.Background(), bytes.NewReader([]byte("line\n")), nil); err != nil {
+		t.Fatalf("ImportJSON: %v", err)
+	}
+	if calls.Load() != 2 || bodies[1] != "line\n" {
+		t.Errorf("calls = %d, bodies = %q", calls.Load(), bodies)
+	}
+	if time.Since(started) < time.Second {
+		t.Errorf("Retry-After 未生效")
+	}
+
+	// 不可重放的请求体不重试
+	calls.Store(0)
+	bodies = nil
+	err = importer.ImportJSON(context.Background(), io.MultiReader(bytes.NewReader([]byte("line\n"))), nil)
+	if err == nil || calls.Load() != 1 {
+		t.Errorf("err = %v, calls = %d, want 503 without retry", err, calls.Load())
+	}
+
+	// WithoutRetry 禁用自动重试，错误按配置的列表标记为可重试
+	calls.Store(0)
+	err = importer.ImportJSON(WithoutRetry(context.Background()), bytes.NewReader([]byte("line\n")), nil)
+	var se *StatusError
+	if !errors.As(err, &se) || !se.Temporary() || calls.Load() != 1 {
+		t.Errorf("err = %v, calls = %d, want retryable 503 without retry", err, calls.Load())
+	}
+}
+
+// TestRetryNotIdempotent 验证快照创建不自动重试、流式导出计入熔断器
+func TestRetryNotIdempotent(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	client, err := NewClient(&ClientConfig{
+		URL:              srv.URL,
+		Timeout:          5 * time.Second,
+		RetryMaxAttempts: 3,
+		RetryWait:        time.Millisecond,
+		RetryMaxWait:     time.Millisecond,
+		RetryStatusCodes: config.DefaultConfig().Server.RetryStatusCodes,
+		BreakerThreshold: 2,
+		BreakerCooldown:  time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.(Admin).SnapshotCreate(context.Background()); err == nil || calls.Load() != 1 {
+		t.Errorf("SnapshotCreate err = %v, calls = %d, want 1 call", err, calls.Load())
+	}
+
+	// 导出的 503 记为失败，达到阈值后熔断
+	exporter := client.(Exporter)
+	if err := exporter.ExportJSON(context.Background(), io.Discard, &ExportOptions{Match: []string{"up"}}); err == nil {
+		t.Fatal("ExportJSON 应返回错误")
+	}
+	err = exporter.ExportJSON(context.Background(), io.Discard, &ExportOptions{Match: []string{"up"}})
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("err = %v, want ErrCircuitOpen", err)
+	}
+}
+
+// TestCircuitBreaker 验证连续失败后熔断、冷却后恢复
+func TestCircuitBreaker(t *testing.T) {
+	b := newCircuitBreaker(2, 50*time.Millisecond)
+
+	b.record(false)
+	if err := b.allow(); err != nil {
+		t.Fatalf("未达到阈值时不应熔断: %v", err)
+	}
+	b.record(false)
+	if err := b.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("err = %v, want ErrCircuitOpen", err)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if err := b.allow(); err != nil {
+		t.Fatalf("冷却后应放行: %v", err)
+	}
+	b.record(true)
+	b.record(false)
+	if err := b.allow(); err != nil {
+		t.Fatalf("成功后计数应清零: %v", err)
+	}
+}
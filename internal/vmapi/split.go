@@ -0,0 +1,65 @@
+package vmapi
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lwmacct/251203-vm-metrics/internal/util"
+)
+
+// RangePoints 返回范围查询 [start, end] 按 step 采样时每个序列的点数
+func RangePoints(start, end time.Time, step time.Duration) int {
+	if step <= 0 || end.Before(start) {
+		return 0
+	}
+	return int(end.Sub(start)/step) + 1
+}
+
+// QueryRangeSplit 执行范围查询，点数超过 maxPoints 时按时间切分为多个请求顺序执行，
+// 再按序列拼接各段的 Values。maxPoints <= 0 或点数未超出时等同于 QueryRange
+//
+// 各段以 step 对齐首尾相接，不会重复请求同一时间点；服务端对齐 start/end 导致边界重叠时，
+// 拼接时丢弃不晚于已有最后一个点的样本
+func QueryRangeSplit(ctx context.Context, c Client, query string, start, end time.Time, step time.Duration, maxPoints int, opts *QueryOptions) (*QueryResult, error) {
+	if maxPoints <= 0 || RangePoints(start, end, step) <= maxPoints {
+		return c.QueryRange(ctx, query, start, end, step, opts)
+	}
+
+	merged := &QueryResult{ResultType: "matrix"}
+	index := make(map[string]int) // 序列 key -> merged.Samples 下标
+	span := time.Duration(maxPoints-1) * step
+
+	for chunkStart := start; !chunkStart.After(end); chunkStart = chunkStart.Add(span + step) {
+		chunkEnd := chunkStart.Add(span)
+		if chunkEnd.After(end) {
+			chunkEnd = end
+		}
+
+		result, err := c.QueryRange(ctx, query, chunkStart, chunkEnd, step, opts)
+		if err != nil {
+			return nil, fmt.Errorf("range %s - %s: %w", chunkStart.Format(time.RFC3339), chunkEnd.Format(time.RFC3339), err)
+		}
+		if result.ResultType != "matrix" {
+			return nil, fmt.Errorf("cannot merge %s results of split range query", result.ResultType)
+		}
+
+		for _, s := range result.Samples {
+			key := util.SeriesKey(s.Metric)
+			i, ok := index[key]
+			if !ok {
+				index[key] = len(merged.Samples)
+				merged.Samples = append(merged.Samples, s)
+				continue
+			}
+			dst := &merged.Samples[i]
+			for _, v := range s.Values {
+				if n := len(dst.Values); n > 0 && !v.Timestamp.After(dst.Values[n-1].Timestamp) {
+					continue
+				}
+				dst.Values = append(dst.Values, v)
+			}
+		}
+	}
+	return merged, nil
+}
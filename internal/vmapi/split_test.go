@@ -0,0 +1,75 @@
+package vmapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestQueryRangeSplit 验证长范围按点数切分请求，并按序列拼接结果
+func TestQueryRangeSplit(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		start, _ := strconv.ParseFloat(r.FormValue("start"), 64)
+		end, _ := strconv.ParseFloat(r.FormValue("end"), 64)
+		step, _ := strconv.ParseFloat(r.FormValue("step"), 64)
+
+		// 模拟服务端将 start 向下对齐到 step，产生与上一段重叠的边界点
+		start -= step
+
+		var values []string
+		for ts := start; ts <= end; ts += step {
+			values = append(values, fmt.Sprintf(`[%g,"%g"]`, ts, ts))
+		}
+		series := fmt.Sprintf(`{"metric":{"__name__":"up","job":"a"},"values":[%s]}`, strings.Join(values, ","))
+		// 第二个序列只出现在后半段
+		if start >= 1000+10*60 {
+			series += fmt.Sprintf(`,{"metric":{"__name__":"up","job":"b"},"values":[%s]}`, strings.Join(values, ","))
+		}
+		_, _ = fmt.Fprintf(w, `{"status":"success","data":{"resultType":"matrix","result":[%s]}}`, series)
+	}))
+	defer srv.Close()
+
+	client, err := NewClient(&ClientConfig{URL: srv.URL, Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Unix(1000, 0)
+	end := start.Add(25 * time.Minute)
+	result, err := QueryRangeSplit(context.Background(), client, "up", start, end, time.Minute, 10, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if requests != 3 {
+		t.Errorf("requests = %d, want 3", requests)
+	}
+	if len(result.Samples) != 2 {
+		t.Fatalf("series = %d, want 2", len(result.Samples))
+	}
+	values := result.Samples[0].Values
+	if len(values) != 27 {
+		t.Errorf("points = %d, want 27 (26 plus the aligned point before start)", len(values))
+	}
+	for i := 1; i < len(values); i++ {
+		if !values[i].Timestamp.After(values[i-1].Timestamp) {
+			t.Fatalf("timestamps not strictly increasing at %d: %v", i, values[i].Timestamp)
+		}
+	}
+
+	// 未超出点数上限时只发送一次请求
+	requests = 0
+	if _, err := QueryRangeSplit(context.Background(), client, "up", start, end, time.Minute, 100, nil); err != nil {
+		t.Fatal(err)
+	}
+	if requests != 1 {
+		t.Errorf("requests = %d, want 1", requests)
+	}
+}
@@ -105,3 +105,21 @@ type LabelsResult struct {
 type LabelValuesResult struct {
 	Values []string
 }
+
+// StatusError 服务端返回非成功状态码
+type StatusError struct {
+	Op         string // 操作名称，如 "import json"
+	StatusCode int
+	Body       string
+	Retryable  bool // 状态码属于配置的可重试列表 (server.retry_status_codes)
+}
+
+// Error 实现 error 接口
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s failed [%d]: %s", e.Op, e.StatusCode, e.Body)
+}
+
+// Temporary 是否为可重试的临时错误
+func (e *StatusError) Temporary() bool {
+	return e.Retryable
+}